@@ -2,6 +2,7 @@ package blame
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	mapset "github.com/deckarep/golang-set"
@@ -91,6 +92,34 @@ func (m *Manager) GetRoundCulprits(workId string, round uint32, allParties map[s
 	return culprits
 }
 
+// GetCulprits returns the culprits of all the rounds of a work, without duplicates.
+func (m *Manager) GetCulprits(workId string, allParties map[string]*tss.PartyID) []*tss.PartyID {
+	m.mgrLock.RLock()
+	defer m.mgrLock.RUnlock()
+
+	prefix := workId + ":"
+	culprits := make([]*tss.PartyID, 0)
+	found := make(map[string]struct{})
+	for key, roundCulprits := range m.roundCulprits {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		for _, culprit := range roundCulprits {
+			if _, ok := found[culprit.Id]; ok {
+				continue
+			}
+
+			if p, ok := allParties[culprit.Id]; ok {
+				found[culprit.Id] = struct{}{}
+				culprits = append(culprits, p)
+			}
+		}
+	}
+
+	return culprits
+}
+
 func (m *Manager) GetPreExecutionCulprits() []*tss.PartyID {
 	m.mgrLock.RLock()
 	defer m.mgrLock.RUnlock()
@@ -32,3 +32,24 @@ func TestManager_GetRoundCulprits(t *testing.T) {
 	sort.Strings(ids)
 	assert.Equal(t, []string{"1", "2"}, ids)
 }
+
+func TestManager_GetCulprits(t *testing.T) {
+	t.Parallel()
+
+	allParties := map[string]*tss.PartyID{
+		"0": {
+			MessageWrapper_PartyID: &tss.MessageWrapper_PartyID{Id: "0"},
+		},
+		"1": {
+			MessageWrapper_PartyID: &tss.MessageWrapper_PartyID{Id: "1"},
+		},
+	}
+	manager := NewManager()
+	manager.AddCulpritByRound("work", 1, []*tss.PartyID{allParties["1"]})
+	manager.AddCulpritByRound("work", 2, []*tss.PartyID{allParties["1"]})
+	manager.AddCulpritByRound("work2", 1, []*tss.PartyID{allParties["0"]})
+
+	culprits := manager.GetCulprits("work", allParties)
+	assert.Equal(t, []*tss.PartyID{allParties["1"]}, culprits)
+	assert.Empty(t, manager.GetCulprits("work1", allParties))
+}
@@ -0,0 +1,129 @@
+package blame
+
+import (
+	"sync"
+	"time"
+)
+
+// Offense is a kind of misbehavior of a peer.
+type Offense string
+
+const (
+	// The peer sends more messages than its rate limit.
+	OffenseRateLimited Offense = "rate_limited"
+)
+
+const (
+	// A peer whose score reaches this value is misbehaving and is excluded from new works.
+	maxOffenseScore = 100
+	// The score that a peer loses every second, so that a peer which stops misbehaving is forgiven.
+	offenseScoreDecay = 1
+)
+
+// Reputation counts the offenses of every peer. Unlike Manager which finds the culprits of a single
+// work, it keeps track of peers that misbehave outside of any work.
+type Reputation struct {
+	// Key: peer id, value: count of every offense.
+	offenses map[string]map[Offense]int
+	// Key: peer id. Every offense adds one to the score of a peer and the score decays over time.
+	scores map[string]*offenseScore
+
+	lock *sync.RWMutex
+
+	now func() time.Time
+}
+
+type offenseScore struct {
+	value float64
+	last  time.Time
+}
+
+func NewReputation() *Reputation {
+	return &Reputation{
+		offenses: make(map[string]map[Offense]int),
+		scores:   make(map[string]*offenseScore),
+		lock:     &sync.RWMutex{},
+		now:      time.Now,
+	}
+}
+
+// ReportOffense records an offense of a peer.
+func (r *Reputation) ReportOffense(peerId string, offense Offense) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.offenses[peerId]; !ok {
+		r.offenses[peerId] = make(map[Offense]int)
+	}
+
+	r.offenses[peerId][offense]++
+
+	now := r.now()
+	score, ok := r.scores[peerId]
+	if !ok {
+		score = &offenseScore{last: now}
+		r.scores[peerId] = score
+	}
+	score.value = decayScore(score, now) + 1
+	score.last = now
+}
+
+// IsMisbehaving returns true if a peer has committed too many offenses recently.
+func (r *Reputation) IsMisbehaving(peerId string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	score, ok := r.scores[peerId]
+	if !ok {
+		return false
+	}
+
+	return decayScore(score, r.now()) >= maxOffenseScore
+}
+
+// GetOffenses returns the count of every offense of a peer.
+func (r *Reputation) GetOffenses(peerId string) map[Offense]int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	offenses := make(map[Offense]int, len(r.offenses[peerId]))
+	for offense, count := range r.offenses[peerId] {
+		offenses[offense] = count
+	}
+
+	return offenses
+}
+
+// GetOffenseCount returns the total number of offenses of a peer.
+func (r *Reputation) GetOffenseCount(peerId string) int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	total := 0
+	for _, count := range r.offenses[peerId] {
+		total += count
+	}
+
+	return total
+}
+
+// RemovePeer forgets all the offenses of a peer.
+func (r *Reputation) RemovePeer(peerId string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.offenses, peerId)
+	delete(r.scores, peerId)
+}
+
+func decayScore(score *offenseScore, now time.Time) float64 {
+	value := score.value
+	if elapsed := now.Sub(score.last).Seconds(); elapsed > 0 {
+		value -= elapsed * offenseScoreDecay
+	}
+	if value < 0 {
+		value = 0
+	}
+
+	return value
+}
@@ -0,0 +1,52 @@
+package blame
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReputation_ReportOffense(t *testing.T) {
+	t.Parallel()
+
+	r := NewReputation()
+	r.ReportOffense("peer0", OffenseRateLimited)
+	r.ReportOffense("peer0", OffenseRateLimited)
+	r.ReportOffense("peer1", OffenseRateLimited)
+
+	assert.Equal(t, map[Offense]int{OffenseRateLimited: 2}, r.GetOffenses("peer0"))
+	assert.Equal(t, 2, r.GetOffenseCount("peer0"))
+	assert.Equal(t, 1, r.GetOffenseCount("peer1"))
+	assert.Equal(t, 0, r.GetOffenseCount("peer2"))
+
+	r.RemovePeer("peer0")
+	assert.Empty(t, r.GetOffenses("peer0"))
+}
+
+func TestReputation_IsMisbehaving(t *testing.T) {
+	t.Parallel()
+
+	r := NewReputation()
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	for i := 0; i < maxOffenseScore-1; i++ {
+		r.ReportOffense("peer0", OffenseRateLimited)
+	}
+	assert.False(t, r.IsMisbehaving("peer0"))
+
+	r.ReportOffense("peer0", OffenseRateLimited)
+	assert.True(t, r.IsMisbehaving("peer0"))
+	assert.False(t, r.IsMisbehaving("peer1"))
+
+	// The peer is forgiven after it stops misbehaving for a while.
+	now = now.Add(time.Second)
+	assert.False(t, r.IsMisbehaving("peer0"))
+
+	r.ReportOffense("peer0", OffenseRateLimited)
+	assert.True(t, r.IsMisbehaving("peer0"))
+
+	r.RemovePeer("peer0")
+	assert.False(t, r.IsMisbehaving("peer0"))
+}
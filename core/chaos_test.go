@@ -0,0 +1,96 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sisu-network/dheart/core/chaostest"
+	"github.com/sisu-network/dheart/core/config"
+)
+
+func TestChaos_EcKeygen(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	cfg := config.NewDefaultTimeoutConfig()
+	cfg.KeygenJobTimeout = 30 * time.Second
+	cfg.MonitorMessageTimeout = 2 * time.Second
+	cfg.SelectionLeaderTimeout = 4 * time.Second
+	cfg.SelectionMemberTimeout = 2 * time.Second
+
+	scenarios := []chaostest.Scenario{
+		{
+			Name:    "no fault",
+			Success: true,
+		},
+		{
+			Name:    "round 1 messages of a node are dropped once",
+			Faults:  []chaostest.Fault{chaostest.DropRound(1, "KGRound1Message", true)},
+			Success: true,
+		},
+		{
+			Name:     "round 2 messages of a node are always dropped",
+			Faults:   []chaostest.Fault{chaostest.DropRound(1, "KGRound2Message1", false)},
+			Culprits: []int{1},
+		},
+		{
+			Name:   "leader crashes during selection",
+			Faults: []chaostest.Fault{chaostest.CrashLeader()},
+		},
+		{
+			Name:     "update message of a node is corrupted",
+			Faults:   []chaostest.Fault{chaostest.CorruptRound(2, "KGRound1Message")},
+			Culprits: []int{2},
+		},
+		{
+			Name:    "node is slower than the message monitor",
+			Faults:  []chaostest.Fault{chaostest.DelayUpdates(0, cfg.MonitorMessageTimeout+time.Second)},
+			Success: true,
+		},
+	}
+
+	chaostest.RunScenarios(t, 3, cfg, scenarios, func(h *chaostest.Harness) []*chaostest.Result {
+		return h.RunEcKeygen(2 * cfg.KeygenJobTimeout)
+	})
+}
+
+func TestChaos_EcSigning(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	cfg := config.NewDefaultTimeoutConfig()
+	cfg.SigningJobTimeout = 20 * time.Second
+	cfg.MonitorMessageTimeout = 2 * time.Second
+	cfg.SelectionLeaderTimeout = 4 * time.Second
+	cfg.SelectionMemberTimeout = 2 * time.Second
+
+	scenarios := []chaostest.Scenario{
+		{
+			Name:    "no fault",
+			Success: true,
+		},
+		{
+			Name:    "round 1 messages of a node are dropped once",
+			Faults:  []chaostest.Fault{chaostest.DropRound(1, "SignRound1Message2", true)},
+			Success: true,
+		},
+		{
+			Name:     "round 3 messages of a node are always dropped",
+			Faults:   []chaostest.Fault{chaostest.DropRound(1, "SignRound3Message", false)},
+			Culprits: []int{1},
+		},
+		{
+			Name:     "update message of a node is corrupted",
+			Faults:   []chaostest.Fault{chaostest.CorruptRound(2, "SignRound1Message2")},
+			Culprits: []int{2},
+		},
+	}
+
+	chaostest.RunScenarios(t, 3, cfg, scenarios, func(h *chaostest.Harness) []*chaostest.Result {
+		return h.RunEcSigning(2 * cfg.SigningJobTimeout)
+	})
+}
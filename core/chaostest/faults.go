@@ -0,0 +1,88 @@
+package chaostest
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sisu-network/dheart/types/common"
+)
+
+// Fault is a fault put into the messages of a running work. It is called for every message that a
+// node sends to another node and can change the message in place. It returns whether the message is
+// dropped and how long it is delayed.
+type Fault func(h *Harness, from, to int, msg *common.SignedMessage) (drop bool, delay time.Duration)
+
+// IsRound returns true if the message is an update message of a tss round, e.g. KGRound2Message1.
+func IsRound(msg *common.SignedMessage, round string) bool {
+	tssMsg := msg.TssMessage
+	return tssMsg.Type == common.TssMessage_UPDATE_MESSAGES && len(tssMsg.UpdateMessages) > 0 &&
+		strings.HasSuffix(tssMsg.UpdateMessages[0].Round, round)
+}
+
+// DropRound drops the messages of a round sent by a node. If once is true, only the first message
+// to every peer is dropped and the peers can get the message again by asking for it.
+func DropRound(node int, round string, once bool) Fault {
+	dropped := make(map[int]bool)
+	lock := &sync.Mutex{}
+
+	return func(h *Harness, from, to int, msg *common.SignedMessage) (bool, time.Duration) {
+		if from != node || !IsRound(msg, round) {
+			return false, 0
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		if once && dropped[to] {
+			return false, 0
+		}
+		dropped[to] = true
+
+		return true, 0
+	}
+}
+
+// CrashLeader crashes the node that sends the first availability request, i.e. the leader of the
+// selection round.
+func CrashLeader() Fault {
+	return func(h *Harness, from, to int, msg *common.SignedMessage) (bool, time.Duration) {
+		if msg.TssMessage.Type != common.TssMessage_AVAILABILITY_REQUEST {
+			return false, 0
+		}
+
+		h.Crash(from)
+		return true, 0
+	}
+}
+
+// CorruptRound flips the bytes of the messages of a round sent by a node. The corrupted messages are
+// signed again by the node like a malicious node would do.
+func CorruptRound(node int, round string) Fault {
+	return func(h *Harness, from, to int, msg *common.SignedMessage) (bool, time.Duration) {
+		if from != node || !IsRound(msg, round) {
+			return false, 0
+		}
+
+		for _, updateMsg := range msg.TssMessage.UpdateMessages {
+			for i := range updateMsg.Data {
+				updateMsg.Data[i] ^= 0xff
+			}
+		}
+		h.Sign(from, msg)
+
+		return false, 0
+	}
+}
+
+// DelayUpdates delays the update messages sent by a node. Messages of the selection round are not
+// delayed.
+func DelayUpdates(node int, delay time.Duration) Fault {
+	return func(h *Harness, from, to int, msg *common.SignedMessage) (bool, time.Duration) {
+		if from != node || msg.TssMessage.Type != common.TssMessage_UPDATE_MESSAGES {
+			return false, 0
+		}
+
+		return false, delay
+	}
+}
@@ -0,0 +1,283 @@
+// Package chaostest runs TSS engines on a loopback network and puts faults into the messages
+// between them.
+package chaostest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	ctypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	libchain "github.com/sisu-network/lib/chain"
+	"github.com/sisu-network/tss-lib/ecdsa/keygen"
+	"github.com/sisu-network/tss-lib/tss"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sisu-network/dheart/core"
+	"github.com/sisu-network/dheart/core/config"
+	"github.com/sisu-network/dheart/db"
+	"github.com/sisu-network/dheart/p2p"
+	p2ptypes "github.com/sisu-network/dheart/p2p/types"
+	htypes "github.com/sisu-network/dheart/types"
+	"github.com/sisu-network/dheart/types/common"
+	"github.com/sisu-network/dheart/worker/types"
+)
+
+// Result is the outcome of a work on a node.
+type Result struct {
+	Node     int
+	Success  bool
+	Culprits []*tss.PartyID
+}
+
+// Harness runs engines on a loopback network and puts faults into the messages between them.
+type Harness struct {
+	t        *testing.T
+	network  *p2p.LoopbackNetwork
+	privKeys []ctypes.PrivKey
+	nodes    []*core.Node
+	pIDs     tss.SortedPartyIDs
+	keygens  []*keygen.LocalPartySaveData
+	engines  []core.Engine
+	faults   []Fault
+	results  chan *Result
+
+	crashed map[int]bool
+	lock    *sync.RWMutex
+}
+
+// NewHarness starts n engines with the test keys of core.GetEngineTestData. The faults are applied
+// in order to every message sent between the engines.
+func NewHarness(t *testing.T, n int, cfg config.TimeoutConfig, faults ...Fault) *Harness {
+	privKeys, nodes, pIDs, keygens := core.GetEngineTestData(n)
+
+	h := &Harness{
+		t:        t,
+		network:  p2p.NewLoopbackNetwork(p2p.LoopbackConfig{Seed: 1, Latency: time.Millisecond}),
+		privKeys: privKeys,
+		nodes:    nodes,
+		pIDs:     pIDs,
+		keygens:  keygens,
+		engines:  make([]core.Engine, n),
+		faults:   faults,
+		results:  make(chan *Result, n),
+		crashed:  make(map[int]bool),
+		lock:     &sync.RWMutex{},
+	}
+	t.Cleanup(h.network.Close)
+
+	peers := make([]*p2ptypes.Peer, n)
+	for i, node := range nodes {
+		peers[i] = &p2ptypes.Peer{
+			Address:    fmt.Sprintf("/ip4/127.0.0.1/tcp/28300/p2p/%s", node.PeerId),
+			PubKey:     fmt.Sprintf("%x", node.PubKey.Bytes()),
+			PubKeyType: node.PubKey.Type(),
+		}
+	}
+
+	for i := range nodes {
+		index := i
+		cm := &connectionManager{
+			ConnectionManager: h.network.NewConnectionManager(p2ptypes.ConnectionsConfig{
+				Peers: append(append([]*p2ptypes.Peer{}, peers[:i]...), peers[i+1:]...),
+			}),
+			harness: h,
+			index:   i,
+		}
+
+		database := db.NewDatabase(&config.DbConfig{InMemory: true})
+		require.NoError(t, database.Init())
+
+		callback := &core.MockEngineCallback{
+			OnWorkKeygenFinishedFunc: func(result *htypes.KeygenResult) {
+				h.results <- &Result{Node: index, Success: result.Outcome == htypes.OutcomeSuccess}
+			},
+			OnWorkSigningFinishedFunc: func(request *types.WorkRequest, result *htypes.KeysignResult) {
+				h.results <- &Result{Node: index, Success: result.Outcome == htypes.OutcomeSuccess}
+			},
+			OnWorkFailedFunc: func(request *types.WorkRequest, culprits []*tss.PartyID) {
+				h.results <- &Result{Node: index, Culprits: culprits}
+			},
+		}
+
+		h.engines[i] = core.NewEngine(nodes[i], cm, database, callback, privKeys[i], cfg)
+		h.engines[i].AddNodes(nodes)
+		require.NoError(t, h.engines[i].Init())
+		cm.AddListener(p2p.TSSProtocolID, h.engines[i])
+		require.NoError(t, cm.Start(privKeys[i].Bytes(), privKeys[i].Type()))
+	}
+
+	return h
+}
+
+// Crash disconnects a node from all the other nodes.
+func (h *Harness) Crash(node int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.crashed[node] = true
+	crashed := make([]peer.ID, 0, len(h.crashed))
+	for i := range h.crashed {
+		crashed = append(crashed, h.nodes[i].PeerId)
+	}
+	// Every crashed node is in its own partition.
+	groups := make([][]peer.ID, len(crashed))
+	for i, pID := range crashed {
+		groups[i] = []peer.ID{pID}
+	}
+	h.network.Partition(groups...)
+}
+
+func (h *Harness) isCrashed(node int) bool {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.crashed[node]
+}
+
+// Sign signs a message again with the key of a node.
+func (h *Harness) Sign(node int, msg *common.SignedMessage) {
+	bz, err := msg.TssMessage.GetSignBytes()
+	require.NoError(h.t, err)
+	msg.Signature, err = h.privKeys[node].Sign(bz)
+	require.NoError(h.t, err)
+}
+
+func (h *Harness) getNodeIndex(pID peer.ID) int {
+	for i, node := range h.nodes {
+		if node.PeerId == pID {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// PartyIndex returns the index of the node of a party, or -1 if the party is not a node of the
+// harness.
+func (h *Harness) PartyIndex(pid *tss.PartyID) int {
+	for i, node := range h.nodes {
+		if node.PartyId.Id == pid.Id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// RunEcKeygen runs an ecdsa keygen on all the nodes and returns the results of the nodes that have
+// not crashed.
+func (h *Harness) RunEcKeygen(timeout time.Duration) []*Result {
+	for i, engine := range h.engines {
+		preparams := &keygen.LocalPreParams{}
+		require.NoError(h.t, json.Unmarshal([]byte(core.Preparams[i]), preparams))
+
+		request := types.NewEcKeygenRequest(libchain.KEY_TYPE_ECDSA, "keygen0", h.pIDs,
+			len(h.pIDs)-1, preparams)
+		require.NoError(h.t, engine.AddRequest(request))
+	}
+
+	return h.waitForResults(timeout)
+}
+
+// RunEcSigning runs an ecdsa signing with the saved test keys on all the nodes and returns the
+// results of the nodes that have not crashed. The nodes have no presigns so the signing runs all the
+// rounds.
+func (h *Harness) RunEcSigning(timeout time.Duration) []*Result {
+	for i, engine := range h.engines {
+		request := types.NewEcSigningRequest("signing0", h.pIDs, len(h.pIDs)-1,
+			[][]byte{[]byte("message")}, []string{"eth"}, h.keygens[i])
+		require.NoError(h.t, engine.AddRequest(request))
+	}
+
+	return h.waitForResults(timeout)
+}
+
+func (h *Harness) waitForResults(timeout time.Duration) []*Result {
+	results := make(map[int]*Result)
+	deadline := time.After(timeout)
+	for {
+		done := true
+		for i := range h.nodes {
+			if results[i] == nil && !h.isCrashed(i) {
+				done = false
+			}
+		}
+		if done {
+			break
+		}
+
+		select {
+		case result := <-h.results:
+			results[result.Node] = result
+		case <-time.After(100 * time.Millisecond):
+		case <-deadline:
+			require.FailNow(h.t, "timeout waiting for work results", "results = %v", results)
+		}
+	}
+
+	list := make([]*Result, 0, len(results))
+	for i, result := range results {
+		if !h.isCrashed(i) {
+			list = append(list, result)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Node < list[j].Node
+	})
+
+	return list
+}
+
+// connectionManager applies the faults of the harness to the messages sent by a node.
+type connectionManager struct {
+	p2p.ConnectionManager
+
+	harness *Harness
+	index   int
+}
+
+func (cm *connectionManager) WriteToStream(pID peer.ID, protocolId protocol.ID, bz []byte) error {
+	h := cm.harness
+	if protocolId != p2p.TSSProtocolID {
+		return cm.ConnectionManager.WriteToStream(pID, protocolId, bz)
+	}
+	if h.isCrashed(cm.index) {
+		return nil
+	}
+
+	msg, err := common.UnmarshalSignedMessage(bz)
+	if err != nil {
+		return err
+	}
+
+	to := h.getNodeIndex(pID)
+	var delay time.Duration
+	for _, fault := range h.faults {
+		drop, d := fault(h, cm.index, to, msg)
+		if drop {
+			return nil
+		}
+		delay += d
+	}
+
+	bz, err = common.MarshalSignedMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	if delay > 0 {
+		go func() {
+			time.Sleep(delay)
+			cm.ConnectionManager.WriteToStream(pID, protocolId, bz)
+		}()
+		return nil
+	}
+
+	return cm.ConnectionManager.WriteToStream(pID, protocolId, bz)
+}
@@ -0,0 +1,57 @@
+package chaostest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sisu-network/dheart/core/config"
+)
+
+// Scenario is a list of faults and the expected result of every node that has not crashed.
+// Culprits are only checked if they are not nil and only on the nodes that are not culprits
+// themselves.
+type Scenario struct {
+	Name     string
+	Faults   []Fault
+	Success  bool
+	Culprits []int
+}
+
+// RunScenarios runs every scenario as a parallel subtest on its own harness with n nodes.
+func RunScenarios(t *testing.T, n int, cfg config.TimeoutConfig, scenarios []Scenario,
+	run func(h *Harness) []*Result) {
+	for _, scenario := range scenarios {
+		scenario := scenario
+		t.Run(scenario.Name, func(t *testing.T) {
+			t.Parallel()
+
+			h := NewHarness(t, n, cfg, scenario.Faults...)
+			results := run(h)
+			require.NotEmpty(t, results)
+
+			for _, result := range results {
+				require.Equal(t, scenario.Success, result.Success, "node %d", result.Node)
+				if scenario.Culprits == nil || containsInt(scenario.Culprits, result.Node) {
+					continue
+				}
+
+				culprits := make([]int, 0, len(result.Culprits))
+				for _, culprit := range result.Culprits {
+					culprits = append(culprits, h.PartyIndex(culprit))
+				}
+				require.ElementsMatch(t, scenario.Culprits, culprits, "node %d", result.Node)
+			}
+		})
+	}
+}
+
+func containsInt(arr []int, x int) bool {
+	for _, v := range arr {
+		if v == x {
+			return true
+		}
+	}
+
+	return false
+}
@@ -6,24 +6,31 @@ import (
 	"sync"
 
 	"github.com/sisu-network/dheart/db"
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
 	"github.com/sisu-network/dheart/types/common"
 	"github.com/sisu-network/dheart/utils"
+	libchain "github.com/sisu-network/lib/chain"
 	"github.com/sisu-network/lib/log"
 	ecsigning "github.com/sisu-network/tss-lib/ecdsa/signing"
 	"github.com/sisu-network/tss-lib/tss"
 )
 
+// AvailablePresigns manages presign sets that are ready to be used for signing. Every key has its
+// own pool of presigns identified by the key type and the key label.
 type AvailablePresigns interface {
 	Load() error
-	GetAvailablePresigns(batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID)
-	AddPresign(workId string, partyIds []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData)
+	GetAvailablePresigns(keyType string, keyLabel string, batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID)
+	AddPresign(keyLabel string, workId string, partyIds []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData)
+	AddEdPresign(keyLabel string, workId string, partyIds []*tss.PartyID, presignOutputs []*edpresign.PresignData)
+	ClaimPresigns(keyType string, keyLabel string, presignIds []string) error
+	GetPresignCount(keyType string, keyLabel string) int
 }
 
 type defaultAvailablePresigns struct {
 	db db.Database
-	// Group all available presign by its list of pids.
-	// map between: list of pids (string) -> array of available presigns.
-	available map[string][]*common.AvailablePresign
+	// Group all available presign by the key and its list of pids.
+	// map between: key type & label -> list of pids (string) -> array of available presigns.
+	available map[string]map[string][]*common.AvailablePresign
 
 	// Set of presign data that being used by a worker. In case the worker fails, we know which
 	// nodes are using the presigns.
@@ -34,20 +41,21 @@ type defaultAvailablePresigns struct {
 func NewAvailPresignManager(db db.Database) AvailablePresigns {
 	return &defaultAvailablePresigns{
 		db:        db,
-		available: make(map[string][]*common.AvailablePresign),
+		available: make(map[string]map[string][]*common.AvailablePresign),
 		lock:      &sync.RWMutex{},
 	}
 }
 
 func (m *defaultAvailablePresigns) Load() error {
-	presignIds, pidStrings, err := m.db.GetAvailablePresignShortForm()
+	presignIds, pidStrings, keyTypes, keyLabels, err := m.db.GetAvailablePresignShortForm()
 	if err != nil {
 		return err
 	}
 
 	m.lock.Lock()
 	for i, pidString := range pidStrings {
-		arr := m.available[pidString]
+		pool := m.getPool(keyTypes[i], keyLabels[i])
+		arr := pool[pidString]
 		if arr == nil {
 			arr = make([]*common.AvailablePresign, 0)
 		}
@@ -58,27 +66,59 @@ func (m *defaultAvailablePresigns) Load() error {
 			Pids:       strings.Split(pidString, ","),
 		}
 		arr = append(arr, ap)
-		m.available[pidString] = arr
+		pool[pidString] = arr
 	}
 	m.lock.Unlock()
 
 	return nil
 }
 
-func (m *defaultAvailablePresigns) AddPresign(workId string, partyIds []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData) {
-	if err := m.db.SavePresignData(workId, partyIds, presignOutputs); err != nil {
+// poolKey returns the key of the presign pool of a key. Key types never contain the separator.
+func poolKey(keyType string, keyLabel string) string {
+	return keyType + ":" + keyLabel
+}
+
+// getPool returns the presign pool of a key, creating it if needed. The caller must hold the lock.
+func (m *defaultAvailablePresigns) getPool(keyType string, keyLabel string) map[string][]*common.AvailablePresign {
+	key := poolKey(keyType, keyLabel)
+	pool, ok := m.available[key]
+	if !ok {
+		pool = make(map[string][]*common.AvailablePresign)
+		m.available[key] = pool
+	}
+
+	return pool
+}
+
+func (m *defaultAvailablePresigns) AddPresign(keyLabel string, workId string, partyIds []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData) {
+	if err := m.db.SavePresignData(keyLabel, workId, partyIds, presignOutputs); err != nil {
+		log.Error("error when saving presign data", err)
+
+		return
+	}
+
+	m.addToPool(libchain.KEY_TYPE_ECDSA, keyLabel, workId, partyIds, len(presignOutputs))
+}
+
+func (m *defaultAvailablePresigns) AddEdPresign(keyLabel string, workId string, partyIds []*tss.PartyID, presignOutputs []*edpresign.PresignData) {
+	if err := m.db.SaveEdPresignData(keyLabel, workId, partyIds, presignOutputs); err != nil {
 		log.Error("error when saving presign data", err)
 
 		return
 	}
 
+	m.addToPool(libchain.KEY_TYPE_EDDSA, keyLabel, workId, partyIds, len(presignOutputs))
+}
+
+// addToPool adds the saved presigns of a work to the pool of a key.
+func (m *defaultAvailablePresigns) addToPool(keyType string, keyLabel string, workId string, partyIds []*tss.PartyID, count int) {
 	pids := utils.GetPidsArray(partyIds)
 	pidString := utils.GetPidString(partyIds)
 
 	// Add this to on-memory. TODO: Control the number of on-memory presign items size.
-	arr := make([]*common.AvailablePresign, len(presignOutputs))
+	arr := make([]*common.AvailablePresign, count)
 
-	for i := range presignOutputs {
+	for i := range arr {
 		presignId := fmt.Sprintf("%s-%d", workId, i)
 
 		arr[i] = &common.AvailablePresign{
@@ -89,24 +129,25 @@ func (m *defaultAvailablePresigns) AddPresign(workId string, partyIds []*tss.Par
 	}
 
 	m.lock.Lock()
-	if ap, ok := m.available[pidString]; ok {
+	pool := m.getPool(keyType, keyLabel)
+	if ap, ok := pool[pidString]; ok {
 		ap = append(ap, arr...)
-		m.available[pidString] = ap
+		pool[pidString] = ap
 	} else {
-		m.available[pidString] = arr
+		pool[pidString] = arr
 	}
 	m.lock.Unlock()
 }
 
-// GetAvailablePresigns returns a list of presigns with size batchSize for a list of parties. It
-// immediately consumes the presign set (i.e. the set is longer available.) to avoid dpulicated
-// usage of presign.
-func (m *defaultAvailablePresigns) GetAvailablePresigns(batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
+// GetAvailablePresigns returns a list of presigns of a key with size batchSize for a list of
+// parties. It immediately consumes the presign set (i.e. the set is longer available.) to avoid
+// dpulicated usage of presign.
+func (m *defaultAvailablePresigns) GetAvailablePresigns(keyType string, keyLabel string, batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
 	selectedPidstring := ""
 	var selectedAps []*common.AvailablePresign
 
 	m.lock.RLock()
-	for pidString, apArr := range m.available {
+	for pidString, apArr := range m.available[poolKey(keyType, keyLabel)] {
 		pids := strings.Split(pidString, ",")
 		ok := true
 		for _, pid := range pids {
@@ -131,17 +172,19 @@ func (m *defaultAvailablePresigns) GetAvailablePresigns(batchSize int, n int, al
 	// 2. Remove the selected presigns from the available set.
 	m.lock.Lock()
 	if selectedPidstring != "" {
-		apArr := m.available[selectedPidstring]
+		pool := m.available[poolKey(keyType, keyLabel)]
+		apArr := pool[selectedPidstring]
 
 		if len(apArr) >= batchSize { // We check again here in case other routine has consume this apArr
 			selectedAps = apArr[:batchSize]
 			// Remove this available presigns from the list.
-			m.available[selectedPidstring] = apArr[batchSize:]
+			pool[selectedPidstring] = apArr[batchSize:]
 
-			if len(m.available[selectedPidstring]) == 0 {
-				delete(m.available, selectedPidstring)
+			if len(pool[selectedPidstring]) == 0 {
+				delete(pool, selectedPidstring)
 			}
 		} else {
+			m.lock.Unlock()
 			return []string{}, []*tss.PartyID{}
 		}
 	}
@@ -175,3 +218,46 @@ func (m *defaultAvailablePresigns) GetAvailablePresigns(batchSize int, n int, al
 
 	return presignIds, selectedPids
 }
+
+// ClaimPresigns marks the presigns selected by a leader as used. The presigns are removed from the
+// pool and the claim fails if any of them has already been used so that a presign is never used to
+// sign twice.
+func (m *defaultAvailablePresigns) ClaimPresigns(keyType string, keyLabel string, presignIds []string) error {
+	claimed := make(map[string]bool, len(presignIds))
+	for _, presignId := range presignIds {
+		claimed[presignId] = true
+	}
+
+	m.lock.Lock()
+	pool := m.available[poolKey(keyType, keyLabel)]
+	for pidString, apArr := range pool {
+		remaining := make([]*common.AvailablePresign, 0, len(apArr))
+		for _, ap := range apArr {
+			if !claimed[ap.PresignId] {
+				remaining = append(remaining, ap)
+			}
+		}
+
+		if len(remaining) == 0 {
+			delete(pool, pidString)
+		} else {
+			pool[pidString] = remaining
+		}
+	}
+	m.lock.Unlock()
+
+	return m.db.UpdatePresignStatus(presignIds)
+}
+
+// GetPresignCount returns the number of available presigns of a key.
+func (m *defaultAvailablePresigns) GetPresignCount(keyType string, keyLabel string) int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	count := 0
+	for _, apArr := range m.available[poolKey(keyType, keyLabel)] {
+		count += len(apArr)
+	}
+
+	return count
+}
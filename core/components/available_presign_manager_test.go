@@ -5,7 +5,10 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/sisu-network/dheart/db"
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
 	"github.com/sisu-network/dheart/types/common"
+	libchain "github.com/sisu-network/lib/chain"
 	"github.com/sisu-network/tss-lib/tss"
 	"github.com/stretchr/testify/assert"
 )
@@ -25,15 +28,15 @@ func TestAvailPresignManager_HappyCase(t *testing.T) {
 
 	availManager := NewAvailPresignManager(mockDb).(*defaultAvailablePresigns)
 	assert.NoError(t, availManager.Load())
-	assert.Equal(t, 3, len(availManager.available))
+	assert.Equal(t, 3, len(availManager.available[poolKey(libchain.KEY_TYPE_ECDSA, "")]))
 
 	// Get and consumes 3 presigns
-	presignIds, selectedPIDs := availManager.GetAvailablePresigns(3, 3, getPartyIdMap(partyIds))
+	presignIds, selectedPIDs := availManager.GetAvailablePresigns(libchain.KEY_TYPE_ECDSA, "", 3, 3, getPartyIdMap(partyIds))
 	assert.Equal(t, 3, len(presignIds))
 	assert.Equal(t, 3, len(selectedPIDs))
 
 	// We should have 1 pid string in use (2,3,5) and 2 available pid strings: (1,2,3) and (3,4,5)
-	assert.Equal(t, 2, len(availManager.available))
+	assert.Equal(t, 2, len(availManager.available[poolKey(libchain.KEY_TYPE_ECDSA, "")]))
 
 	// Update status
 	selectedAps := make([]*common.AvailablePresign, 3)
@@ -58,12 +61,12 @@ func TestAvailPresignManager_NotFound(t *testing.T) {
 
 	availManager := NewAvailPresignManager(mockDb).(*defaultAvailablePresigns)
 	assert.NoError(t, availManager.Load())
-	assert.Equal(t, 3, len(availManager.available))
+	assert.Equal(t, 3, len(availManager.available[poolKey(libchain.KEY_TYPE_ECDSA, "")]))
 
-	presignIds, _ := availManager.GetAvailablePresigns(3, 3, getPartyIdMap(partyIds))
+	presignIds, _ := availManager.GetAvailablePresigns(libchain.KEY_TYPE_ECDSA, "", 3, 3, getPartyIdMap(partyIds))
 	assert.Equal(t, 0, len(presignIds))
 
-	assert.Equal(t, 3, len(availManager.available))
+	assert.Equal(t, 3, len(availManager.available[poolKey(libchain.KEY_TYPE_ECDSA, "")]))
 }
 
 func TestAvailPresignManager_NotUsed(t *testing.T) {
@@ -79,7 +82,7 @@ func TestAvailPresignManager_NotUsed(t *testing.T) {
 	availManager := NewAvailPresignManager(mockDb)
 	assert.NoError(t, availManager.Load())
 
-	presignIds, _ := availManager.GetAvailablePresigns(3, 3, getPartyIdMap(partyIds))
+	presignIds, _ := availManager.GetAvailablePresigns(libchain.KEY_TYPE_ECDSA, "", 3, 3, getPartyIdMap(partyIds))
 	assert.Equal(t, 3, len(presignIds))
 
 	// Update status
@@ -92,6 +95,69 @@ func TestAvailPresignManager_NotUsed(t *testing.T) {
 	}
 }
 
+func TestAvailPresignManager_KeyLabels(t *testing.T) {
+	t.Parallel()
+
+	presignPids := []string{"work0-0", "work0-1", "work1-0", "work1-1"}
+	pids := []string{"2,3,5", "2,3,5", "2,3,5", "2,3,5"}
+	keyTypes := []string{libchain.KEY_TYPE_ECDSA, libchain.KEY_TYPE_ECDSA, libchain.KEY_TYPE_ECDSA, libchain.KEY_TYPE_ECDSA}
+	keyLabels := []string{"", "", "vault1", "vault1"}
+	mockDb := &db.MockDatabase{
+		GetAvailablePresignShortFormFunc: func() ([]string, []string, []string, []string, error) {
+			return presignPids, pids, keyTypes, keyLabels, nil
+		},
+	}
+	partyIds := getPartyIdsFromStrings([]string{"2", "3", "4", "5"})
+
+	availManager := NewAvailPresignManager(mockDb)
+	assert.NoError(t, availManager.Load())
+
+	// Presigns of a key are never used for another key.
+	presignIds, _ := availManager.GetAvailablePresigns(libchain.KEY_TYPE_ECDSA, "vault1", 2, 3, getPartyIdMap(partyIds))
+	assert.Equal(t, []string{"work1-0", "work1-1"}, presignIds)
+
+	presignIds, _ = availManager.GetAvailablePresigns(libchain.KEY_TYPE_ECDSA, "vault1", 1, 3, getPartyIdMap(partyIds))
+	assert.Equal(t, 0, len(presignIds))
+
+	presignIds, _ = availManager.GetAvailablePresigns(libchain.KEY_TYPE_ECDSA, "vault2", 1, 3, getPartyIdMap(partyIds))
+	assert.Equal(t, 0, len(presignIds))
+
+	presignIds, _ = availManager.GetAvailablePresigns(libchain.KEY_TYPE_ECDSA, "", 2, 3, getPartyIdMap(partyIds))
+	assert.Equal(t, []string{"work0-0", "work0-1"}, presignIds)
+}
+
+func TestAvailPresignManager_KeyTypes(t *testing.T) {
+	t.Parallel()
+
+	presignPids := []string{"work0-0", "work0-1", "work1-0"}
+	pids := []string{"2,3,5", "2,3,5", "2,3,5"}
+	keyTypes := []string{libchain.KEY_TYPE_ECDSA, libchain.KEY_TYPE_ECDSA, libchain.KEY_TYPE_EDDSA}
+	mockDb := &db.MockDatabase{
+		GetAvailablePresignShortFormFunc: func() ([]string, []string, []string, []string, error) {
+			return presignPids, pids, keyTypes, make([]string, len(presignPids)), nil
+		},
+	}
+	partyIds := getPartyIdsFromStrings([]string{"2", "3", "4", "5"})
+
+	availManager := NewAvailPresignManager(mockDb)
+	assert.NoError(t, availManager.Load())
+
+	// Presigns of an ECDSA key are never used for the EdDSA key with the same label.
+	presignIds, _ := availManager.GetAvailablePresigns(libchain.KEY_TYPE_EDDSA, "", 2, 3, getPartyIdMap(partyIds))
+	assert.Equal(t, 0, len(presignIds))
+
+	presignIds, _ = availManager.GetAvailablePresigns(libchain.KEY_TYPE_EDDSA, "", 1, 3, getPartyIdMap(partyIds))
+	assert.Equal(t, []string{"work1-0"}, presignIds)
+
+	// EdDSA presigns added after loading go to the EdDSA pool.
+	availManager.AddEdPresign("", "work2", partyIds[:3], make([]*edpresign.PresignData, 2))
+	presignIds, _ = availManager.GetAvailablePresigns(libchain.KEY_TYPE_EDDSA, "", 2, 3, getPartyIdMap(partyIds))
+	assert.Equal(t, []string{"work2-0", "work2-1"}, presignIds)
+
+	presignIds, _ = availManager.GetAvailablePresigns(libchain.KEY_TYPE_ECDSA, "", 2, 3, getPartyIdMap(partyIds))
+	assert.Equal(t, []string{"work0-0", "work0-1"}, presignIds)
+}
+
 func getPartyIdsFromStrings(pids []string) []*tss.PartyID {
 	partyIds := make([]*tss.PartyID, len(pids))
 	for i := 0; i < len(pids); i++ {
@@ -2,13 +2,19 @@ package components
 
 import (
 	"github.com/sisu-network/dheart/db"
+	libchain "github.com/sisu-network/lib/chain"
 	ecsigning "github.com/sisu-network/tss-lib/ecdsa/signing"
 )
 
 func GetMokDbForAvailManager(presignPids, pids []string) db.Database {
 	return &db.MockDatabase{
-		GetAvailablePresignShortFormFunc: func() ([]string, []string, error) {
-			return presignPids, pids, nil
+		GetAvailablePresignShortFormFunc: func() ([]string, []string, []string, []string, error) {
+			// All presigns belong to the default ECDSA key.
+			keyTypes := make([]string, len(presignPids))
+			for i := range keyTypes {
+				keyTypes[i] = libchain.KEY_TYPE_ECDSA
+			}
+			return presignPids, pids, keyTypes, make([]string, len(presignPids)), nil
 		},
 
 		LoadPresignFunc: func(presignIds []string) ([]*ecsigning.SignatureData_OneRoundData, error) {
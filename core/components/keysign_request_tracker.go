@@ -0,0 +1,106 @@
+package components
+
+import (
+	"errors"
+	"sync"
+
+	htypes "github.com/sisu-network/dheart/types"
+)
+
+var (
+	ErrKeysignRequestExisted = errors.New("keysign request has already been tracked")
+)
+
+// KeysignRequestTracker keeps track of keysign requests sent by Sisu so that the results produced
+// by workers can be matched back to the originating requests. A request must be added before its
+// work is dispatched to the engine.
+type KeysignRequestTracker interface {
+	Add(workId string, request *htypes.KeysignRequest) error
+	Get(workId string) *htypes.KeysignRequest
+	GetByMessageId(msgId string) (string, *htypes.KeysignRequest)
+	Remove(workId string) *htypes.KeysignRequest
+	Size() int
+}
+
+type defaultKeysignRequestTracker struct {
+	// map between: workId -> keysign request
+	requests map[string]*htypes.KeysignRequest
+	// map between: keysign message id -> workId. If several tracked requests contain the same
+	// message id, the latest added request wins.
+	msgIndex map[string]string
+	lock     *sync.RWMutex
+}
+
+func NewKeysignRequestTracker() KeysignRequestTracker {
+	return &defaultKeysignRequestTracker{
+		requests: make(map[string]*htypes.KeysignRequest),
+		msgIndex: make(map[string]string),
+		lock:     &sync.RWMutex{},
+	}
+}
+
+// Add tracks a new keysign request. It returns ErrKeysignRequestExisted if there is already a
+// request for the same workId.
+func (t *defaultKeysignRequestTracker) Add(workId string, request *htypes.KeysignRequest) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.requests[workId]; ok {
+		return ErrKeysignRequestExisted
+	}
+
+	t.requests[workId] = request
+	for _, msg := range request.KeysignMessages {
+		t.msgIndex[msg.Id] = workId
+	}
+
+	return nil
+}
+
+func (t *defaultKeysignRequestTracker) Get(workId string) *htypes.KeysignRequest {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.requests[workId]
+}
+
+// GetByMessageId returns the workId and the request that contains a keysign message.
+func (t *defaultKeysignRequestTracker) GetByMessageId(msgId string) (string, *htypes.KeysignRequest) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	workId, ok := t.msgIndex[msgId]
+	if !ok {
+		return "", nil
+	}
+
+	return workId, t.requests[workId]
+}
+
+// Remove stops tracking a request and returns it. It returns nil if the request is not tracked.
+func (t *defaultKeysignRequestTracker) Remove(workId string) *htypes.KeysignRequest {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	request := t.requests[workId]
+	if request == nil {
+		return nil
+	}
+
+	delete(t.requests, workId)
+	for _, msg := range request.KeysignMessages {
+		// Only remove the index if it still points to this work.
+		if t.msgIndex[msg.Id] == workId {
+			delete(t.msgIndex, msg.Id)
+		}
+	}
+
+	return request
+}
+
+func (t *defaultKeysignRequestTracker) Size() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return len(t.requests)
+}
@@ -0,0 +1,73 @@
+package components
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	htypes "github.com/sisu-network/dheart/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeysignRequestTracker_AddRemove(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewKeysignRequestTracker()
+	req := &htypes.KeysignRequest{
+		KeysignMessages: []*htypes.KeysignMessage{{Id: "msg0"}, {Id: "msg1"}},
+	}
+
+	require.NoError(t, tracker.Add("work0", req))
+	require.Equal(t, ErrKeysignRequestExisted, tracker.Add("work0", req))
+	require.Equal(t, req, tracker.Get("work0"))
+
+	workId, found := tracker.GetByMessageId("msg1")
+	require.Equal(t, "work0", workId)
+	require.Equal(t, req, found)
+
+	// A retry of the same message points the index to the latest work.
+	retry := &htypes.KeysignRequest{
+		Attempt:         1,
+		KeysignMessages: []*htypes.KeysignMessage{{Id: "msg1"}},
+	}
+	require.NoError(t, tracker.Add("work1", retry))
+	workId, _ = tracker.GetByMessageId("msg1")
+	require.Equal(t, "work1", workId)
+
+	// Removing the old work does not remove the index of the retried message.
+	require.Equal(t, req, tracker.Remove("work0"))
+	require.Nil(t, tracker.Remove("work0"))
+	workId, _ = tracker.GetByMessageId("msg0")
+	require.Equal(t, "", workId)
+	workId, _ = tracker.GetByMessageId("msg1")
+	require.Equal(t, "work1", workId)
+
+	require.Equal(t, retry, tracker.Remove("work1"))
+	require.Equal(t, 0, tracker.Size())
+}
+
+func TestKeysignRequestTracker_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewKeysignRequestTracker()
+	n := 100
+
+	wg := &sync.WaitGroup{}
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+
+			workId := fmt.Sprintf("work%d", i)
+			req := &htypes.KeysignRequest{
+				KeysignMessages: []*htypes.KeysignMessage{{Id: fmt.Sprintf("msg%d", i)}},
+			}
+			require.NoError(t, tracker.Add(workId, req))
+			require.Equal(t, req, tracker.Get(workId))
+			require.Equal(t, req, tracker.Remove(workId))
+		}(i)
+	}
+	wg.Wait()
+
+	require.Equal(t, 0, tracker.Size())
+}
@@ -1,6 +1,7 @@
 package components
 
 import (
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
 	ecsigning "github.com/sisu-network/tss-lib/ecdsa/signing"
 	"github.com/sisu-network/tss-lib/tss"
 )
@@ -9,8 +10,11 @@ import (
 
 type MockAvailablePresigns struct {
 	LoadFunc                 func() error
-	GetAvailablePresignsFunc func(batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID)
-	AddPresignFunc           func(workId string, partyIds []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData)
+	GetAvailablePresignsFunc func(keyType string, keyLabel string, batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID)
+	AddPresignFunc           func(keyLabel string, workId string, partyIds []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData)
+	AddEdPresignFunc         func(keyLabel string, workId string, partyIds []*tss.PartyID, presignOutputs []*edpresign.PresignData)
+	ClaimPresignsFunc        func(keyType string, keyLabel string, presignIds []string) error
+	GetPresignCountFunc      func(keyType string, keyLabel string) int
 }
 
 func NewMockAvailablePresigns() AvailablePresigns {
@@ -25,16 +29,61 @@ func (m *MockAvailablePresigns) Load() error {
 	return nil
 }
 
-func (m *MockAvailablePresigns) GetAvailablePresigns(batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
+func (m *MockAvailablePresigns) GetAvailablePresigns(keyType string, keyLabel string, batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
 	if m.GetAvailablePresignsFunc != nil {
-		return m.GetAvailablePresignsFunc(batchSize, n, allPids)
+		return m.GetAvailablePresignsFunc(keyType, keyLabel, batchSize, n, allPids)
 	}
 
 	return nil, nil
 }
 
-func (m *MockAvailablePresigns) AddPresign(workId string, partyIds []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData) {
+func (m *MockAvailablePresigns) AddPresign(keyLabel string, workId string, partyIds []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData) {
 	if m.AddPresignFunc != nil {
-		m.AddPresignFunc(workId, partyIds, presignOutputs)
+		m.AddPresignFunc(keyLabel, workId, partyIds, presignOutputs)
 	}
 }
+
+func (m *MockAvailablePresigns) AddEdPresign(keyLabel string, workId string, partyIds []*tss.PartyID, presignOutputs []*edpresign.PresignData) {
+	if m.AddEdPresignFunc != nil {
+		m.AddEdPresignFunc(keyLabel, workId, partyIds, presignOutputs)
+	}
+}
+
+func (m *MockAvailablePresigns) ClaimPresigns(keyType string, keyLabel string, presignIds []string) error {
+	if m.ClaimPresignsFunc != nil {
+		return m.ClaimPresignsFunc(keyType, keyLabel, presignIds)
+	}
+
+	return nil
+}
+
+func (m *MockAvailablePresigns) GetPresignCount(keyType string, keyLabel string) int {
+	if m.GetPresignCountFunc != nil {
+		return m.GetPresignCountFunc(keyType, keyLabel)
+	}
+
+	return 0
+}
+
+//---/
+
+type MockResourceMonitor struct {
+	CpuLoadFunc     func() float64
+	MemoryUsageFunc func() float64
+}
+
+func (m *MockResourceMonitor) CpuLoad() float64 {
+	if m.CpuLoadFunc != nil {
+		return m.CpuLoadFunc()
+	}
+
+	return 0
+}
+
+func (m *MockResourceMonitor) MemoryUsage() float64 {
+	if m.MemoryUsageFunc != nil {
+		return m.MemoryUsageFunc()
+	}
+
+	return 0
+}
@@ -0,0 +1,222 @@
+package components
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sisu-network/dheart/db"
+	"github.com/sisu-network/dheart/types"
+	"github.com/sisu-network/lib/log"
+	eckeygen "github.com/sisu-network/tss-lib/ecdsa/keygen"
+)
+
+const (
+	// The generator tops up the pool to this number of available preparams.
+	PreparamsPoolSize = 4
+	// The generator only runs when the cpu load is below this budget so that it does not slow down
+	// running works.
+	PreparamsMaxCpuLoad = 0.5
+	// The generator uses the least number of goroutines that tss-lib allows.
+	PreparamsConcurrency = 1
+	// How often the generator checks the pool.
+	PreparamsCheckInterval = time.Minute
+	PreparamsTimeout       = 30 * time.Minute
+)
+
+// GeneratePreparamsFunc generates new preparams, e.g. eckeygen.GeneratePreParams.
+type GeneratePreparamsFunc func(timeout time.Duration, optionalConcurrency ...int) (*eckeygen.LocalPreParams, error)
+
+// PreparamsPool keeps fresh preparams for ECDSA keygens in the db. Every keygen consumes one
+// preparams of the pool and a background generator tops the pool up when the machine is not busy.
+type PreparamsPool interface {
+	// Init makes sure that the pool has preparams for at least one keygen. It generates them at
+	// full speed if needed.
+	Init() error
+	Start()
+	Stop()
+	Status() *types.PreparamsPoolStatus
+
+	// Get takes preparams out of the pool for a keygen work. If the pool is empty, it generates
+	// preparams right away regardless of the cpu load so that the keygen does not fail.
+	Get(workId string) (*eckeygen.LocalPreParams, error)
+}
+
+type defaultPreparamsPool struct {
+	db        db.Database
+	resources ResourceMonitor
+	generate  GeneratePreparamsFunc
+
+	stopCh   chan struct{}
+	stopOnce *sync.Once
+
+	generating bool
+	throttled  bool
+	lastError  string
+	lock       *sync.RWMutex
+}
+
+func NewPreparamsPool(db db.Database, resources ResourceMonitor, generate GeneratePreparamsFunc) PreparamsPool {
+	return &defaultPreparamsPool{
+		db:        db,
+		resources: resources,
+		generate:  generate,
+		stopCh:    make(chan struct{}),
+		stopOnce:  &sync.Once{},
+		lock:      &sync.RWMutex{},
+	}
+}
+
+func (p *defaultPreparamsPool) Init() error {
+	available, _, err := p.db.CountPreparams()
+	if err != nil {
+		return err
+	}
+
+	if available > 0 {
+		log.Info("Preparams was generated, available = ", available)
+		return nil
+	}
+
+	log.Info("Start generating preparams....")
+	start := time.Now()
+	preparams, err := p.generate(PreparamsTimeout)
+	log.Info("Generating time = ", time.Since(start))
+	if err != nil {
+		log.Error("Cannot generate preparams. err = ", err)
+		return err
+	}
+
+	return p.db.SavePreparams(preparams)
+}
+
+// Start runs the generator in the background until the pool is stopped.
+func (p *defaultPreparamsPool) Start() {
+	go func() {
+		ticker := time.NewTicker(PreparamsCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			p.topUp()
+
+			select {
+			case <-p.stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the generator. Preparams that are being generated are dropped.
+func (p *defaultPreparamsPool) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopCh)
+	})
+}
+
+func (p *defaultPreparamsPool) isStopped() bool {
+	select {
+	case <-p.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// topUp generates preparams one by one until the pool is full, the cpu load exceeds the budget or
+// the pool is stopped.
+func (p *defaultPreparamsPool) topUp() {
+	for !p.isStopped() {
+		available, _, err := p.db.CountPreparams()
+		if err != nil {
+			p.setState(false, false, err)
+			return
+		}
+		if available >= PreparamsPoolSize {
+			p.setState(false, false, nil)
+			return
+		}
+
+		if load := p.resources.CpuLoad(); load >= PreparamsMaxCpuLoad {
+			log.Verbosef("Preparams pool: cpu load %f is above the budget, available = %d", load, available)
+			p.setState(false, true, nil)
+			return
+		}
+
+		p.setState(true, false, nil)
+		start := time.Now()
+		preparams, err := p.generate(PreparamsTimeout, PreparamsConcurrency)
+		if err != nil {
+			log.Error("Preparams pool: cannot generate preparams, err = ", err)
+			p.setState(false, false, err)
+			return
+		}
+
+		if p.isStopped() {
+			return
+		}
+
+		if err := p.db.SavePreparams(preparams); err != nil {
+			log.Error("Preparams pool: cannot save preparams, err = ", err)
+			p.setState(false, false, err)
+			return
+		}
+		log.Info("Preparams pool: generated preparams in ", time.Since(start))
+	}
+}
+
+func (p *defaultPreparamsPool) Get(workId string) (*eckeygen.LocalPreParams, error) {
+	preparams, err := p.db.ConsumePreparams(workId)
+	if err == nil {
+		return preparams, nil
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		return nil, err
+	}
+
+	log.Warn("Preparams pool is empty, generating preparams for work ", workId)
+	start := time.Now()
+	preparams, err = p.generate(PreparamsTimeout)
+	if err != nil {
+		log.Error("Cannot generate preparams for work ", workId, ", err = ", err)
+		return nil, err
+	}
+	log.Info("Generated preparams for work ", workId, " in ", time.Since(start))
+
+	return preparams, nil
+}
+
+func (p *defaultPreparamsPool) setState(generating bool, throttled bool, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.generating = generating
+	p.throttled = throttled
+	p.lastError = ""
+	if err != nil {
+		p.lastError = err.Error()
+	}
+}
+
+func (p *defaultPreparamsPool) Status() *types.PreparamsPoolStatus {
+	status := &types.PreparamsPoolStatus{
+		Target: PreparamsPoolSize,
+	}
+
+	p.lock.RLock()
+	status.Generating = p.generating
+	status.Throttled = p.throttled
+	status.LastError = p.lastError
+	p.lock.RUnlock()
+
+	available, used, err := p.db.CountPreparams()
+	if err != nil {
+		status.LastError = err.Error()
+		return status
+	}
+	status.Available = available
+	status.Used = used
+
+	return status
+}
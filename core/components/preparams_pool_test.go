@@ -0,0 +1,129 @@
+package components
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sisu-network/dheart/db"
+	eckeygen "github.com/sisu-network/tss-lib/ecdsa/keygen"
+	"github.com/stretchr/testify/require"
+)
+
+// getMockDbForPreparamsPool returns a db that counts the preparams saved to it.
+func getMockDbForPreparamsPool(available int) (db.Database, func() int) {
+	lock := &sync.Mutex{}
+	mockDb := &db.MockDatabase{
+		SavePreparamsFunc: func(preparams *eckeygen.LocalPreParams) error {
+			lock.Lock()
+			defer lock.Unlock()
+			available++
+			return nil
+		},
+		CountPreparamsFunc: func() (int, int, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			return available, 0, nil
+		},
+	}
+
+	return mockDb, func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return available
+	}
+}
+
+func mockGeneratePreparams(timeout time.Duration, optionalConcurrency ...int) (*eckeygen.LocalPreParams, error) {
+	return &eckeygen.LocalPreParams{}, nil
+}
+
+func TestPreparamsPool_Init(t *testing.T) {
+	t.Parallel()
+
+	// An empty pool gets preparams even if the machine is busy.
+	mockDb, count := getMockDbForPreparamsPool(0)
+	resources := &MockResourceMonitor{CpuLoadFunc: func() float64 { return 10 }}
+	pool := NewPreparamsPool(mockDb, resources, mockGeneratePreparams)
+	require.NoError(t, pool.Init())
+	require.Equal(t, 1, count())
+
+	// A pool with preparams does not generate more.
+	mockDb, count = getMockDbForPreparamsPool(2)
+	pool = NewPreparamsPool(mockDb, resources, mockGeneratePreparams)
+	require.NoError(t, pool.Init())
+	require.Equal(t, 2, count())
+}
+
+func TestPreparamsPool_TopUp(t *testing.T) {
+	t.Parallel()
+
+	load := 0.0
+	mockDb, count := getMockDbForPreparamsPool(1)
+	resources := &MockResourceMonitor{CpuLoadFunc: func() float64 { return load }}
+	concurrencies := make([]int, 0)
+	generate := func(timeout time.Duration, optionalConcurrency ...int) (*eckeygen.LocalPreParams, error) {
+		concurrencies = append(concurrencies, optionalConcurrency...)
+		return &eckeygen.LocalPreParams{}, nil
+	}
+	pool := NewPreparamsPool(mockDb, resources, generate).(*defaultPreparamsPool)
+
+	// The generator waits while the cpu load is above the budget.
+	load = PreparamsMaxCpuLoad
+	pool.topUp()
+	require.Equal(t, 1, count())
+	status := pool.Status()
+	require.True(t, status.Throttled)
+	require.Equal(t, 1, status.Available)
+	require.Equal(t, PreparamsPoolSize, status.Target)
+
+	// The generator fills the pool with low concurrency when the machine is not busy.
+	load = 0
+	pool.topUp()
+	require.Equal(t, PreparamsPoolSize, count())
+	require.Equal(t, []int{PreparamsConcurrency, PreparamsConcurrency, PreparamsConcurrency}, concurrencies)
+	status = pool.Status()
+	require.False(t, status.Throttled)
+	require.False(t, status.Generating)
+	require.Equal(t, PreparamsPoolSize, status.Available)
+
+	// A stopped pool does not generate.
+	pool.Stop()
+	mockDb.(*db.MockDatabase).CountPreparamsFunc = func() (int, int, error) { return 0, 0, nil }
+	pool.topUp()
+	require.Len(t, concurrencies, PreparamsPoolSize-1)
+}
+
+func TestPreparamsPool_Get(t *testing.T) {
+	t.Parallel()
+
+	pooled := &eckeygen.LocalPreParams{}
+	mockDb := &db.MockDatabase{
+		ConsumePreparamsFunc: func(workId string) (*eckeygen.LocalPreParams, error) {
+			return pooled, nil
+		},
+	}
+	generated := 0
+	generate := func(timeout time.Duration, optionalConcurrency ...int) (*eckeygen.LocalPreParams, error) {
+		generated++
+		return &eckeygen.LocalPreParams{}, nil
+	}
+
+	// The preparams come from the pool if there are some.
+	busy := &MockResourceMonitor{CpuLoadFunc: func() float64 { return 10 }}
+	pool := NewPreparamsPool(mockDb, busy, generate)
+	preparams, err := pool.Get("keygen0")
+	require.NoError(t, err)
+	require.Same(t, pooled, preparams)
+	require.Equal(t, 0, generated)
+
+	// An empty pool generates preparams for the keygen even if the machine is busy.
+	mockDb.ConsumePreparamsFunc = func(workId string) (*eckeygen.LocalPreParams, error) {
+		return nil, db.ErrNotFound
+	}
+	preparams, err = pool.Get("keygen1")
+	require.NoError(t, err)
+	require.NotNil(t, preparams)
+	require.NotSame(t, pooled, preparams)
+	require.Equal(t, 1, generated)
+}
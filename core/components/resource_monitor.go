@@ -0,0 +1,103 @@
+package components
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ResourceMonitor reports the cpu and memory pressure of the machine this node runs on. A node
+// under pressure tells the leader that it is busy when asked to join a work.
+type ResourceMonitor interface {
+	// CpuLoad returns the load average of the last minute divided by the number of cpus.
+	CpuLoad() float64
+
+	// MemoryUsage returns the fraction of the memory in use, between 0 and 1.
+	MemoryUsage() float64
+}
+
+// procResourceMonitor reads the system load from /proc. On systems without /proc, it reports no
+// pressure.
+type procResourceMonitor struct {
+	procDir string
+}
+
+func NewResourceMonitor() ResourceMonitor {
+	return &procResourceMonitor{
+		procDir: "/proc",
+	}
+}
+
+func (m *procResourceMonitor) CpuLoad() float64 {
+	f, err := os.Open(m.procDir + "/loadavg")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	load, err := parseLoadAvg(f)
+	if err != nil {
+		return 0
+	}
+
+	return load / float64(runtime.NumCPU())
+}
+
+func (m *procResourceMonitor) MemoryUsage() float64 {
+	f, err := os.Open(m.procDir + "/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	usage, err := parseMemInfo(f)
+	if err != nil {
+		return 0
+	}
+
+	return usage
+}
+
+// parseLoadAvg returns the load average of the last minute from the content of /proc/loadavg.
+func parseLoadAvg(r io.Reader) (float64, error) {
+	var load float64
+	if _, err := fmt.Fscan(r, &load); err != nil {
+		return 0, err
+	}
+
+	return load, nil
+}
+
+// parseMemInfo returns the fraction of the memory in use from the content of /proc/meminfo.
+func parseMemInfo(r io.Reader) (float64, error) {
+	values := make(map[string]float64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// Lines have the form "MemTotal:       16318460 kB".
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	total, available := values["MemTotal"], values["MemAvailable"]
+	if total <= 0 {
+		return 0, errors.New("cannot find total memory")
+	}
+
+	return (total - available) / total, nil
+}
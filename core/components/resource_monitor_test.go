@@ -0,0 +1,43 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceMonitor_Parse(t *testing.T) {
+	t.Parallel()
+
+	load, err := parseLoadAvg(strings.NewReader("1.50 0.80 0.40 2/512 12345\n"))
+	require.NoError(t, err)
+	require.Equal(t, 1.5, load)
+
+	_, err = parseLoadAvg(strings.NewReader(""))
+	require.Error(t, err)
+
+	usage, err := parseMemInfo(strings.NewReader(
+		"MemTotal:       16000 kB\nMemFree:         2000 kB\nMemAvailable:    4000 kB\n"))
+	require.NoError(t, err)
+	require.Equal(t, 0.75, usage)
+
+	_, err = parseMemInfo(strings.NewReader("MemFree: 2000 kB\n"))
+	require.Error(t, err)
+}
+
+func TestResourceMonitor_NoProc(t *testing.T) {
+	t.Parallel()
+
+	// Without readable /proc files, the monitor reports no pressure.
+	monitor := &procResourceMonitor{procDir: filepath.Join(t.TempDir(), "proc")}
+	require.Equal(t, 0.0, monitor.CpuLoad())
+	require.Equal(t, 0.0, monitor.MemoryUsage())
+
+	require.NoError(t, os.Mkdir(monitor.procDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(monitor.procDir, "meminfo"),
+		[]byte("MemTotal: 1000 kB\nMemAvailable: 100 kB\n"), 0600))
+	require.InDelta(t, 0.9, monitor.MemoryUsage(), 1e-9)
+}
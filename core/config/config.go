@@ -21,6 +21,9 @@ type HeartConfig struct {
 	ShortcutPreparams bool   `toml:"shortcut-preparams"`
 	SisuServerUrl     string `toml:"sisu-server-url"`
 	Port              int    `toml:"port"`
+	// EnablePresign makes EdDSA presigns at the end of every block so that EdDSA keysigns only need
+	// one online round. Presign is off by default.
+	EnablePresign bool `toml:"enable-presign"`
 
 	Db         DbConfig                   `toml:"db"`
 	Connection p2ptypes.ConnectionsConfig `toml:"connection"`
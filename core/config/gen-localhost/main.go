@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/sisu-network/dheart/core/config"
+	p2ptypes "github.com/sisu-network/dheart/p2p/types"
 )
 
 func genLocalhostConfig() {
@@ -24,6 +25,11 @@ func genLocalhostConfig() {
 		Password: "password",
 		Schema:   "dheart",
 	}
+	cfg.Connection = p2ptypes.ConnectionsConfig{
+		Host:       "0.0.0.0",
+		Port:       28300,
+		Rendezvous: "rendezvous",
+	}
 
 	configFilePath := filepath.Join(cfg.HomeDir, "./dheart.toml")
 	config.WriteConfigFile(configFilePath, cfg)
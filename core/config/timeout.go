@@ -11,6 +11,9 @@ type TimeoutConfig struct {
 	MonitorMessageTimeout  time.Duration
 	SelectionLeaderTimeout time.Duration
 	SelectionMemberTimeout time.Duration
+
+	// Maximum time a signing work can wait in the request queue before it is dropped.
+	SigningQueueTimeout time.Duration
 }
 
 func NewDefaultTimeoutConfig() TimeoutConfig {
@@ -23,5 +26,6 @@ func NewDefaultTimeoutConfig() TimeoutConfig {
 		MonitorMessageTimeout:  time.Second * 15,
 		SelectionLeaderTimeout: selectionLeaderTimeout + time.Second*15,
 		SelectionMemberTimeout: selectionLeaderTimeout,
+		SigningQueueTimeout:    defaultJobTimeout,
 	}
 }
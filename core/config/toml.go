@@ -15,6 +15,7 @@ use-on-memory = {{ .UseOnMemory }}
 shortcut-preparams = {{ .ShortcutPreparams }}
 sisu-server-url = "{{ .SisuServerUrl }}"
 port = {{ .Port }}
+enable-presign = {{ .EnablePresign }}
 
 ###############################################################################
 ###                        Database Configuration                           ###
@@ -25,13 +26,17 @@ port = {{ .Port }}
 	username = "{{ .Db.Username }}"
 	password = "{{ .Db.Password }}"
 	schema = "{{ .Db.Schema }}"
-	migration-path = "{{ .Db.MigrationPath }}"
+	in-memory = {{ .Db.InMemory }}
 [connection]
-  host = "0.0.0.0"
-  port = 28300
-  rendezvous = "rendezvous"
-  peers = {{ .Connection.BootstrapPeers }}
-`
+  host = "{{ .Connection.Host }}"
+  port = {{ .Connection.Port }}
+  rendezvous = "{{ .Connection.Rendezvous }}"
+{{ range .Connection.Peers }}
+[[connection.peers]]
+  address = "{{ .Address }}"
+  pubkey = "{{ .PubKey }}"
+  pubkey_type = "{{ .PubKeyType }}"
+{{ end }}`
 
 var configTemplate *template.Template
 
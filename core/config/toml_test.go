@@ -0,0 +1,45 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	p2ptypes "github.com/sisu-network/dheart/p2p/types"
+)
+
+func TestWriteConfigFile(t *testing.T) {
+	t.Parallel()
+
+	cfg := HeartConfig{
+		HomeDir:           "/tmp/dheart",
+		ShortcutPreparams: true,
+		SisuServerUrl:     "http://127.0.0.1:25456",
+		Port:              5678,
+		EnablePresign:     true,
+		Db: DbConfig{
+			Schema:   "dheart0",
+			InMemory: true,
+		},
+		Connection: p2ptypes.ConnectionsConfig{
+			Host:       "127.0.0.1",
+			Port:       28300,
+			Rendezvous: "rendezvous",
+			Peers: []*p2ptypes.Peer{
+				{
+					Address:    "/ip4/127.0.0.1/tcp/28301/p2p/16Uiu2HAm1VbuonZJDGXXr91ubZGwcc7jaAWGqg8Mw1R8gBsKEG1M",
+					PubKey:     "025a27d8223ab5dc823845d58ee9316559494b9b67b554736db41bd46e07553c98",
+					PubKeyType: "secp256k1",
+				},
+			},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "dheart.toml")
+	WriteConfigFile(path, cfg)
+
+	read, err := ReadConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, cfg, read)
+}
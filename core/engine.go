@@ -1,9 +1,10 @@
 package core
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	ctypes "github.com/cosmos/cosmos-sdk/crypto/types"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -14,8 +15,10 @@ import (
 	"github.com/sisu-network/dheart/core/config"
 	"github.com/sisu-network/dheart/core/signer"
 	"github.com/sisu-network/dheart/db"
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
 	"github.com/sisu-network/dheart/p2p"
 	p2ptypes "github.com/sisu-network/dheart/p2p/types"
+	"github.com/sisu-network/dheart/tools"
 	htypes "github.com/sisu-network/dheart/types"
 	"github.com/sisu-network/dheart/types/common"
 	commonTypes "github.com/sisu-network/dheart/types/common"
@@ -27,9 +30,23 @@ import (
 
 const (
 	MaxWorker          = 2
+	MaxQueueSize       = 64
 	BatchSize          = 4
 	MaxBatchSize       = 4
 	MaxOutMsgCacheSize = 100
+	// The number of recently finished work ids that the engine remembers to reject duplicated works.
+	MaxFinishedWorkCacheSize = 1024
+	// A node tells the leader that it is busy when any of these limits is reached.
+	MaxBusyQueueSize = MaxQueueSize / 2
+	MaxCpuLoad       = 2.0
+	MaxMemoryUsage   = 0.9
+	// How often Stop checks if the running works have finished.
+	StopCheckInterval = time.Millisecond * 200
+)
+
+var (
+	ErrEngineStopped    = errors.New("engine has been stopped")
+	ErrInvalidSignature = errors.New("invalid message signature")
 )
 
 type Engine interface {
@@ -37,6 +54,8 @@ type Engine interface {
 
 	AddNodes(nodes []*Node)
 
+	RemoveNodes(nodes []*Node)
+
 	AddRequest(request *types.WorkRequest) error
 
 	OnNetworkMessage(message *p2ptypes.P2PMessage)
@@ -44,6 +63,10 @@ type Engine interface {
 	ProcessNewMessage(tssMsg *commonTypes.TssMessage) error
 
 	GetActiveWorkerCount() int
+
+	// Stop stops accepting works and waits up to gracePeriod for the running works to finish. Works
+	// in the queue and works that are still running after the grace period are reported as failed.
+	Stop(gracePeriod time.Duration)
 }
 
 type EngineCallback interface {
@@ -75,16 +98,22 @@ type defaultEngine struct {
 	///////////////////////
 	// Mutable data. Any data change requires a lock operation.
 	///////////////////////
-	workers      map[string]worker.Worker
-	requestQueue *requestQueue
+	workers map[string]worker.Worker
+	// The requests of the running works, including the works whose workers are being created.
+	requests      map[string]*types.WorkRequest
+	requestQueue  *requestQueue
+	finishedWorks tools.CircularQueue
 
 	workLock *sync.RWMutex
+	// True when the engine does not accept works anymore. Guarded by workLock.
+	stopped bool
 	// Cache all message before a worker starts
 	preworkCache *cache.MessageCache
 	// Cache messages during and after worker's execution.
 	workCache       *cache.WorkMessageCache
 	nodeLock        *sync.RWMutex
 	presignsManager components.AvailablePresigns
+	resources       components.ResourceMonitor
 }
 
 func NewEngine(myNode *Node, cm p2p.ConnectionManager, db db.Database, callback EngineCallback,
@@ -95,7 +124,9 @@ func NewEngine(myNode *Node, cm p2p.ConnectionManager, db db.Database, callback
 		db:              db,
 		cm:              cm,
 		workers:         make(map[string]worker.Worker),
-		requestQueue:    NewRequestQueue(),
+		requests:        make(map[string]*types.WorkRequest),
+		requestQueue:    NewRequestQueue(MaxQueueSize),
+		finishedWorks:   tools.NewCircularQueue(MaxFinishedWorkCacheSize),
 		workLock:        &sync.RWMutex{},
 		preworkCache:    cache.NewMessageCache(),
 		callback:        callback,
@@ -103,6 +134,7 @@ func NewEngine(myNode *Node, cm p2p.ConnectionManager, db db.Database, callback
 		signer:          signer.NewDefaultSigner(privateKey),
 		nodeLock:        &sync.RWMutex{},
 		presignsManager: components.NewAvailPresignManager(db),
+		resources:       components.NewResourceMonitor(),
 		config:          config,
 		workCache:       cache.NewWorkMessageCache(cache.MaxMessagePerNode, myNode.PartyId),
 	}
@@ -126,7 +158,22 @@ func (engine *defaultEngine) AddNodes(nodes []*Node) {
 	}
 }
 
+// RemoveNodes removes nodes from the engine. Works that are running with these nodes do not send
+// messages to them anymore and fail if they cannot finish without them.
+func (engine *defaultEngine) RemoveNodes(nodes []*Node) {
+	engine.nodeLock.Lock()
+	defer engine.nodeLock.Unlock()
+
+	for _, node := range nodes {
+		delete(engine.nodes, node.PeerId.String())
+	}
+}
+
 func (engine *defaultEngine) AddRequest(request *types.WorkRequest) error {
+	if engine.isStopped() {
+		return ErrEngineStopped
+	}
+
 	if err := request.Validate(); err != nil {
 		log.Error(err)
 		return err
@@ -141,8 +188,41 @@ func (engine *defaultEngine) AddRequest(request *types.WorkRequest) error {
 		}
 	}
 
-	if engine.requestQueue.AddWork(request) {
-		engine.startNextWork()
+	// Only keysigns asked by Sisu have a deadline. Presigns are made in the background and nobody waits
+	// for them.
+	if request.IsSigning() && !request.IsPresign() && request.Deadline.IsZero() &&
+		engine.config.SigningQueueTimeout > 0 {
+		request.Deadline = time.Now().Add(engine.config.SigningQueueTimeout)
+	}
+
+	if err := engine.enqueueWork(request); err != nil {
+		return err
+	}
+
+	engine.startNextWork()
+
+	return nil
+}
+
+// enqueueWork adds a work to the queue. Works that are running or have recently finished are
+// rejected under the same lock that moves works from the queue to the running works. Works that are
+// still in the queue are rejected by the queue itself.
+func (engine *defaultEngine) enqueueWork(request *types.WorkRequest) error {
+	engine.workLock.Lock()
+	defer engine.workLock.Unlock()
+
+	if engine.stopped {
+		return ErrEngineStopped
+	}
+
+	if engine.requests[request.WorkId] != nil || engine.finishedWorks.Get(request.WorkId) != nil {
+		log.Warnf("Work %s has already been submitted", request.WorkId)
+		return ErrWorkExisted
+	}
+
+	if err := engine.requestQueue.AddWork(request); err != nil {
+		log.Warnf("Cannot add work %s to the queue, err = %v", request.WorkId, err)
+		return err
 	}
 
 	return nil
@@ -156,17 +236,23 @@ func (engine *defaultEngine) startWork(request *types.WorkRequest) {
 
 	// Create a new worker.
 	switch request.WorkType {
-	case types.EcKeygen, types.EdKeygen:
+	case types.EcKeygen, types.EdKeygen, types.SchnorrKeygen:
 		w = worker.NewKeygenWorker(request, myPid, engine, engine.db, engine,
 			engine.config)
 
-	case types.EcSigning, types.EdSigning:
+	case types.EcSigning, types.EdSigning, types.EdPresign, types.SchnorrSigning:
 		w = worker.NewSigningWorker(request, myPid, engine, engine.db, engine,
 			engine.config, MaxBatchSize, engine.presignsManager)
 	}
 
 	engine.workLock.Lock()
 
+	// The work has been stopped by Stop, which already reported it.
+	if engine.requests[request.WorkId] != request {
+		engine.workLock.Unlock()
+		return
+	}
+
 	engine.workers[request.WorkId] = w
 	cachedMsgs := engine.preworkCache.PopAllMessages(request.WorkId, nil)
 	log.Info("Starting a work with id ", request.WorkId, " with cache size ", len(cachedMsgs))
@@ -231,10 +317,8 @@ func (engine *defaultEngine) OnAskMessage(tssMsg *commonTypes.TssMessage) error
 	}
 
 	var dest *tss.PartyID
-	for _, node := range engine.nodes {
-		if node.PartyId.Id == tssMsg.From {
-			dest = node.PartyId
-		}
+	if node := engine.getNodeFromPeerId(tssMsg.From); node != nil {
+		dest = node.PartyId
 	}
 
 	if dest != nil {
@@ -246,43 +330,118 @@ func (engine *defaultEngine) OnAskMessage(tssMsg *commonTypes.TssMessage) error
 	return nil
 }
 
-// finishWorker removes a worker from the current worker pool.
-func (engine *defaultEngine) finishWorker(workId string) {
+// removeWorker removes a worker from the current worker pool and returns it. It returns nil if the
+// worker has already been removed, e.g. by Stop. Only the caller that removes a worker reports the
+// result of its work so that a work never has two results.
+func (engine *defaultEngine) removeWorker(workId string) worker.Worker {
 	engine.workLock.Lock()
+	defer engine.workLock.Unlock()
+
+	w := engine.workers[workId]
+	if w == nil {
+		return nil
+	}
+
 	delete(engine.workers, workId)
-	engine.workLock.Unlock()
+	delete(engine.requests, workId)
+	engine.finishedWorks.Add(workId, true)
 
-	// fmt.Println
 	s := fmt.Sprintf("%s finished work %s: remaining work id ", engine.myPid.Id, workId)
 	for id := range engine.workers {
 		s += id
 	}
 	log.Verbosef(s)
 
-	// Start next work
-	engine.startNextWork()
+	return w
 }
 
 // startNextWork gets a request from the queue (if not empty) and execute it. If there is no
 // available worker, wait for one of the current worker to finish before running.
 func (engine *defaultEngine) startNextWork() {
+	engine.dropExpiredWorks()
+
 	engine.workLock.Lock()
-	if len(engine.workers) >= MaxWorker {
-		log.Verbosef("Max work reach, worker queue len = %d", len(engine.workers))
+	if engine.stopped {
 		engine.workLock.Unlock()
 		return
 	}
 
-	nextWork := engine.requestQueue.Pop()
-	engine.workLock.Unlock()
+	// The requests include the works that have been taken from the queue but whose workers have not
+	// been created yet.
+	if len(engine.requests) >= MaxWorker {
+		log.Verbosef("Max work reach, worker queue len = %d", len(engine.requests))
+		engine.workLock.Unlock()
+		return
+	}
 
+	nextWork := engine.requestQueue.Pop()
 	if nextWork == nil {
+		engine.workLock.Unlock()
 		return
 	}
+	engine.requests[nextWork.WorkId] = nextWork
+	engine.workLock.Unlock()
 
 	engine.startWork(nextWork)
 }
 
+// dropExpiredWorks removes all stale works from the queue and reports them as failures.
+func (engine *defaultEngine) dropExpiredWorks() {
+	expired := engine.requestQueue.RemoveExpired(time.Now())
+	for _, request := range expired {
+		log.Warnf("Work %s has passed its deadline %v, dropping it", request.WorkId, request.Deadline)
+		engine.callback.OnWorkFailed(request, nil)
+	}
+}
+
+func (engine *defaultEngine) isStopped() bool {
+	engine.workLock.RLock()
+	defer engine.workLock.RUnlock()
+
+	return engine.stopped
+}
+
+func (engine *defaultEngine) Stop(gracePeriod time.Duration) {
+	engine.workLock.Lock()
+	if engine.stopped {
+		engine.workLock.Unlock()
+		return
+	}
+	engine.stopped = true
+	engine.workLock.Unlock()
+
+	// Works in the queue are not started anymore.
+	for _, request := range engine.requestQueue.RemoveAll() {
+		log.Warnf("Work %s has not started before shutdown, dropping it", request.WorkId)
+		engine.callback.OnWorkFailed(request, nil)
+	}
+
+	deadline := time.Now().Add(gracePeriod)
+	for engine.GetActiveWorkerCount() > 0 && time.Now().Before(deadline) {
+		time.Sleep(StopCheckInterval)
+	}
+
+	// Stop the works that have not finished in the grace period. The works are removed under the lock
+	// so that their workers cannot report a result anymore.
+	engine.workLock.Lock()
+	workers := engine.workers
+	requests := engine.requests
+	engine.workers = make(map[string]worker.Worker)
+	engine.requests = make(map[string]*types.WorkRequest)
+	for workId := range requests {
+		engine.finishedWorks.Add(workId, true)
+	}
+	engine.workLock.Unlock()
+
+	for workId, request := range requests {
+		log.Warnf("Work %s has not finished before shutdown, stopping it", workId)
+		if w := workers[workId]; w != nil {
+			w.Stop()
+		}
+		engine.callback.OnWorkFailed(request, nil)
+	}
+}
+
 func (engine *defaultEngine) getNodeFromPeerId(peerId string) *Node {
 	engine.nodeLock.RLock()
 	defer engine.nodeLock.RUnlock()
@@ -348,7 +507,7 @@ func (engine *defaultEngine) cacheWorkMsg(signedMsg *common.SignedMessage) {
 
 // sendSignMessaged sends data to the network.
 func (engine *defaultEngine) sendSignMessaged(signedMessage *common.SignedMessage, pIDs []*tss.PartyID) {
-	bz, err := json.Marshal(signedMessage)
+	bz, err := common.MarshalSignedMessage(signedMessage)
 	if err != nil {
 		log.Errorf("error when marshalling message %w", err)
 		return
@@ -363,11 +522,11 @@ func (engine *defaultEngine) sendSignMessaged(signedMessage *common.SignedMessag
 			continue
 		}
 
-		node := engine.getNodeFromPeerId(pid.Id)
-
+		node := engine.nodes[pid.Id]
 		if node == nil {
+			// The node could have been removed while the work is running.
 			log.Errorf("Cannot find node with party key %s", pid.Id)
-			return
+			continue
 		}
 
 		peerIds = append(peerIds, node.PeerId)
@@ -382,7 +541,7 @@ func (engine *defaultEngine) sendSignMessaged(signedMessage *common.SignedMessag
 
 // getSignedMessageBytes signs a tss message and returns serialized bytes of the signed message.
 func (engine *defaultEngine) getSignedMessageBytes(tssMessage *common.TssMessage) (*common.SignedMessage, error) {
-	serialized, err := json.Marshal(tssMessage)
+	serialized, err := tssMessage.GetSignBytes()
 	if err != nil {
 		return nil, fmt.Errorf("error when marshalling message %w", err)
 	}
@@ -409,8 +568,8 @@ func (engine *defaultEngine) OnNetworkMessage(message *p2ptypes.P2PMessage) {
 		return
 	}
 
-	signedMessage := &common.SignedMessage{}
-	if err := json.Unmarshal(message.Data, signedMessage); err != nil {
+	signedMessage, err := common.UnmarshalSignedMessage(message.Data)
+	if err != nil {
 		log.Error("Error when unmarshal p2p message", err)
 		return
 	}
@@ -421,7 +580,25 @@ func (engine *defaultEngine) OnNetworkMessage(message *p2ptypes.P2PMessage) {
 		return
 	}
 
-	// TODO: Check message signature here.
+	if signedMessage.From != tssMessage.From {
+		log.Errorf("Signed sender %s does not match the tss message sender %s", signedMessage.From, tssMessage.From)
+		return
+	}
+
+	// Only update messages are forwarded by other nodes when they are asked for. Every other message
+	// must come from the peer of its sender.
+	if tssMessage.Type != common.TssMessage_UPDATE_MESSAGES && signedMessage.From != node.PartyId.Id {
+		log.Errorf("Message of type %s from %s is sent by another peer %s", tssMessage.Type, signedMessage.From,
+			message.FromPeerId)
+		return
+	}
+
+	if err := engine.verifySignature(signedMessage, common.IsLegacySignedMessage(message.Data)); err != nil {
+		log.Errorf("Cannot verify message from %s sent by peer %s, err = %v", signedMessage.From,
+			message.FromPeerId, err)
+		return
+	}
+
 	if tssMessage.Type == common.TssMessage_UPDATE_MESSAGES && len(tssMessage.UpdateMessages) > 0 &&
 		tssMessage.IsBroadcast() {
 		engine.cacheWorkMsg(signedMessage)
@@ -432,6 +609,38 @@ func (engine *defaultEngine) OnNetworkMessage(message *p2ptypes.P2PMessage) {
 	}
 }
 
+// verifySignature checks that a signed message is signed by the node of its sender. Nodes that send
+// messages in the legacy JSON encoding sign the JSON bytes of the tss message. A message of such a
+// node can also be forwarded in the protobuf encoding by an upgraded node that is asked for it.
+func (engine *defaultEngine) verifySignature(signedMessage *common.SignedMessage, legacy bool) error {
+	sender := engine.getNodeFromPeerId(signedMessage.From)
+	if sender == nil {
+		return fmt.Errorf("cannot find node of sender %s", signedMessage.From)
+	}
+
+	if !legacy {
+		signBytes, err := signedMessage.TssMessage.GetSignBytes()
+		if err != nil {
+			return err
+		}
+
+		if sender.PubKey.VerifySignature(signBytes, signedMessage.Signature) {
+			return nil
+		}
+	}
+
+	signBytes, err := signedMessage.TssMessage.GetLegacySignBytes()
+	if err != nil {
+		return err
+	}
+
+	if !sender.PubKey.VerifySignature(signBytes, signedMessage.Signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
 func (engine *defaultEngine) GetActiveWorkerCount() int {
 	engine.workLock.RLock()
 	defer engine.workLock.RUnlock()
@@ -441,27 +650,29 @@ func (engine *defaultEngine) GetActiveWorkerCount() int {
 
 // OnNodeNotSelected is called when this node is not selected by the leader in the election round.
 func (engine *defaultEngine) OnNodeNotSelected(request *types.WorkRequest) {
+	if engine.removeWorker(request.WorkId) == nil {
+		log.Warnf("Work %s has already finished", request.WorkId)
+		return
+	}
+
 	switch request.WorkType {
 	case types.EcKeygen:
 		// This should not happen as in keygen all nodes should be selected.
 
-	case types.EcSigning:
+	case types.EcSigning, types.EdSigning, types.SchnorrSigning:
 		result := &htypes.KeysignResult{
 			Outcome: htypes.OutcometNotSelected,
 		}
 		engine.callback.OnWorkSigningFinished(request, result)
 	}
 
-	// Finish this worker and start the next one (if any).
-	engine.finishWorker(request.WorkId)
+	// Start the next work (if any).
+	engine.startNextWork()
 }
 
 func (engine *defaultEngine) OnWorkFailed(request *types.WorkRequest) {
 	// Clear all the worker's resources
-	engine.workLock.RLock()
-	worker := engine.workers[request.WorkId]
-	engine.workLock.RUnlock()
-
+	worker := engine.removeWorker(request.WorkId)
 	if worker == nil {
 		log.Error("Worker " + request.WorkId + " does not exist.")
 		return
@@ -470,13 +681,68 @@ func (engine *defaultEngine) OnWorkFailed(request *types.WorkRequest) {
 	culprits := worker.GetCulprits()
 	engine.callback.OnWorkFailed(request, culprits)
 
-	// Finish this worker and start the next one (if any).
-	engine.finishWorker(request.WorkId)
+	// Start the next work (if any).
+	engine.startNextWork()
 }
 
-func (engine *defaultEngine) GetAvailablePresigns(batchSize int, n int,
+func (engine *defaultEngine) GetAvailablePresigns(keyType string, keyLabel string, batchSize int, n int,
 	allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
-	return engine.presignsManager.GetAvailablePresigns(batchSize, n, allPids)
+	return engine.presignsManager.GetAvailablePresigns(keyType, keyLabel, batchSize, n, allPids)
+}
+
+// IsPeerDown implements worker.WorkerCallback interface. The party id of a node is its peer id.
+func (engine *defaultEngine) IsPeerDown(pid string) bool {
+	peerId, err := peer.Decode(pid)
+	if err != nil {
+		return false
+	}
+
+	return engine.cm.IsPeerDown(peerId)
+}
+
+// GetAvailability implements worker.WorkerCallback interface. This node is available for a work if
+// it can do the work and is not overloaded.
+func (engine *defaultEngine) GetAvailability(request *types.WorkRequest) (common.AvailabilityResponseMessage_ANSWER, int) {
+	if (request.WorkType == types.EcSigning && request.EcSigningInput == nil) ||
+		(request.WorkType == types.EdSigning && request.EdSigningInput == nil) ||
+		(request.WorkType == types.EdPresign && request.EdSigningInput == nil) ||
+		(request.WorkType == types.SchnorrSigning && request.SchnorrSigningInput == nil) {
+		log.Warnf("Work %s: this node does not hold the key %s", request.WorkId, request.KeyLabel)
+		return common.AvailabilityResponseMessage_NO, 0
+	}
+
+	// The worker of this request already holds a slot.
+	engine.workLock.RLock()
+	if engine.stopped {
+		engine.workLock.RUnlock()
+		return common.AvailabilityResponseMessage_NO, 0
+	}
+	others := len(engine.workers)
+	if _, ok := engine.workers[request.WorkId]; ok {
+		others--
+	}
+	engine.workLock.RUnlock()
+	maxJob := MaxWorker - others
+	if maxJob <= 0 {
+		return common.AvailabilityResponseMessage_NO, 0
+	}
+
+	if queueSize := engine.requestQueue.Size(); queueSize >= MaxBusyQueueSize {
+		log.Verbosef("Work %s: this node is busy, queue size = %d", request.WorkId, queueSize)
+		return common.AvailabilityResponseMessage_NO, maxJob
+	}
+
+	if load := engine.resources.CpuLoad(); load >= MaxCpuLoad {
+		log.Verbosef("Work %s: this node is busy, cpu load = %f", request.WorkId, load)
+		return common.AvailabilityResponseMessage_NO, maxJob
+	}
+
+	if usage := engine.resources.MemoryUsage(); usage >= MaxMemoryUsage {
+		log.Verbosef("Work %s: this node is busy, memory usage = %f", request.WorkId, usage)
+		return common.AvailabilityResponseMessage_NO, maxJob
+	}
+
+	return common.AvailabilityResponseMessage_YES, maxJob
 }
 
 func (engine *defaultEngine) GetPresignOutputs(presignIds []string) []*ecsigning.SignatureData_OneRoundData {
@@ -489,7 +755,22 @@ func (engine *defaultEngine) GetPresignOutputs(presignIds []string) []*ecsigning
 	return loaded
 }
 
+func (engine *defaultEngine) GetEdPresignOutputs(presignIds []string) []*edpresign.PresignData {
+	loaded, err := engine.db.LoadEdPresign(presignIds)
+	if err != nil {
+		log.Error("Cannot load presign, err =", err)
+		return make([]*edpresign.PresignData, 0)
+	}
+
+	return loaded
+}
+
 func (engine *defaultEngine) OnWorkerResult(request *types.WorkRequest, result *worker.WorkerResult) {
+	if engine.removeWorker(request.WorkId) == nil {
+		log.Warnf("Work %s has already finished", request.WorkId)
+		return
+	}
+
 	switch request.WorkType {
 	// Ecdsa
 	case types.EcKeygen:
@@ -502,10 +783,18 @@ func (engine *defaultEngine) OnWorkerResult(request *types.WorkRequest, result *
 		engine.onEdKeygenFinished(request, worker.GetEdKeygenOutputs(result.JobResults)[0])
 	case types.EdSigning:
 		engine.onEdSigningFinished(request, worker.GetEdSigningOutputs(result.JobResults))
+	case types.EdPresign:
+		engine.onEdPresignFinished(request, worker.GetEdPresignOutputs(result.JobResults))
+
+	// Schnorr
+	case types.SchnorrKeygen:
+		engine.onSchnorrKeygenFinished(request, worker.GetSchnorrKeygenOutputs(result.JobResults)[0])
+	case types.SchnorrSigning:
+		engine.onSchnorrSigningFinished(request, worker.GetSchnorrSigningOutputs(result.JobResults))
 	default:
 		log.Error("OnWorkerResult: Unknown work type ", request.WorkType)
 	}
 
-	// Finish this worker and start the next one (if any).
-	engine.finishWorker(request.WorkId)
+	// Start the next work (if any).
+	engine.startNextWork()
 }
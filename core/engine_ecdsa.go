@@ -3,6 +3,8 @@ package core
 import (
 	cryptoec "crypto/ecdsa"
 	"encoding/hex"
+	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	htypes "github.com/sisu-network/dheart/types"
@@ -30,6 +32,7 @@ func (engine *defaultEngine) onEcKeygenFinished(request *types.WorkRequest, outp
 	// Make a callback and start next work.
 	result := htypes.KeygenResult{
 		KeyType:     request.KeygenType,
+		KeyLabel:    request.KeyLabel,
 		PubKeyBytes: publicKeyBytes,
 		Outcome:     htypes.OutcomeSuccess,
 	}
@@ -41,22 +44,36 @@ func (engine *defaultEngine) onEcSigningFinished(request *types.WorkRequest, dat
 	log.Infof("%s: Signing finished for Ecdsa workId %s", engine.myPid.Id[len(engine.myPid.Id)-4:],
 		request.WorkId)
 
+	ecdsaPub := request.EcSigningInput.ECDSAPub
+	pubkey := &cryptoec.PublicKey{
+		Curve: tss.EC(tss.EcdsaScheme),
+		X:     ecdsaPub.X(),
+		Y:     ecdsaPub.Y(),
+	}
+
 	signatures := make([][]byte, len(data))
 	for i, sig := range data {
-		bitSizeInBytes := tss.EC(tss.EcdsaScheme).Params().BitSize / 8
-		r := utils.PadToLengthBytesForSignature(data[i].R, bitSizeInBytes)
-		s := utils.PadToLengthBytesForSignature(data[i].S, bitSizeInBytes)
+		// Only the low-S form of a signature is accepted by most chains. The recovery id is computed
+		// again for the normalized signature.
+		r := new(big.Int).SetBytes(sig.R)
+		s, recovery, err := utils.VerifyEcSignature(pubkey, request.Messages[i], r,
+			new(big.Int).SetBytes(sig.S))
+		if err != nil {
+			log.Errorf("Invalid ECDSA signature for workId %s, err = %v, msg hex = %s, hex of R,S = %s %s",
+				request.WorkId, err, hex.EncodeToString(request.Messages[i]), hex.EncodeToString(sig.R),
+				hex.EncodeToString(sig.S))
 
-		signatures[i] = append(r, s...)
-		signatures[i] = append(signatures[i], data[i].SignatureRecovery[0])
-
-		if len(signatures[i]) != 65 {
-			log.Error("Signatures length is not 65: hex of R,S,Recovery = ",
-				hex.EncodeToString(sig.R),
-				hex.EncodeToString(sig.S),
-				hex.EncodeToString(data[i].SignatureRecovery),
-			)
+			engine.callback.OnWorkSigningFinished(request, &htypes.KeysignResult{
+				Outcome:   htypes.OutcomeFailure,
+				ErrMesage: fmt.Sprintf("signature of message %d is invalid: %v", i, err),
+			})
+			return
 		}
+
+		bitSizeInBytes := tss.EC(tss.EcdsaScheme).Params().BitSize / 8
+		signatures[i] = append(utils.PadToLengthBytesForSignature(r.Bytes(), bitSizeInBytes),
+			utils.PadToLengthBytesForSignature(s.Bytes(), bitSizeInBytes)...)
+		signatures[i] = append(signatures[i], recovery)
 	}
 
 	result := &htypes.KeysignResult{
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sisu-network/dheart/core/config"
+	"github.com/sisu-network/dheart/db"
+	htypes "github.com/sisu-network/dheart/types"
+	"github.com/sisu-network/dheart/worker/types"
+	libCommon "github.com/sisu-network/tss-lib/common"
+	libCrypto "github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/ecdsa/keygen"
+	"github.com/sisu-network/tss-lib/tss"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_OnEcSigningFinished(t *testing.T) {
+	t.Parallel()
+
+	privKeys, nodes, pIDs, _ := GetEngineTestData(2)
+	var result *htypes.KeysignResult
+	engine := NewEngine(nodes[0], NewMockConnectionManager(nodes[0].PeerId.String(), nil),
+		db.NewMockDatabase(), &MockEngineCallback{
+			OnWorkSigningFinishedFunc: func(request *types.WorkRequest, r *htypes.KeysignResult) {
+				result = r
+			},
+		}, privKeys[0], config.NewDefaultTimeoutConfig()).(*defaultEngine)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	hash := crypto.Keccak256([]byte("message"))
+	expected, err := crypto.Sign(hash, key)
+	require.NoError(t, err)
+
+	keygenOutput := &keygen.LocalPartySaveData{
+		ECDSAPub: libCrypto.NewECPointNoCurveCheck(tss.EC(tss.EcdsaScheme), key.X, key.Y),
+	}
+	request := types.NewEcSigningRequest("signing", pIDs, 1, [][]byte{hash}, []string{"eth"}, keygenOutput)
+
+	// The high-S form of the signature with a wrong recovery id is normalized.
+	n := tss.EC(tss.EcdsaScheme).Params().N
+	highS := new(big.Int).Sub(n, new(big.Int).SetBytes(expected[32:64]))
+	engine.onEcSigningFinished(request, []*libCommon.ECSignature{{
+		R:                 expected[:32],
+		S:                 highS.Bytes(),
+		SignatureRecovery: []byte{expected[64]},
+	}})
+	require.Equal(t, htypes.OutcomeSuccess, result.Outcome)
+	require.Equal(t, [][]byte{expected}, result.Signatures)
+
+	// A signature of another message fails the work.
+	request.Messages = [][]byte{crypto.Keccak256([]byte("another message"))}
+	engine.onEcSigningFinished(request, []*libCommon.ECSignature{{
+		R:                 expected[:32],
+		S:                 expected[32:64],
+		SignatureRecovery: []byte{expected[64]},
+	}})
+	require.Equal(t, htypes.OutcomeFailure, result.Outcome)
+	require.Empty(t, result.Signatures)
+	require.NotEmpty(t, result.ErrMesage)
+}
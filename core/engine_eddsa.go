@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/decred/dcrd/dcrec/edwards/v2"
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
 	"github.com/sisu-network/dheart/types"
 	htypes "github.com/sisu-network/dheart/types"
 	wtypes "github.com/sisu-network/dheart/worker/types"
@@ -19,6 +20,7 @@ func (engine *defaultEngine) onEdKeygenFinished(request *wtypes.WorkRequest, out
 	// Make a callback and start next work.
 	result := types.KeygenResult{
 		KeyType:     request.KeygenType,
+		KeyLabel:    request.KeyLabel,
 		PubKeyBytes: pubkey.Serialize(),
 		Outcome:     types.OutcomeSuccess,
 	}
@@ -26,6 +28,13 @@ func (engine *defaultEngine) onEdKeygenFinished(request *wtypes.WorkRequest, out
 	engine.callback.OnWorkKeygenFinished(&result)
 }
 
+// onEdPresignFinished is called when a presign work finishes. The worker has already added the
+// presigns to the presign pool of the key.
+func (engine *defaultEngine) onEdPresignFinished(request *wtypes.WorkRequest, data []*edpresign.PresignData) {
+	log.Infof("%s Presign finished for Eddsa workId %s, presign count = %d", engine.myPid.Id[len(engine.myPid.Id)-4:],
+		request.WorkId, len(data))
+}
+
 func (engine *defaultEngine) onEdSigningFinished(request *wtypes.WorkRequest, data []*edsigning.SignatureData) {
 	log.Infof("%s Signing finished for Eddsa workId %s", engine.myPid.Id[len(engine.myPid.Id)-4:],
 		request.WorkId)
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	schnorrkeygen "github.com/sisu-network/dheart/schnorr/keygen"
+	htypes "github.com/sisu-network/dheart/types"
+	"github.com/sisu-network/dheart/utils"
+	"github.com/sisu-network/dheart/worker/types"
+	"github.com/sisu-network/lib/log"
+	libCommon "github.com/sisu-network/tss-lib/common"
+)
+
+func (engine *defaultEngine) onSchnorrKeygenFinished(request *types.WorkRequest, output *schnorrkeygen.LocalPartySaveData) {
+	log.Info("Keygen finished for type ", request.KeygenType)
+
+	// Make a callback and start next work. The public key is the 32-byte x-only key of BIP-340.
+	result := htypes.KeygenResult{
+		KeyType:     request.KeygenType,
+		KeyLabel:    request.KeyLabel,
+		PubKeyBytes: utils.SerializeSchnorrPubKey(output.SchnorrPub),
+		Outcome:     htypes.OutcomeSuccess,
+	}
+
+	engine.callback.OnWorkKeygenFinished(&result)
+}
+
+func (engine *defaultEngine) onSchnorrSigningFinished(request *types.WorkRequest, data []*libCommon.ECSignature) {
+	log.Infof("%s: Signing finished for Schnorr workId %s", engine.myPid.Id[len(engine.myPid.Id)-4:],
+		request.WorkId)
+
+	pubKey := utils.SerializeSchnorrPubKey(request.SchnorrSigningInput.SchnorrPub)
+	signatures := make([][]byte, len(data))
+	for i, sig := range data {
+		if err := utils.VerifySchnorrSignature(pubKey, request.Messages[i], sig.Signature); err != nil {
+			log.Errorf("Invalid Schnorr signature for workId %s, err = %v, msg hex = %s, signature hex = %s",
+				request.WorkId, err, hex.EncodeToString(request.Messages[i]), hex.EncodeToString(sig.Signature))
+
+			engine.callback.OnWorkSigningFinished(request, &htypes.KeysignResult{
+				Outcome:   htypes.OutcomeFailure,
+				ErrMesage: fmt.Sprintf("signature of message %d is invalid: %v", i, err),
+			})
+			return
+		}
+
+		signatures[i] = sig.Signature
+	}
+
+	result := &htypes.KeysignResult{
+		Outcome:    htypes.OutcomeSuccess,
+		Signatures: signatures,
+	}
+
+	engine.callback.OnWorkSigningFinished(request, result)
+}
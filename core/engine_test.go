@@ -3,18 +3,23 @@ package core
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/sisu-network/dheart/core/components"
 	"github.com/sisu-network/dheart/core/config"
+	"github.com/sisu-network/dheart/db"
+	p2ptypes "github.com/sisu-network/dheart/p2p/types"
 	htypes "github.com/sisu-network/dheart/types"
 	"github.com/sisu-network/dheart/types/common"
 	"github.com/sisu-network/dheart/worker"
 	"github.com/sisu-network/dheart/worker/types"
 	"github.com/sisu-network/lib/log"
+	edkeygen "github.com/sisu-network/tss-lib/eddsa/keygen"
 	"github.com/sisu-network/tss-lib/tss"
+	"github.com/stretchr/testify/require"
 )
 
 func runEnginesWithDelay(engines []Engine, workId string, outCh chan *p2pDataWrapper, errCh chan error, done chan bool, delay time.Duration) {
@@ -56,8 +61,8 @@ func runEngineWithOptions(engines []Engine, workId string, outCh chan *p2pDataWr
 			for _, engine := range engines {
 				defaultEngine := engine.(*defaultEngine)
 				if defaultEngine.myNode.PeerId.String() == p2pMsgWrapper.To {
-					signedMessage := &common.SignedMessage{}
-					if err := json.Unmarshal(p2pMsgWrapper.msg.Data, signedMessage); err != nil {
+					signedMessage, err := common.UnmarshalSignedMessage(p2pMsgWrapper.msg.Data)
+					if err != nil {
 						panic(err)
 					}
 
@@ -125,7 +130,7 @@ func TestEngine_DelayStart(t *testing.T) {
 	log.Verbose("Running test with tss works starting at different time.")
 	n := 4
 
-	privKeys, nodes, pIDs, savedData := getEngineTestData(n)
+	privKeys, nodes, pIDs, savedData := GetEngineTestData(n)
 
 	errCh := make(chan error)
 	outCh := make(chan *p2pDataWrapper)
@@ -181,7 +186,7 @@ func TestEngine_SendDuplicateMessage(t *testing.T) {
 
 	n := 4
 
-	privKeys, nodes, pIDs, savedData := getEngineTestData(n)
+	privKeys, nodes, pIDs, savedData := GetEngineTestData(n)
 
 	errCh := make(chan error)
 	outCh := make(chan *p2pDataWrapper)
@@ -234,7 +239,7 @@ func TestEngine_SendDuplicateMessage(t *testing.T) {
 func TestEngine_JobTimeout(t *testing.T) {
 	n := 4
 
-	privKeys, nodes, pIDs, savedData := getEngineTestData(n)
+	privKeys, nodes, pIDs, savedData := GetEngineTestData(n)
 
 	errCh := make(chan error)
 	outCh := make(chan *p2pDataWrapper)
@@ -292,7 +297,7 @@ func TestEngine_JobTimeout(t *testing.T) {
 func TestEngine_MissingMessages(t *testing.T) {
 	n := 4
 
-	privKeys, nodes, pIDs, savedData := getEngineTestData(n)
+	privKeys, nodes, pIDs, savedData := GetEngineTestData(n)
 
 	errCh := make(chan error)
 	outCh := make(chan *p2pDataWrapper, n*10)
@@ -366,3 +371,305 @@ func TestEngine_MissingMessages(t *testing.T) {
 	// Run all engines
 	runEnginesWithDroppedMessages(engines, workId, outCh, errCh, done, drop)
 }
+
+func TestEngine_GetAvailability(t *testing.T) {
+	t.Parallel()
+
+	n := 2
+	privKeys, nodes, pIDs, savedData := GetEngineTestData(n)
+	resources := &components.MockResourceMonitor{}
+	engine := NewEngine(nodes[0], NewMockConnectionManager(nodes[0].PeerId.String(), nil),
+		db.NewMockDatabase(), &MockEngineCallback{}, privKeys[0], config.NewDefaultTimeoutConfig()).(*defaultEngine)
+	engine.resources = resources
+
+	request := types.NewEcSigningRequest("signing", worker.CopySortedPartyIds(pIDs), n-1,
+		[][]byte{[]byte("message")}, []string{"eth"}, savedData[0])
+	engine.workers[request.WorkId] = nil
+
+	answer, maxJob := engine.GetAvailability(request)
+	require.Equal(t, common.AvailabilityResponseMessage_YES, answer)
+	require.Equal(t, MaxWorker, maxJob)
+
+	// Another running work takes a worker slot.
+	engine.workers["other"] = nil
+	answer, maxJob = engine.GetAvailability(request)
+	require.Equal(t, common.AvailabilityResponseMessage_YES, answer)
+	require.Equal(t, MaxWorker-1, maxJob)
+
+	// The node is overloaded.
+	resources.CpuLoadFunc = func() float64 { return MaxCpuLoad }
+	answer, maxJob = engine.GetAvailability(request)
+	require.Equal(t, common.AvailabilityResponseMessage_NO, answer)
+	require.Equal(t, MaxWorker-1, maxJob)
+
+	resources.CpuLoadFunc = nil
+	resources.MemoryUsageFunc = func() float64 { return MaxMemoryUsage }
+	answer, _ = engine.GetAvailability(request)
+	require.Equal(t, common.AvailabilityResponseMessage_NO, answer)
+
+	resources.MemoryUsageFunc = nil
+	for i := 0; i < MaxBusyQueueSize; i++ {
+		require.NoError(t, engine.requestQueue.AddWork(types.NewEcSigningRequest(fmt.Sprintf("queued%d", i),
+			worker.CopySortedPartyIds(pIDs), n-1, [][]byte{[]byte("message")}, []string{"eth"}, savedData[0])))
+	}
+	answer, _ = engine.GetAvailability(request)
+	require.Equal(t, common.AvailabilityResponseMessage_NO, answer)
+
+	// The node cannot sign without the key.
+	request.EcSigningInput = nil
+	answer, maxJob = engine.GetAvailability(request)
+	require.Equal(t, common.AvailabilityResponseMessage_NO, answer)
+	require.Equal(t, 0, maxJob)
+}
+
+func TestEngine_Stop(t *testing.T) {
+	t.Parallel()
+
+	n := 2
+	privKeys, nodes, pIDs, savedData := GetEngineTestData(n)
+	failed := make([]string, 0)
+	callback := &MockEngineCallback{
+		OnWorkFailedFunc: func(request *types.WorkRequest, culprits []*tss.PartyID) {
+			failed = append(failed, request.WorkId)
+		},
+	}
+	engine := NewEngine(nodes[0], NewMockConnectionManager(nodes[0].PeerId.String(), nil),
+		db.NewMockDatabase(), callback, privKeys[0], config.NewDefaultTimeoutConfig()).(*defaultEngine)
+
+	request := types.NewEcSigningRequest("signing", worker.CopySortedPartyIds(pIDs), n-1,
+		[][]byte{[]byte("message")}, []string{"eth"}, savedData[0])
+	require.NoError(t, engine.requestQueue.AddWork(request))
+
+	// Works that have not started are reported as failed.
+	engine.Stop(time.Second)
+	require.Equal(t, []string{"signing"}, failed)
+	require.Equal(t, 0, engine.requestQueue.Size())
+
+	// The engine does not take new works.
+	require.Equal(t, ErrEngineStopped, engine.AddRequest(request))
+	answer, _ := engine.GetAvailability(request)
+	require.Equal(t, common.AvailabilityResponseMessage_NO, answer)
+}
+
+func TestEngine_StopRunningWork(t *testing.T) {
+	t.Parallel()
+
+	n := 2
+	privKeys, nodes, pIDs, savedData := GetEngineTestData(n)
+	failed := make([]string, 0)
+	finished := make([]string, 0)
+	callback := &MockEngineCallback{
+		OnWorkFailedFunc: func(request *types.WorkRequest, culprits []*tss.PartyID) {
+			failed = append(failed, request.WorkId)
+		},
+		OnWorkSigningFinishedFunc: func(request *types.WorkRequest, result *htypes.KeysignResult) {
+			finished = append(finished, request.WorkId)
+		},
+	}
+	engine := NewEngine(nodes[0], NewMockConnectionManager(nodes[0].PeerId.String(), nil),
+		db.NewMockDatabase(), callback, privKeys[0], config.NewDefaultTimeoutConfig()).(*defaultEngine)
+
+	request := types.NewEcSigningRequest("signing", worker.CopySortedPartyIds(pIDs), n-1,
+		[][]byte{[]byte("message")}, []string{"eth"}, savedData[0])
+	engine.requests[request.WorkId] = request
+	engine.workers[request.WorkId] = worker.NewSigningWorker(request, nodes[0].PartyId, engine, engine.db,
+		engine, engine.config, MaxBatchSize, engine.presignsManager)
+
+	// A work that is taken from the queue right before the shutdown is not started.
+	notStarted := types.NewEcSigningRequest("not_started", worker.CopySortedPartyIds(pIDs), n-1,
+		[][]byte{[]byte("message")}, []string{"eth"}, savedData[0])
+	engine.requests[notStarted.WorkId] = notStarted
+
+	engine.Stop(0)
+	require.ElementsMatch(t, []string{"signing", "not_started"}, failed)
+
+	// The work finishes while it is being stopped. It is only reported once.
+	engine.OnNodeNotSelected(request)
+	engine.OnWorkFailed(request)
+	require.Empty(t, finished)
+	require.Len(t, failed, 2)
+
+	engine.startWork(notStarted)
+	require.Equal(t, 0, engine.GetActiveWorkerCount())
+}
+
+func TestEngine_SigningDeadline(t *testing.T) {
+	t.Parallel()
+
+	n := 2
+	privKeys, nodes, pIDs, savedData := GetEngineTestData(n)
+	engine := NewEngine(nodes[0], NewMockConnectionManager(nodes[0].PeerId.String(), nil),
+		db.NewMockDatabase(), &MockEngineCallback{}, privKeys[0], config.NewDefaultTimeoutConfig()).(*defaultEngine)
+	engine.AddNodes(nodes)
+
+	// Keep the works in the queue.
+	for i := 0; i < MaxWorker; i++ {
+		engine.requests[fmt.Sprintf("running%d", i)] = &types.WorkRequest{}
+	}
+
+	signing := types.NewEcSigningRequest("signing", worker.CopySortedPartyIds(pIDs), n-1,
+		[][]byte{[]byte("message")}, []string{"eth"}, savedData[0])
+	presign := types.NewEcSigningRequest("presign", worker.CopySortedPartyIds(pIDs), n-1, nil, nil,
+		savedData[0])
+	require.NoError(t, engine.AddRequest(signing))
+	require.NoError(t, engine.AddRequest(presign))
+
+	// Background presigns do not expire.
+	require.False(t, signing.Deadline.IsZero())
+	require.True(t, presign.Deadline.IsZero())
+}
+
+func TestEngine_SendToRemovedNode(t *testing.T) {
+	t.Parallel()
+
+	n := 3
+	privKeys, nodes, pIDs, _ := GetEngineTestData(n)
+	outCh := make(chan *p2pDataWrapper, n)
+	engine := NewEngine(nodes[0], NewMockConnectionManager(nodes[0].PeerId.String(), outCh),
+		db.NewMockDatabase(), &MockEngineCallback{}, privKeys[0], config.NewDefaultTimeoutConfig()).(*defaultEngine)
+	engine.AddNodes(nodes)
+	engine.RemoveNodes([]*Node{nodes[1]})
+
+	// A running work still sends to the removed node. The message only goes to the other nodes.
+	tssMsg := common.NewRequestMessage(pIDs[0].Id, "", "work", "key")
+	engine.BroadcastMessage(pIDs, tssMsg)
+	require.Len(t, outCh, 1)
+	require.Equal(t, nodes[2].PeerId.String(), (<-outCh).To)
+
+	// The node lock is not held anymore.
+	done := make(chan bool)
+	go func() {
+		engine.AddNodes(nodes)
+		engine.RemoveNodes([]*Node{nodes[1]})
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("node lock is still held")
+	}
+}
+
+func TestEngine_VerifySignature(t *testing.T) {
+	t.Parallel()
+
+	n := 3
+	privKeys, nodes, pIDs, _ := GetEngineTestData(n)
+	engine := NewEngine(nodes[0], NewMockConnectionManager(nodes[0].PeerId.String(), nil),
+		db.NewMockDatabase(), &MockEngineCallback{}, privKeys[0], config.NewDefaultTimeoutConfig()).(*defaultEngine)
+	engine.AddNodes(nodes)
+
+	newSignedMessage := func(from int, sign func(tssMsg *common.TssMessage) ([]byte, error)) *common.SignedMessage {
+		msg := edkeygen.NewKGRound1Message(pIDs[from], big.NewInt(1))
+		tssMsg, err := common.NewTssMessage(pIDs[from].Id, "", "keygen", []tss.Message{msg}, msg.Type())
+		require.NoError(t, err)
+
+		signBytes, err := sign(tssMsg)
+		require.NoError(t, err)
+		signature, err := privKeys[from].Sign(signBytes)
+		require.NoError(t, err)
+
+		return &common.SignedMessage{From: pIDs[from].Id, TssMessage: tssMsg, Signature: signature}
+	}
+	protoBytes := func(signedMsg *common.SignedMessage) []byte {
+		bz, err := common.MarshalSignedMessage(signedMsg)
+		require.NoError(t, err)
+		return bz
+	}
+	jsonBytes := func(signedMsg *common.SignedMessage) []byte {
+		bz, err := json.Marshal(signedMsg)
+		require.NoError(t, err)
+		return bz
+	}
+	signProto := func(tssMsg *common.TssMessage) ([]byte, error) { return tssMsg.GetSignBytes() }
+	signJson := func(tssMsg *common.TssMessage) ([]byte, error) { return tssMsg.GetLegacySignBytes() }
+
+	forged := newSignedMessage(2, signProto)
+	forged.From = pIDs[1].Id
+	forged.TssMessage.From = pIDs[1].Id
+	tampered := newSignedMessage(1, signProto)
+	tampered.TssMessage.UpdateMessages[0].Data[0] ^= 0xff
+
+	tests := []struct {
+		name     string
+		peer     int
+		data     []byte
+		accepted bool
+	}{
+		{name: "protobuf message", peer: 1, data: protoBytes(newSignedMessage(1, signProto)), accepted: true},
+		{name: "legacy json message", peer: 1, data: jsonBytes(newSignedMessage(1, signJson)), accepted: true},
+		{name: "legacy message forwarded by another node", peer: 2, data: protoBytes(newSignedMessage(1, signJson)),
+			accepted: true},
+		{name: "json message signed over protobuf bytes", peer: 1, data: jsonBytes(newSignedMessage(1, signProto))},
+		{name: "tampered message", peer: 1, data: protoBytes(tampered)},
+		{name: "message of another party", peer: 2, data: protoBytes(forged)},
+	}
+
+	for _, test := range tests {
+		engine.OnNetworkMessage(&p2ptypes.P2PMessage{FromPeerId: nodes[test.peer].PeerId.String(), Data: test.data})
+
+		msgs := engine.preworkCache.PopAllMessages("keygen", nil)
+		if test.accepted {
+			require.Len(t, msgs, 1, test.name)
+		} else {
+			require.Empty(t, msgs, test.name)
+		}
+	}
+}
+
+func TestEngine_MessageFromAnotherPeer(t *testing.T) {
+	t.Parallel()
+
+	n := 3
+	privKeys, nodes, pIDs, _ := GetEngineTestData(n)
+	engine := NewEngine(nodes[0], NewMockConnectionManager(nodes[0].PeerId.String(), nil),
+		db.NewMockDatabase(), &MockEngineCallback{}, privKeys[0], config.NewDefaultTimeoutConfig()).(*defaultEngine)
+	engine.AddNodes(nodes)
+
+	tssMsg := common.NewAvailabilityRequestMessage(pIDs[1].Id, pIDs[0].Id, "signing")
+	signBytes, err := tssMsg.GetSignBytes()
+	require.NoError(t, err)
+	signature, err := privKeys[1].Sign(signBytes)
+	require.NoError(t, err)
+	bz, err := common.MarshalSignedMessage(&common.SignedMessage{From: pIDs[1].Id, TssMessage: tssMsg,
+		Signature: signature})
+	require.NoError(t, err)
+
+	// A valid message of a party that is replayed by another peer is dropped.
+	engine.OnNetworkMessage(&p2ptypes.P2PMessage{FromPeerId: nodes[2].PeerId.String(), Data: bz})
+	require.Empty(t, engine.preworkCache.PopAllMessages("signing", nil))
+
+	engine.OnNetworkMessage(&p2ptypes.P2PMessage{FromPeerId: nodes[1].PeerId.String(), Data: bz})
+	require.Len(t, engine.preworkCache.PopAllMessages("signing", nil), 1)
+}
+
+func TestEngine_DuplicateWork(t *testing.T) {
+	t.Parallel()
+
+	n := 2
+	privKeys, nodes, pIDs, savedData := GetEngineTestData(n)
+	engine := NewEngine(nodes[0], NewMockConnectionManager(nodes[0].PeerId.String(), nil),
+		db.NewMockDatabase(), &MockEngineCallback{}, privKeys[0], config.NewDefaultTimeoutConfig()).(*defaultEngine)
+	engine.AddNodes(nodes)
+
+	newRequest := func(workId string) *types.WorkRequest {
+		return types.NewEcSigningRequest(workId, worker.CopySortedPartyIds(pIDs), n-1,
+			[][]byte{[]byte("message")}, []string{"eth"}, savedData[0])
+	}
+
+	// Keep the works in the queue.
+	for i := 0; i < MaxWorker; i++ {
+		engine.requests[fmt.Sprintf("running%d", i)] = newRequest(fmt.Sprintf("running%d", i))
+	}
+
+	// A work that has been taken from the queue but whose worker has not started yet.
+	require.Equal(t, ErrWorkExisted, engine.AddRequest(newRequest("running0")))
+
+	// A work in the queue.
+	require.NoError(t, engine.AddRequest(newRequest("queued")))
+	require.Equal(t, ErrWorkExisted, engine.AddRequest(newRequest("queued")))
+
+	// A work that has finished.
+	engine.finishedWorks.Add("finished", true)
+	require.Equal(t, ErrWorkExisted, engine.AddRequest(newRequest("finished")))
+}
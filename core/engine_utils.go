@@ -1,32 +1,142 @@
 package core
 
 import (
-	"crypto"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"strconv"
+	"hash"
+	"math/big"
+	"reflect"
 
-	"github.com/sisu-network/dheart/worker/types"
-	"github.com/sisu-network/lib/log"
+	htypes "github.com/sisu-network/dheart/types"
+	"github.com/sisu-network/dheart/utils"
+	libchain "github.com/sisu-network/lib/chain"
+	"github.com/sisu-network/tss-lib/tss"
+	"golang.org/x/crypto/sha3"
 )
 
-func GetKeysignWorkId(workType types.WorkType, txs [][]byte, block int64, chain string) string {
+var (
+	ErrInvalidKeysignParties = errors.New("keysign parties are not a subset of the key committee")
+)
+
+// ValidateKeysignParties checks that the parties of a keysign can sign with a key. The committee is
+// the list of party ids that generated the key and ks is the list of party keys in the keygen
+// output. Every signer must be in the committee and its key must be in ks so that tss-lib can map
+// the signer to its index in the keygen data. There must be more signers than the threshold.
+func ValidateKeysignParties(pids []*tss.PartyID, committee []string, ks []*big.Int, threshold int) error {
+	if len(pids) <= threshold {
+		return fmt.Errorf("%w: need at least %d parties, got %d", ErrInvalidKeysignParties,
+			threshold+1, len(pids))
+	}
+
+	committeeIds := make(map[string]bool)
+	for _, id := range committee {
+		committeeIds[id] = true
+	}
+
+	keys := make(map[string]bool)
+	for _, k := range ks {
+		keys[k.String()] = true
+	}
+
+	seen := make(map[string]bool)
+	for _, pid := range pids {
+		if seen[pid.Id] {
+			return fmt.Errorf("%w: party %s is duplicated", ErrInvalidKeysignParties, pid.Id)
+		}
+		seen[pid.Id] = true
+
+		if !committeeIds[pid.Id] {
+			return fmt.Errorf("%w: party %s is not in the committee", ErrInvalidKeysignParties, pid.Id)
+		}
+
+		if !keys[pid.KeyInt().String()] {
+			return fmt.Errorf("%w: key of party %s is not in the keygen data", ErrInvalidKeysignParties,
+				pid.Id)
+		}
+	}
+
+	return nil
+}
+
+// GetKeysignWorkId returns a deterministic work id for a keysign request. Every node that receives
+// the same request with the same committee computes the same id. The id covers the key type, key
+// label, key version, committee, request nonce, attempt number and all the messages to sign with
+// their derivation paths so that two different requests never share a work id. Callers that want to
+// retry a request must increase its attempt number.
+func GetKeysignWorkId(req *htypes.KeysignRequest, pids []*tss.PartyID) string {
 	var prefix string
-	switch workType {
-	case types.EcSigning:
+	switch req.KeyType {
+	case libchain.KEY_TYPE_ECDSA:
 		prefix = "ecdsa_signing"
-	case types.EdSigning:
+	case libchain.KEY_TYPE_EDDSA:
 		prefix = "eddsa_signing"
+	case htypes.KeyTypeSchnorr:
+		prefix = "schnorr_signing"
 	default:
-		log.Critical("Invalid keygen work type, workType =", workType)
-		return ""
+		prefix = "signing"
 	}
 
-	digester := crypto.MD5.New()
-	for _, tx := range txs {
-		fmt.Fprint(digester, tx)
+	h := sha3.NewLegacyKeccak256()
+	writeWorkIdField(h, []byte(req.KeyType))
+	writeWorkIdField(h, []byte(req.KeyLabel))
+	writeWorkIdField(h, []byte(utils.GetPidString(pids)))
+	writeWorkIdUint(h, req.Nonce)
+	writeWorkIdUint(h, uint64(req.Attempt))
+	if req.TapTweak {
+		writeWorkIdUint(h, 1)
+	} else {
+		writeWorkIdUint(h, 0)
 	}
-	hash := hex.EncodeToString(digester.Sum(nil))
 
-	return prefix + "-" + strconv.FormatInt(block, 10) + "-" + chain + "-" + hash
+	writeWorkIdUint(h, uint64(len(req.KeysignMessages)))
+	for _, msg := range req.KeysignMessages {
+		writeWorkIdField(h, []byte(msg.Id))
+		writeWorkIdField(h, []byte(msg.OutChain))
+		writeWorkIdField(h, msg.BytesToSign)
+		writeWorkIdField(h, []byte(msg.DerivationPath))
+	}
+
+	return prefix + "-" + hex.EncodeToString(h.Sum(nil))
+}
+
+// GetKeysignDerivationPath returns the derivation path of the messages of a keysign request. All
+// messages of a request are signed by the same key and must have the same path.
+func GetKeysignDerivationPath(req *htypes.KeysignRequest) (string, []uint32, error) {
+	if len(req.KeysignMessages) == 0 {
+		return "", []uint32{}, nil
+	}
+
+	path := req.KeysignMessages[0].DerivationPath
+	indexes, err := utils.ParseDerivationPath(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, msg := range req.KeysignMessages[1:] {
+		other, err := utils.ParseDerivationPath(msg.DerivationPath)
+		if err != nil {
+			return "", nil, err
+		}
+		if !reflect.DeepEqual(indexes, other) {
+			return "", nil, fmt.Errorf("%w: messages %s and %s use different paths", utils.ErrInvalidDerivationPath,
+				req.KeysignMessages[0].Id, msg.Id)
+		}
+	}
+
+	return path, indexes, nil
+}
+
+// writeWorkIdField writes a length prefixed field into the hash so that the boundaries of fields
+// are unambiguous.
+func writeWorkIdField(h hash.Hash, bz []byte) {
+	writeWorkIdUint(h, uint64(len(bz)))
+	h.Write(bz)
+}
+
+func writeWorkIdUint(h hash.Hash, n uint64) {
+	bz := make([]byte, 8)
+	binary.BigEndian.PutUint64(bz, n)
+	h.Write(bz)
 }
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	htypes "github.com/sisu-network/dheart/types"
+	"github.com/sisu-network/dheart/utils"
+	libchain "github.com/sisu-network/lib/chain"
+	"github.com/sisu-network/tss-lib/tss"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetKeysignWorkId(t *testing.T) {
+	t.Parallel()
+
+	pids := getPartyIdsFromStrings([]string{"node0", "node1", "node2"})
+	newRequest := func() *htypes.KeysignRequest {
+		return &htypes.KeysignRequest{
+			KeyType: libchain.KEY_TYPE_ECDSA,
+			Nonce:   10,
+			KeysignMessages: []*htypes.KeysignMessage{
+				{Id: "msg0", OutChain: "ganache1", BytesToSign: []byte("hash0")},
+			},
+		}
+	}
+
+	workId := GetKeysignWorkId(newRequest(), pids)
+	require.Equal(t, len("ecdsa_signing-")+64, len(workId))
+
+	// The same request with the committee in different order produces the same id.
+	require.Equal(t, workId, GetKeysignWorkId(newRequest(), []*tss.PartyID{pids[2], pids[1], pids[0]}))
+
+	// Any change in the request produces a different id.
+	retried := newRequest()
+	retried.Attempt = 1
+	require.NotEqual(t, workId, GetKeysignWorkId(retried, pids))
+
+	otherNonce := newRequest()
+	otherNonce.Nonce = 11
+	require.NotEqual(t, workId, GetKeysignWorkId(otherNonce, pids))
+
+	otherLabel := newRequest()
+	otherLabel.KeyLabel = "other"
+	require.NotEqual(t, workId, GetKeysignWorkId(otherLabel, pids))
+
+	otherPath := newRequest()
+	otherPath.KeysignMessages[0].DerivationPath = "m/1"
+	require.NotEqual(t, workId, GetKeysignWorkId(otherPath, pids))
+
+	tapTweak := newRequest()
+	tapTweak.TapTweak = true
+	require.NotEqual(t, workId, GetKeysignWorkId(tapTweak, pids))
+
+	require.NotEqual(t, workId, GetKeysignWorkId(newRequest(), pids[:2]))
+}
+
+func TestGetKeysignDerivationPath(t *testing.T) {
+	t.Parallel()
+
+	newRequest := func(paths ...string) *htypes.KeysignRequest {
+		req := &htypes.KeysignRequest{}
+		for i, path := range paths {
+			req.KeysignMessages = append(req.KeysignMessages, &htypes.KeysignMessage{
+				Id:             fmt.Sprintf("msg%d", i),
+				DerivationPath: path,
+			})
+		}
+		return req
+	}
+
+	path, indexes, err := GetKeysignDerivationPath(newRequest("", "m"))
+	require.NoError(t, err)
+	require.Equal(t, "", path)
+	require.Empty(t, indexes)
+
+	path, indexes, err = GetKeysignDerivationPath(newRequest("m/1/2", "1/2"))
+	require.NoError(t, err)
+	require.Equal(t, "m/1/2", path)
+	require.Equal(t, []uint32{1, 2}, indexes)
+
+	// All messages are signed by the same key.
+	_, _, err = GetKeysignDerivationPath(newRequest("m/1", "m/2"))
+	require.True(t, errors.Is(err, utils.ErrInvalidDerivationPath))
+
+	_, _, err = GetKeysignDerivationPath(newRequest("m/1'"))
+	require.True(t, errors.Is(err, utils.ErrInvalidDerivationPath))
+}
+
+func TestValidateKeysignParties(t *testing.T) {
+	t.Parallel()
+
+	pids := getPartyIdsFromStrings([]string{"node0", "node1", "node2", "node3"})
+	committee := []string{"node0", "node1", "node2"}
+	ks := []*big.Int{pids[0].KeyInt(), pids[1].KeyInt(), pids[2].KeyInt()}
+
+	require.NoError(t, ValidateKeysignParties(pids[:3], committee, ks, 1))
+	require.NoError(t, ValidateKeysignParties(pids[1:3], committee, ks, 1))
+
+	// Not enough parties.
+	err := ValidateKeysignParties(pids[:1], committee, ks, 1)
+	require.ErrorIs(t, err, ErrInvalidKeysignParties)
+
+	// A party outside of the committee.
+	err = ValidateKeysignParties(pids[1:], committee, ks, 1)
+	require.ErrorIs(t, err, ErrInvalidKeysignParties)
+
+	// A party in the committee with a key different from the keygen.
+	other := tss.NewPartyID("node1", "", big.NewInt(100))
+	err = ValidateKeysignParties([]*tss.PartyID{pids[0], other}, committee, ks, 1)
+	require.ErrorIs(t, err, ErrInvalidKeysignParties)
+
+	// Duplicated parties.
+	err = ValidateKeysignParties([]*tss.PartyID{pids[0], pids[0]}, committee, ks, 1)
+	require.ErrorIs(t, err, ErrInvalidKeysignParties)
+}
@@ -2,35 +2,48 @@ package core
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"encoding/hex"
+	"errors"
 
 	"fmt"
+	"math/big"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	libchain "github.com/sisu-network/lib/chain"
 
 	ctypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/decred/dcrd/dcrec/edwards/v2"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/sisu-network/dheart/client"
 	htypes "github.com/sisu-network/dheart/types"
 
 	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 
+	"github.com/sisu-network/dheart/core/components"
 	"github.com/sisu-network/dheart/core/config"
 	"github.com/sisu-network/dheart/db"
 	"github.com/sisu-network/dheart/p2p"
+	p2ptypes "github.com/sisu-network/dheart/p2p/types"
+	schnorrkeygen "github.com/sisu-network/dheart/schnorr/keygen"
 	"github.com/sisu-network/dheart/utils"
 	"github.com/sisu-network/dheart/worker/types"
 	"github.com/sisu-network/lib/log"
 	"github.com/sisu-network/tss-lib/ecdsa/keygen"
+	edkeygen "github.com/sisu-network/tss-lib/eddsa/keygen"
 	"github.com/sisu-network/tss-lib/tss"
 )
 
 const (
 	TX_CACHE_SIZE = 2048
 	RETRY_TIMEOUT = time.Second * 3
+
+	// The time running works have to finish when the node shuts down.
+	ShutdownGracePeriod = time.Minute
 )
 
 var (
@@ -39,30 +52,44 @@ var (
 
 // The dragon heart of this component.
 type Heart struct {
-	config     config.HeartConfig
-	db         db.Database
-	cm         p2p.ConnectionManager
-	engine     Engine
-	client     client.Client
-	valPubkeys []ctypes.PubKey
+	config config.HeartConfig
+	db     db.Database
+	cm     p2p.ConnectionManager
+	engine Engine
+	// Creates the connection manager when the private key is set.
+	newConnectionManager func(config p2ptypes.ConnectionsConfig) p2p.ConnectionManager
+	client               client.Client
+	valPubkeys           []ctypes.PubKey
 
 	ready atomic.Value
 
 	privateKey ctypes.PrivKey
 	aesKey     []byte
 
-	keysignRequests map[string]*htypes.KeysignRequest
+	keysignRequests components.KeysignRequestTracker
+	preparamsPool   components.PreparamsPool
+
+	// Guards valPubkeys which change when peers are added or removed.
+	peerLock *sync.RWMutex
 }
 
 func NewHeart(config config.HeartConfig, client client.Client) *Heart {
 	return &Heart{
-		config:          config,
-		aesKey:          config.AesKey,
-		client:          client,
-		keysignRequests: make(map[string]*htypes.KeysignRequest),
+		config:               config,
+		aesKey:               config.AesKey,
+		client:               client,
+		newConnectionManager: p2p.NewConnectionManager,
+		keysignRequests:      components.NewKeysignRequestTracker(),
+		peerLock:             &sync.RWMutex{},
 	}
 }
 
+// SetConnectionManagerFactory replaces the function that creates the connection manager of this
+// heart, e.g. with a loopback network in tests. It must be called before SetPrivKey.
+func (h *Heart) SetConnectionManagerFactory(newConnectionManager func(config p2ptypes.ConnectionsConfig) p2p.ConnectionManager) {
+	h.newConnectionManager = newConnectionManager
+}
+
 func (h *Heart) Start() error {
 	log.Info("Starting heart")
 
@@ -78,50 +105,69 @@ func (h *Heart) Start() error {
 		time.Sleep(RETRY_TIMEOUT)
 	}
 
+	// Every ECDSA keygen uses fresh preparams from the pool.
+	generate := components.GeneratePreparamsFunc(keygen.GeneratePreParams)
 	if h.config.ShortcutPreparams {
 		log.Info("Loading preloaded preparams (we must be in dev mode)")
-		// Save precomputed preparams in the db. Only use this in local dev mode to speed up dev time.
-		preloadPreparams(h.db, h.config)
-	} else {
-		_, err := h.db.LoadPreparams()
-		if err == db.ErrNotFound {
-			log.Info("Start generating preparams....")
-			timeout := 60 * 5 * time.Second // 5 minutes
-			start := time.Now()
-			preparams, err := keygen.GeneratePreParams(timeout)
-			log.Info("Generating time = ", time.Now().Sub(start))
-			if err != nil {
-				log.Error("Cannot generate preparams. err = ", err)
-				return err
-			}
+		generate = devPreparams(h.config)
+	}
 
-			err = h.db.SavePreparams(preparams)
-			if err != nil {
-				log.Error("cannot save preparams, err = ", err)
-				return err
-			}
-		} else if err != nil {
-			return err
-		} else if err == nil {
-			log.Info("Preparams was generated.")
-		}
+	h.preparamsPool = components.NewPreparamsPool(h.db, components.NewResourceMonitor(), generate)
+	if err := h.preparamsPool.Init(); err != nil {
+		return err
 	}
+	h.preparamsPool.Start()
 
 	return nil
 }
 
+// Stop shuts down this node. New requests are rejected and running works have gracePeriod to finish.
+// Works that cannot finish are reported to Sisu as failed. Then the peers are told that this node is
+// leaving and the connections and the db are closed.
+func (h *Heart) Stop(gracePeriod time.Duration) {
+	log.Info("Stopping heart")
+	h.ready.Store(false)
+
+	if h.engine != nil {
+		h.engine.Stop(gracePeriod)
+	}
+
+	if h.preparamsPool != nil {
+		h.preparamsPool.Stop()
+	}
+
+	if h.cm != nil {
+		if err := h.cm.Stop(); err != nil {
+			log.Error("Cannot stop connection manager, err = ", err)
+		}
+	}
+
+	if h.db != nil {
+		if err := h.db.Close(); err != nil {
+			log.Error("Cannot close db, err = ", err)
+		}
+	}
+
+	log.Info("Heart stopped")
+}
+
 func (h *Heart) initConnectionManager() error {
 	log.Info("Creating connection manager")
 
+	// After the first start, peers are managed through the peer RPCs and saved in the db. The peers in
+	// the config file are only used to initialize the db.
+	peers, pubkeys := h.loadPeers()
+	h.config.Connection.Peers = peers
+
 	// Connection manager
-	h.cm = p2p.NewConnectionManager(h.config.Connection)
+	h.cm = h.newConnectionManager(h.config.Connection)
 
 	// Engine
 	myNode := NewNode(h.privateKey.PubKey())
 	h.engine = NewEngine(myNode, h.cm, h.db, h, h.privateKey, config.NewDefaultTimeoutConfig())
 
-	if h.valPubkeys != nil {
-		h.engine.AddNodes(NewNodes(h.valPubkeys))
+	if valPubkeys := h.getValPubkeys(); valPubkeys != nil {
+		h.engine.AddNodes(NewNodes(valPubkeys))
 	}
 
 	log.Info("Adding engine as listener for connection manager....")
@@ -129,7 +175,11 @@ func (h *Heart) initConnectionManager() error {
 	if err != nil {
 		return err
 	}
-	h.loadPeers(h.engine)
+
+	h.peerLock.Lock()
+	h.valPubkeys = pubkeys
+	h.peerLock.Unlock()
+	h.engine.AddNodes(NewNodes(pubkeys))
 
 	// Start connection manager.
 	err = h.cm.Start(h.privateKey.Bytes(), h.privateKey.Type())
@@ -143,34 +193,51 @@ func (h *Heart) initConnectionManager() error {
 	return nil
 }
 
-func (h *Heart) loadPeers(engine Engine) {
+// loadPeers returns the peers saved in the db and their pubkeys. If there is no peer in the db, the
+// peers in the config file are saved to the db.
+func (h *Heart) loadPeers() ([]*p2ptypes.Peer, []ctypes.PubKey) {
 	peers := h.db.LoadPeers()
 	if len(peers) == 0 && len(h.config.Connection.Peers) > 0 {
 		// Save peers to db
 		peers = h.config.Connection.Peers
-		h.db.SavePeers(peers)
+		if err := h.db.SavePeers(peers); err != nil {
+			log.Error("loadPeers: cannot save peers, err = ", err)
+		}
 	}
 
 	pubkeys := make([]ctypes.PubKey, 0)
 	for _, peer := range peers {
-		bz, err := hex.DecodeString(peer.PubKey)
-		if err != nil {
-			log.Error("loadPeers: cannot decode pubkey")
-			continue
-		}
-
-		pubkey, err := utils.GetCosmosPubKey(peer.PubKeyType, bz)
+		pubkey, err := getPeerPubKey(peer)
 		if err != nil {
-			log.Error("loadPeers: get cosmos pubkey with type: ", peer.PubKeyType)
+			log.Error("loadPeers: ", err)
 			continue
 		}
 
 		pubkeys = append(pubkeys, pubkey)
 	}
-	h.valPubkeys = pubkeys
 
-	// Add pubkeys to engine
-	engine.AddNodes(NewNodes(pubkeys))
+	return peers, pubkeys
+}
+
+func getPeerPubKey(peer *p2ptypes.Peer) (ctypes.PubKey, error) {
+	bz, err := hex.DecodeString(peer.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode pubkey %s: %w", peer.PubKey, err)
+	}
+
+	pubkey, err := utils.GetCosmosPubKey(peer.PubKeyType, bz)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get cosmos pubkey with type %s: %w", peer.PubKeyType, err)
+	}
+
+	return pubkey, nil
+}
+
+func (h *Heart) getValPubkeys() []ctypes.PubKey {
+	h.peerLock.RLock()
+	defer h.peerLock.RUnlock()
+
+	return h.valPubkeys
 }
 
 func (h *Heart) createDb() error {
@@ -187,31 +254,66 @@ func (h *Heart) OnWorkKeygenFinished(result *htypes.KeygenResult) {
 }
 
 func (h *Heart) OnWorkSigningFinished(request *types.WorkRequest, result *htypes.KeysignResult) {
-	clientRequest := h.keysignRequests[request.WorkId]
+	// Remove this request.
+	clientRequest := h.keysignRequests.Remove(request.WorkId)
 	result.Request = clientRequest
 
+	if result.Outcome == htypes.OutcomeSuccess && clientRequest != nil {
+		if err := encodeSignatures(clientRequest, result); err != nil {
+			log.Error("Cannot encode signatures, err = ", err)
+			result.Outcome = htypes.OutcomeFailure
+			result.ErrMesage = err.Error()
+			result.Signatures = nil
+		}
+	}
+
 	err := h.client.PostKeysignResult(result)
 	if err != nil {
 		log.Error("Faield to post result back to sisu")
 	}
+}
 
-	// Remove this request.
-	delete(h.keysignRequests, request.WorkId)
+// encodeSignatures encodes the signatures of a result in the formats asked by the messages of the
+// request.
+func encodeSignatures(request *htypes.KeysignRequest, result *htypes.KeysignResult) error {
+	if len(request.KeysignMessages) != len(result.Signatures) {
+		return fmt.Errorf("got %d signatures for %d messages", len(result.Signatures),
+			len(request.KeysignMessages))
+	}
+
+	signatures := make([][]byte, len(result.Signatures))
+	for i, msg := range request.KeysignMessages {
+		encoded, err := utils.EncodeSignature(request.KeyType, result.Signatures[i], msg)
+		if err != nil {
+			return fmt.Errorf("message %s: %w", msg.Id, err)
+		}
+		signatures[i] = encoded
+	}
+	result.Signatures = signatures
+
+	return nil
 }
 
 func (h *Heart) OnWorkFailed(request *types.WorkRequest, culprits []*tss.PartyID) {
-	clientRequest := h.keysignRequests[request.WorkId]
+	clientRequest := h.keysignRequests.Remove(request.WorkId)
 
 	switch request.WorkType {
-	case types.EcKeygen, types.EdKeygen:
+	case types.EcKeygen, types.EdKeygen, types.SchnorrKeygen:
 		result := htypes.KeygenResult{
 			KeyType:  request.KeygenType,
+			KeyLabel: request.KeyLabel,
 			Outcome:  htypes.OutcomeFailure,
 			Culprits: culprits,
 		}
 		h.client.PostKeygenResult(&result)
 
-	case types.EcSigning, types.EdSigning:
+	case types.EcSigning, types.EdSigning, types.SchnorrSigning:
+		if clientRequest == nil {
+			// Presigns and other works that Sisu did not ask for are not reported.
+			log.Warnf("Work %s has failed, it is not a keysign request of Sisu", request.WorkId)
+			return
+		}
+
 		result := htypes.KeysignResult{
 			Request:  clientRequest,
 			Outcome:  htypes.OutcomeFailure,
@@ -238,6 +340,9 @@ func (h *Heart) SetSisuReady(isReady bool) {
 // SetPrivKey receives encrypted private key from Sisu, decrypts it and start the engine,
 // network communication, etc. This is only for integration testing.
 func (h *Heart) SetBootstrappedKeys(tPubKeys []ctypes.PubKey) {
+	h.peerLock.Lock()
+	defer h.peerLock.Unlock()
+
 	if h.valPubkeys == nil {
 		h.valPubkeys = tPubKeys
 	}
@@ -282,7 +387,9 @@ func (h *Heart) SetPrivKey(encryptedKey string, tendermintKeyType string) error
 	return nil
 }
 
-func (h *Heart) Keygen(keygenId string, keyType string, tPubKeys []ctypes.PubKey) error {
+// Keygen generates a new key with a label for a key type. Keys of the same type but with different
+// labels are independent and can be generated by different committees.
+func (h *Heart) Keygen(keygenId string, keyType string, keyLabel string, tPubKeys []ctypes.PubKey) error {
 	if h.ready.Load() != true {
 		log.Verbose("Heart not ready")
 		return ErrDheartNotReady
@@ -290,7 +397,6 @@ func (h *Heart) Keygen(keygenId string, keyType string, tPubKeys []ctypes.PubKey
 
 	// TODO: Check if our pubkey is one of the pubkeys.
 	n := len(tPubKeys)
-	h.valPubkeys = tPubKeys
 
 	nodes := NewNodes(tPubKeys)
 	// For keygen, workId is the same as keygenId
@@ -306,10 +412,22 @@ func (h *Heart) Keygen(keygenId string, keyType string, tPubKeys []ctypes.PubKey
 	var request *types.WorkRequest
 	switch keyType {
 	case libchain.KEY_TYPE_ECDSA:
-		request = types.NewEcKeygenRequest(keyType, workId, sorted, utils.GetThreshold(n), nil)
+		// Take the preparams before the work starts. If the pool is empty, generating them takes a while
+		// and the other parties would time out if it happened in the middle of the work.
+		preparams, err := h.preparamsPool.Get(workId)
+		if err != nil {
+			log.Error("Cannot get preparams for keygen ", workId, ", err = ", err)
+			return err
+		}
+		request = types.NewEcKeygenRequest(keyType, workId, sorted, utils.GetThreshold(n), preparams)
 	case libchain.KEY_TYPE_EDDSA:
 		request = types.NewEdKeygenRequest(workId, sorted, utils.GetThreshold(n))
+	case htypes.KeyTypeSchnorr:
+		request = types.NewSchnorrKeygenRequest(workId, sorted, utils.GetThreshold(n))
+	default:
+		return fmt.Errorf("unsupported key type %s", keyType)
 	}
+	request.KeyLabel = keyLabel
 
 	return h.engine.AddRequest(request)
 }
@@ -330,59 +448,127 @@ func (h *Heart) Keysign(req *htypes.KeysignRequest, tPubKeys []ctypes.PubKey) er
 		pids[i] = node.PartyId
 	}
 
+	// Only the parties that generated the key can sign with it. The threshold of the signing is the
+	// threshold of the key.
+	committee, err := h.db.LoadKeygenCommittee(req.KeyType, req.KeyLabel)
+	if err != nil {
+		return err
+	}
+	if committee == nil {
+		return fmt.Errorf("cannot find key with type %s and label %s", req.KeyType, req.KeyLabel)
+	}
+	threshold := utils.GetThreshold(len(committee))
+
+	path, indexes, err := GetKeysignDerivationPath(req)
+	if err != nil {
+		return err
+	}
+	if req.TapTweak && req.KeyType != htypes.KeyTypeSchnorr {
+		return fmt.Errorf("taptweak is not supported by key type %s", req.KeyType)
+	}
+
+	// TODO: Load multiple input here.
+	var ecKeygenData *keygen.LocalPartySaveData
+	var edKeygenData *edkeygen.LocalPartySaveData
+	var schnorrKeygenData *schnorrkeygen.LocalPartySaveData
+	var ks []*big.Int
+	switch req.KeyType {
+	case libchain.KEY_TYPE_ECDSA:
+		ecKeygenData, err = h.db.LoadEcKeygen(req.KeyType, req.KeyLabel)
+		if err != nil {
+			return err
+		}
+		if ecKeygenData == nil {
+			return fmt.Errorf("cannot find key with type %s and label %s", req.KeyType, req.KeyLabel)
+		}
+		ks = ecKeygenData.Ks
+		if ecKeygenData, err = utils.DeriveEcKeygen(ecKeygenData, indexes); err != nil {
+			return err
+		}
+	case libchain.KEY_TYPE_EDDSA:
+		edKeygenData, err = h.db.LoadEdKeygen(req.KeyType, req.KeyLabel)
+		if err != nil {
+			return err
+		}
+		if edKeygenData == nil {
+			return fmt.Errorf("cannot find key with type %s and label %s", req.KeyType, req.KeyLabel)
+		}
+		ks = edKeygenData.Ks
+		if edKeygenData, err = utils.DeriveEdKeygen(edKeygenData, indexes); err != nil {
+			return err
+		}
+	case htypes.KeyTypeSchnorr:
+		schnorrKeygenData, err = h.db.LoadSchnorrKeygen(req.KeyType, req.KeyLabel)
+		if err != nil {
+			return err
+		}
+		if schnorrKeygenData == nil {
+			return fmt.Errorf("cannot find key with type %s and label %s", req.KeyType, req.KeyLabel)
+		}
+		ks = schnorrKeygenData.Ks
+		if schnorrKeygenData, err = utils.DeriveSchnorrKeygen(schnorrKeygenData, indexes); err != nil {
+			return err
+		}
+		if req.TapTweak {
+			if schnorrKeygenData, err = utils.TapTweakSchnorrKeygen(schnorrKeygenData); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported key type %s", req.KeyType)
+	}
+
+	if err := ValidateKeysignParties(pids, committee, ks, threshold); err != nil {
+		log.Error("Invalid keysign parties for key ", req.KeyType, " label ", req.KeyLabel, ", err = ", err)
+		return err
+	}
+
 	h.engine.AddNodes(nodes)
 
-	// TODO: Find unique workId
-	workId := ""
+	workId := GetKeysignWorkId(req, pids)
 	signMessages := make([][]byte, len(req.KeysignMessages)) // TODO: make this a byte array
 	chains := make([]string, len(req.KeysignMessages))
 	for i, msg := range req.KeysignMessages {
 		log.Verbosef("There is a new work for chain %s with hash %s", msg.OutChain, msg.OutHash)
 
-		workId = workId + msg.Id
-		workId = utils.KeccakHash32(workId)
 		signMessages[i] = msg.BytesToSign
 		chains[i] = msg.OutChain
 	}
 
-	// TODO: Load multiple input here.
 	var workRequest *types.WorkRequest
 	switch req.KeyType {
 	case libchain.KEY_TYPE_ECDSA:
-		presignInput, err := h.db.LoadEcKeygen(req.KeyType)
-		if err != nil {
-			return err
-		}
-		workRequest = types.NewEcSigningRequest(
-			workId,
-			pids,
-			utils.GetThreshold(len(pids)),
-			signMessages,
-			chains,
-			presignInput,
-		)
+		workRequest = types.NewEcSigningRequest(workId, pids, threshold, signMessages, chains,
+			ecKeygenData)
 	case libchain.KEY_TYPE_EDDSA:
-		keygenData, err := h.db.LoadEdKeygen(req.KeyType)
-		if err != nil {
-			return nil
-		}
-
-		workRequest = types.NewEdSigningRequest(workId, pids, utils.GetThreshold(len(pids)),
-			signMessages, chains, keygenData)
+		workRequest = types.NewEdSigningRequest(workId, pids, threshold, signMessages, chains,
+			edKeygenData)
+	case htypes.KeyTypeSchnorr:
+		workRequest = types.NewSchnorrSigningRequest(workId, pids, threshold, signMessages, chains,
+			schnorrKeygenData)
 	}
+	workRequest.KeyLabel = req.KeyLabel
+	workRequest.DerivationPath = path
 
-	err := h.engine.AddRequest(workRequest)
+	// Track the request before dispatching the work since the work could finish before AddRequest
+	// returns.
+	if err := h.keysignRequests.Add(workId, req); err != nil {
+		return err
+	}
 
-	h.keysignRequests[workRequest.WorkId] = req
+	err = h.engine.AddRequest(workRequest)
+	if err != nil {
+		h.keysignRequests.Remove(workId)
+		return err
+	}
 
-	return err
+	return nil
 }
 
 // Called at the end of Sisu's block. This could be a time when we can check our CPU resource and
 // does additional presign work.
 func (h *Heart) BlockEnd(blockHeight int64) error {
-	// Temporarily disable presign.
-	if true {
+	if !h.config.EnablePresign {
 		return nil
 	}
 
@@ -390,7 +576,7 @@ func (h *Heart) BlockEnd(blockHeight int64) error {
 		return ErrDheartNotReady
 	}
 
-	if h.valPubkeys == nil || len(h.valPubkeys) == 0 {
+	if len(h.getValPubkeys()) == 0 {
 		return nil
 	}
 
@@ -400,26 +586,321 @@ func (h *Heart) BlockEnd(blockHeight int64) error {
 	return nil
 }
 
+// AddPeer adds a new peer to this node. The peer is saved in the db and the node connects to it.
+func (h *Heart) AddPeer(peer *p2ptypes.Peer) error {
+	if h.engine == nil {
+		return ErrDheartNotReady
+	}
+
+	addrInfo, err := p2p.GetPeerAddrInfo(peer)
+	if err != nil {
+		return err
+	}
+	pubkey, err := getPeerPubKey(peer)
+	if err != nil {
+		return fmt.Errorf("%w: %v", p2p.ErrInvalidPeer, err)
+	}
+
+	if err := h.cm.AddPeer(peer); err != nil {
+		return err
+	}
+	if err := h.db.SavePeers([]*p2ptypes.Peer{peer}); err != nil {
+		h.cm.RemovePeer(addrInfo.ID)
+		return err
+	}
+
+	h.engine.AddNodes([]*Node{NewNode(pubkey)})
+
+	h.peerLock.Lock()
+	h.valPubkeys = append(h.valPubkeys, pubkey)
+	h.peerLock.Unlock()
+
+	log.Infof("Added peer %s, address = %s", addrInfo.ID, peer.Address)
+
+	return nil
+}
+
+// RemovePeer removes the peer with a hex encoded pubkey from this node. Works that are running with
+// the peer are not affected.
+func (h *Heart) RemovePeer(pubkeyHex string) error {
+	if h.engine == nil {
+		return ErrDheartNotReady
+	}
+
+	var peer *p2ptypes.Peer
+	for _, p := range h.db.LoadPeers() {
+		if p.PubKey == pubkeyHex {
+			peer = p
+			break
+		}
+	}
+	if peer == nil {
+		return fmt.Errorf("%w: %s", p2p.ErrPeerNotFound, pubkeyHex)
+	}
+
+	if err := h.db.DeletePeer(pubkeyHex); err != nil {
+		return err
+	}
+
+	pubkey, err := getPeerPubKey(peer)
+	if err != nil {
+		// The peer was saved with an invalid pubkey, it was never added to the engine.
+		log.Warn("RemovePeer: ", err)
+		return nil
+	}
+	node := NewNode(pubkey)
+
+	if err := h.cm.RemovePeer(node.PeerId); err != nil && !errors.Is(err, p2p.ErrPeerNotFound) {
+		log.Warnf("RemovePeer: failed to disconnect from peer %s, err = %v", node.PeerId, err)
+	}
+	h.engine.RemoveNodes([]*Node{node})
+
+	h.peerLock.Lock()
+	valPubkeys := make([]ctypes.PubKey, 0, len(h.valPubkeys))
+	for _, valPubkey := range h.valPubkeys {
+		if !valPubkey.Equals(pubkey) {
+			valPubkeys = append(valPubkeys, valPubkey)
+		}
+	}
+	h.valPubkeys = valPubkeys
+	h.peerLock.Unlock()
+
+	log.Infof("Removed peer %s", node.PeerId)
+
+	return nil
+}
+
+// ListPeers returns all peers of this node and their connection states.
+func (h *Heart) ListPeers() []*htypes.PeerInfo {
+	if h.db == nil {
+		return []*htypes.PeerInfo{}
+	}
+
+	statuses := make(map[string]p2p.PeerStatus)
+	if h.cm != nil {
+		for _, status := range h.cm.GetPeerStatuses() {
+			statuses[status.PeerId.String()] = status
+		}
+	}
+
+	peers := h.db.LoadPeers()
+	infos := make([]*htypes.PeerInfo, 0, len(peers))
+	for _, peer := range peers {
+		info := &htypes.PeerInfo{
+			Address:    peer.Address,
+			PubKey:     peer.PubKey,
+			PubKeyType: peer.PubKeyType,
+			State:      p2p.PeerDisconnected.String(),
+		}
+
+		if pID, err := p2p.GetPeerId(peer.PubKey, peer.PubKeyType); err == nil {
+			info.PeerId = pID.String()
+			if status, ok := statuses[info.PeerId]; ok {
+				info.State = status.State.String()
+				info.Retries = status.Retries
+				info.LastError = status.LastError
+				info.Healthy = status.Healthy
+				info.RttMs = status.Rtt.Milliseconds()
+				info.Offenses = status.Offenses
+			}
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+// GetAddresses returns the addresses of the stored key with a type and a label on a network. Cosmos
+// addresses use the given bech32 prefix.
+func (h *Heart) GetAddresses(keyType string, keyLabel string, bech32Prefix string, network string) (*htypes.Addresses, error) {
+	pubKey, err := h.loadPubKey(keyType, keyLabel, []uint32{})
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := utils.GetAddresses(keyType, pubKey, bech32Prefix, network)
+	if err != nil {
+		return nil, err
+	}
+	addresses.KeyLabel = keyLabel
+
+	return addresses, nil
+}
+
+// GetDerivedPubKey returns the public key of the child key at a derivation path of the stored key
+// with a type and a label. The key has the format of keygen results.
+func (h *Heart) GetDerivedPubKey(keyType string, keyLabel string, path string) ([]byte, error) {
+	indexes, err := utils.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.loadPubKey(keyType, keyLabel, indexes)
+}
+
+// GetPreparamsStatus returns the state of the pool of preparams used by ECDSA keygens.
+func (h *Heart) GetPreparamsStatus() (*htypes.PreparamsPoolStatus, error) {
+	if h.preparamsPool == nil {
+		return nil, ErrDheartNotReady
+	}
+
+	return h.preparamsPool.Status(), nil
+}
+
+// loadPubKey returns the public key of the child key at a derivation path of the stored key with a
+// type and a label.
+func (h *Heart) loadPubKey(keyType string, keyLabel string, path []uint32) ([]byte, error) {
+	if h.db == nil {
+		return nil, ErrDheartNotReady
+	}
+
+	switch keyType {
+	case libchain.KEY_TYPE_ECDSA:
+		keygenData, err := h.db.LoadEcKeygen(keyType, keyLabel)
+		if err != nil {
+			return nil, err
+		}
+		if keygenData == nil {
+			return nil, fmt.Errorf("cannot find key with type %s and label %s", keyType, keyLabel)
+		}
+
+		_, pubKey, err := utils.DeriveEcChildKey(keygenData.ECDSAPub, path)
+		if err != nil {
+			return nil, err
+		}
+
+		return crypto.FromECDSAPub(&ecdsa.PublicKey{
+			Curve: tss.EC(tss.EcdsaScheme),
+			X:     pubKey.X(),
+			Y:     pubKey.Y(),
+		}), nil
+	case libchain.KEY_TYPE_EDDSA:
+		keygenData, err := h.db.LoadEdKeygen(keyType, keyLabel)
+		if err != nil {
+			return nil, err
+		}
+		if keygenData == nil {
+			return nil, fmt.Errorf("cannot find key with type %s and label %s", keyType, keyLabel)
+		}
+
+		_, pubKey, err := utils.DeriveEdChildKey(keygenData.EDDSAPub, path)
+		if err != nil {
+			return nil, err
+		}
+
+		return edwards.NewPublicKey(pubKey.X(), pubKey.Y()).Serialize(), nil
+	case htypes.KeyTypeSchnorr:
+		keygenData, err := h.db.LoadSchnorrKeygen(keyType, keyLabel)
+		if err != nil {
+			return nil, err
+		}
+		if keygenData == nil {
+			return nil, fmt.Errorf("cannot find key with type %s and label %s", keyType, keyLabel)
+		}
+
+		_, pubKey, err := utils.DeriveEcChildKey(keygenData.SchnorrPub, path)
+		if err != nil {
+			return nil, err
+		}
+
+		return utils.SerializeSchnorrPubKey(pubKey), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", keyType)
+	}
+}
+
 // --- End of Server API  /
 
 func (h *Heart) doPresign(blockHeight int64) {
-	nodes := NewNodes(h.valPubkeys)
-	pids := make([]*tss.PartyID, len(h.valPubkeys))
+	// TODO: Add ECDSA presigns once their outputs are saved by the workers. Until then, ECDSA presign
+	// works are wasted.
+	for _, keygenType := range []string{libchain.KEY_TYPE_EDDSA} {
+		// Every key has its own presign pool which is generated by the committee recorded with the key
+		// in the db.
+		keyLabels, err := h.db.LoadKeyLabels(keygenType)
+		if err != nil {
+			log.Error("Cannot load key labels, err = ", err)
+			continue
+		}
+
+		for _, keyLabel := range keyLabels {
+			committee, err := h.loadKeyCommittee(keygenType, keyLabel)
+			if err != nil {
+				log.Error("Cannot load the committee of key ", keygenType, " label ", keyLabel, ", err = ", err)
+				continue
+			}
+
+			h.doPresignForKey(blockHeight, keygenType, keyLabel, committee)
+		}
+	}
+}
+
+// loadKeyCommittee returns the pubkeys of the parties that generated a key. All the parties must be
+// known peers of this node.
+func (h *Heart) loadKeyCommittee(keyType string, keyLabel string) ([]ctypes.PubKey, error) {
+	committee, err := h.db.LoadKeygenCommittee(keyType, keyLabel)
+	if err != nil {
+		return nil, err
+	}
+	if committee == nil {
+		return nil, fmt.Errorf("cannot find key with type %s and label %s", keyType, keyLabel)
+	}
+
+	knownPubKeys := make(map[string]ctypes.PubKey)
+	for _, pubKey := range append([]ctypes.PubKey{h.privateKey.PubKey()}, h.getValPubkeys()...) {
+		if node := NewNode(pubKey); node != nil {
+			knownPubKeys[node.PeerId.String()] = pubKey
+		}
+	}
+
+	pubKeys := make([]ctypes.PubKey, len(committee))
+	for i, id := range committee {
+		pubKey, ok := knownPubKeys[id]
+		if !ok {
+			return nil, fmt.Errorf("party %s of the committee is not a known peer", id)
+		}
+		pubKeys[i] = pubKey
+	}
+
+	return pubKeys, nil
+}
+
+func (h *Heart) doPresignForKey(blockHeight int64, keygenType string, keyLabel string, committee []ctypes.PubKey) {
+	nodes := NewNodes(committee)
+	pids := make([]*tss.PartyID, len(committee))
 	for i, node := range nodes {
 		pids[i] = node.PartyId
 	}
 
 	sorted := tss.SortPartyIDs(pids)
+	workId := "presign_" + keygenType + "_" + keyLabel + "_" + strconv.FormatInt(blockHeight, 10)
 
-	keygenType := "ecdsa"
-	presignInput, err := h.db.LoadEcKeygen(keygenType)
+	var presignRequest *types.WorkRequest
+	switch keygenType {
+	case libchain.KEY_TYPE_ECDSA:
+		presignInput, err := h.db.LoadEcKeygen(keygenType, keyLabel)
+		if err != nil {
+			log.Error("Cannot get presign input, err = ", err)
+		}
+		if presignInput != nil {
+			presignRequest = types.NewEcSigningRequest(workId, sorted, utils.GetThreshold(len(sorted)), nil, nil, presignInput)
+		}
 
-	if err != nil {
-		log.Error("Cannot get presign input, err = ", err)
+	case libchain.KEY_TYPE_EDDSA:
+		presignInput, err := h.db.LoadEdKeygen(keygenType, keyLabel)
+		if err != nil {
+			log.Error("Cannot get presign input, err = ", err)
+		}
+		if presignInput != nil {
+			// The presign table has one row per work, so a presign work makes a single presign.
+			presignRequest = types.NewEdPresignRequest(workId, sorted, utils.GetThreshold(len(sorted)), 1, presignInput)
+		}
 	}
 
-	if presignInput == nil {
+	if presignRequest == nil {
 		log.Info("Cannot find presign input. Presign cannot be executed until keygen has finished running.")
+		return
 	}
 
 	activeWorkerCount := h.engine.GetActiveWorkerCount()
@@ -427,12 +908,10 @@ func (h *Heart) doPresign(blockHeight int64) {
 
 	if activeWorkerCount < MaxWorker {
 		// TODO Presign work with our available worker
-		workId := "presign_" + keygenType + "_" + strconv.FormatInt(blockHeight, 10)
 		log.Info("Presign workId = ", workId)
 
-		presignRequest := types.NewEcSigningRequest(workId, sorted, utils.GetThreshold(len(sorted)), nil, nil, presignInput)
-		err = h.engine.AddRequest(presignRequest)
-		if err != nil {
+		presignRequest.KeyLabel = keyLabel
+		if err := h.engine.AddRequest(presignRequest); err != nil {
 			log.Error("Failed to add presign request to engine, err = ", err)
 		}
 	}
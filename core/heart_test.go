@@ -0,0 +1,314 @@
+package core
+
+import (
+	ed25519std "crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	ctypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	libchain "github.com/sisu-network/lib/chain"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sisu-network/dheart/core/config"
+	"github.com/sisu-network/dheart/db"
+	"github.com/sisu-network/dheart/p2p"
+	p2ptypes "github.com/sisu-network/dheart/p2p/types"
+	"github.com/sisu-network/dheart/test/mock"
+	htypes "github.com/sisu-network/dheart/types"
+	"github.com/sisu-network/dheart/utils"
+	"github.com/sisu-network/dheart/worker/types"
+)
+
+// startLoopbackHearts starts n hearts that talk to each other on a loopback network.
+func startLoopbackHearts(t *testing.T, network *p2p.LoopbackNetwork, n int,
+	clients []*mock.MockClient) ([]*Heart, []ctypes.PubKey) {
+	aesKey := []byte("Jxq9PhUzvP4RZFBGivXGfA3Y") // 24 bytes, AES-192
+	privKeys := make([]*ed25519.PrivKey, n)
+	pubKeys := make([]ctypes.PubKey, n)
+	peers := make([]*p2ptypes.Peer, n)
+	for i := 0; i < n; i++ {
+		privKeys[i] = ed25519.GenPrivKey()
+		pubKeys[i] = privKeys[i].PubKey()
+
+		pID, err := p2p.GetPeerId(hex.EncodeToString(pubKeys[i].Bytes()), "ed25519")
+		require.NoError(t, err)
+		peers[i] = &p2ptypes.Peer{
+			Address:    fmt.Sprintf("/ip4/127.0.0.1/tcp/28300/p2p/%s", pID),
+			PubKey:     hex.EncodeToString(pubKeys[i].Bytes()),
+			PubKeyType: "ed25519",
+		}
+	}
+
+	hearts := make([]*Heart, n)
+	for i := 0; i < n; i++ {
+		cfg := config.HeartConfig{
+			ShortcutPreparams: true,
+			Db: config.DbConfig{
+				// The schema selects the preloaded preparams.
+				Schema:   fmt.Sprintf("dheart%d", i),
+				InMemory: true,
+			},
+			Connection: p2ptypes.ConnectionsConfig{Peers: peers},
+			AesKey:     aesKey,
+		}
+
+		hearts[i] = NewHeart(cfg, clients[i])
+		hearts[i].SetConnectionManagerFactory(network.NewConnectionManager)
+		require.NoError(t, hearts[i].Start())
+
+		encrypted, err := utils.AESDEncrypt(privKeys[i].Bytes(), aesKey)
+		require.NoError(t, err)
+		require.NoError(t, hearts[i].SetPrivKey(hex.EncodeToString(encrypted), "ed25519"))
+		hearts[i].SetSisuReady(true)
+	}
+
+	return hearts, pubKeys
+}
+
+func TestHeart_LoadKeyCommittee(t *testing.T) {
+	t.Parallel()
+
+	myKey := ed25519.GenPrivKey()
+	peerKey := ed25519.GenPrivKey()
+	unknownKey := ed25519.GenPrivKey()
+	committees := map[string][]string{
+		"":        {NewNode(myKey.PubKey()).PeerId.String(), NewNode(peerKey.PubKey()).PeerId.String()},
+		"unknown": {NewNode(myKey.PubKey()).PeerId.String(), NewNode(unknownKey.PubKey()).PeerId.String()},
+	}
+
+	h := NewHeart(config.HeartConfig{}, nil)
+	h.privateKey = myKey
+	h.valPubkeys = []ctypes.PubKey{peerKey.PubKey()}
+	h.db = &db.MockDatabase{
+		LoadKeygenCommitteeFunc: func(keyType string, keyLabel string) ([]string, error) {
+			return committees[keyLabel], nil
+		},
+	}
+
+	// The committee is read from the db, not from the keygen requests seen by this node.
+	committee, err := h.loadKeyCommittee(libchain.KEY_TYPE_ECDSA, "")
+	require.NoError(t, err)
+	require.Equal(t, []ctypes.PubKey{myKey.PubKey(), peerKey.PubKey()}, committee)
+
+	_, err = h.loadKeyCommittee(libchain.KEY_TYPE_ECDSA, "unknown")
+	require.Error(t, err)
+
+	_, err = h.loadKeyCommittee(libchain.KEY_TYPE_ECDSA, "missing")
+	require.Error(t, err)
+}
+
+func TestHeart_OnWorkFailed(t *testing.T) {
+	t.Parallel()
+
+	posted := make([]*htypes.KeysignResult, 0)
+	h := NewHeart(config.HeartConfig{}, &mock.MockClient{
+		PostKeysignResultFunc: func(result *htypes.KeysignResult) error {
+			posted = append(posted, result)
+			return nil
+		},
+	})
+
+	// Failed presigns are not reported to Sisu.
+	h.OnWorkFailed(types.NewEcSigningRequest("presign", nil, 0, nil, nil, nil), nil)
+	h.OnWorkFailed(types.NewEdPresignRequest("ed_presign", nil, 0, 1, nil), nil)
+	require.Empty(t, posted)
+
+	request := &htypes.KeysignRequest{KeyType: libchain.KEY_TYPE_ECDSA}
+	require.NoError(t, h.keysignRequests.Add("signing", request))
+	h.OnWorkFailed(types.NewEcSigningRequest("signing", nil, 0, [][]byte{[]byte("message")}, []string{"eth"},
+		nil), nil)
+	require.Len(t, posted, 1)
+	require.Equal(t, request, posted[0].Request)
+	require.Equal(t, htypes.OutcomeFailure, posted[0].Outcome)
+}
+
+// loopbackClients are the mock Sisu clients of loopback hearts. They forward the keygen results
+// and the results of the keysign requests to channels.
+type loopbackClients struct {
+	clients        []*mock.MockClient
+	keygenResults  chan *htypes.KeygenResult
+	keysignResults chan *htypes.KeysignResult
+}
+
+func newLoopbackClients(n int) *loopbackClients {
+	c := &loopbackClients{
+		clients:        make([]*mock.MockClient, n),
+		keygenResults:  make(chan *htypes.KeygenResult, n),
+		keysignResults: make(chan *htypes.KeysignResult, n),
+	}
+	for i := range c.clients {
+		c.clients[i] = &mock.MockClient{
+			PostKeygenResultFunc: func(result *htypes.KeygenResult) error {
+				c.keygenResults <- result
+				return nil
+			},
+			PostKeysignResultFunc: func(result *htypes.KeysignResult) error {
+				// Presigns are also reported but they are not keysign requests of Sisu.
+				if result.Request != nil {
+					c.keysignResults <- result
+				}
+				return nil
+			},
+		}
+	}
+
+	return c
+}
+
+// runLoopbackKeygen runs a keygen on all the hearts and returns the public key of the new key.
+func runLoopbackKeygen(t *testing.T, hearts []*Heart, pubKeys []ctypes.PubKey, c *loopbackClients,
+	keyType string) []byte {
+	for _, h := range hearts {
+		require.NoError(t, h.Keygen("keygen_"+keyType, keyType, "", pubKeys))
+	}
+
+	var pubKeyBytes []byte
+	for range hearts {
+		select {
+		case result := <-c.keygenResults:
+			require.Equal(t, htypes.OutcomeSuccess, result.Outcome)
+			if pubKeyBytes == nil {
+				pubKeyBytes = result.PubKeyBytes
+			}
+			require.Equal(t, pubKeyBytes, result.PubKeyBytes)
+		case <-time.After(2 * time.Minute):
+			t.Fatal("keygen timeout")
+		}
+	}
+
+	return pubKeyBytes
+}
+
+// runLoopbackKeysign signs a message with a key on all the hearts and returns the signature. The
+// nodes that are not selected for the signing do not return a signature.
+func runLoopbackKeysign(t *testing.T, hearts []*Heart, pubKeys []ctypes.PubKey, c *loopbackClients,
+	keyType string, msg []byte) []byte {
+	request := &htypes.KeysignRequest{
+		KeyType: keyType,
+		Nonce:   1,
+		KeysignMessages: []*htypes.KeysignMessage{
+			{Id: "message0", OutChain: "eth", BytesToSign: msg},
+		},
+	}
+	for _, h := range hearts {
+		require.NoError(t, h.Keysign(request, pubKeys))
+	}
+
+	var signature []byte
+	for range hearts {
+		select {
+		case result := <-c.keysignResults:
+			if result.Outcome == htypes.OutcometNotSelected {
+				continue
+			}
+			require.Equal(t, htypes.OutcomeSuccess, result.Outcome, result.ErrMesage)
+			require.Len(t, result.Signatures, 1)
+			if signature == nil {
+				signature = result.Signatures[0]
+			}
+			require.Equal(t, signature, result.Signatures[0])
+		case <-time.After(2 * time.Minute):
+			t.Fatal("keysign timeout")
+		}
+	}
+
+	return signature
+}
+
+func TestHeart_LoopbackKeygen(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	n := 3
+	network := p2p.NewLoopbackNetwork(p2p.LoopbackConfig{
+		Seed:        1,
+		Latency:     5 * time.Millisecond,
+		Jitter:      5 * time.Millisecond,
+		ReorderRate: 0.1,
+	})
+	defer network.Close()
+
+	clients := newLoopbackClients(n)
+	hearts, pubKeys := startLoopbackHearts(t, network, n, clients.clients)
+	pubKeyBytes := runLoopbackKeygen(t, hearts, pubKeys, clients, libchain.KEY_TYPE_ECDSA)
+
+	// All nodes derive the same addresses from the stored key.
+	expected, err := utils.GetAddresses(libchain.KEY_TYPE_ECDSA, pubKeyBytes, "sisu", "")
+	require.NoError(t, err)
+	for _, h := range hearts {
+		addresses, err := h.GetAddresses(libchain.KEY_TYPE_ECDSA, "", "sisu", "")
+		require.NoError(t, err)
+		require.Equal(t, expected, addresses)
+	}
+	_, err = hearts[0].GetAddresses(libchain.KEY_TYPE_ECDSA, "unknown", "sisu", "")
+	require.Error(t, err)
+
+	// The nodes sign with the new key.
+	hash := crypto.Keccak256([]byte("message"))
+	signature := runLoopbackKeysign(t, hearts, pubKeys, clients, libchain.KEY_TYPE_ECDSA, hash)
+	require.True(t, crypto.VerifySignature(pubKeyBytes, hash, signature[:64]))
+
+	// A node in another partition is reported as down.
+	pID := NewNode(pubKeys[0]).PeerId
+	network.Partition([]peer.ID{pID})
+	require.True(t, hearts[1].cm.IsPeerDown(pID))
+}
+
+func TestHeart_LoopbackPresignAndKeysign(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	// Every work of two nodes needs both of them, so both nodes hold the presign.
+	n := 2
+	network := p2p.NewLoopbackNetwork(p2p.LoopbackConfig{
+		Seed:    2,
+		Latency: 5 * time.Millisecond,
+		Jitter:  5 * time.Millisecond,
+	})
+	defer network.Close()
+
+	clients := newLoopbackClients(n)
+	hearts, pubKeys := startLoopbackHearts(t, network, n, clients.clients)
+	pubKeyBytes := runLoopbackKeygen(t, hearts, pubKeys, clients, libchain.KEY_TYPE_EDDSA)
+	for _, h := range hearts {
+		h.config.EnablePresign = true
+	}
+
+	// Every node makes a presign for the new key with the committee of the key at the end of a block.
+	for _, h := range hearts {
+		require.NoError(t, h.BlockEnd(1))
+	}
+
+	var presignIds []string
+	require.Eventually(t, func() bool {
+		for _, h := range hearts {
+			ids, _, _, _, err := h.db.GetAvailablePresignShortForm()
+			require.NoError(t, err)
+			if len(ids) != 1 {
+				return false
+			}
+			presignIds = ids
+		}
+		return true
+	}, 2*time.Minute, 100*time.Millisecond)
+
+	// The keysign uses the presign and every node marks it as used.
+	msg := []byte("message")
+	signature := runLoopbackKeysign(t, hearts, pubKeys, clients, libchain.KEY_TYPE_EDDSA, msg)
+	require.True(t, ed25519std.Verify(pubKeyBytes, msg, signature))
+
+	for _, h := range hearts {
+		statuses, err := h.db.LoadPresignStatus(presignIds)
+		require.NoError(t, err)
+		require.Equal(t, []string{db.PresignStatusUsed}, statuses)
+	}
+}
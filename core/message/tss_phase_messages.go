@@ -2,7 +2,11 @@ package message
 
 import (
 	"errors"
+	"strings"
 
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
+	schnorrkeygen "github.com/sisu-network/dheart/schnorr/keygen"
+	schnorrsigning "github.com/sisu-network/dheart/schnorr/signing"
 	wtypes "github.com/sisu-network/dheart/worker/types"
 	"github.com/sisu-network/lib/log"
 	eckeygen "github.com/sisu-network/tss-lib/ecdsa/keygen"
@@ -33,6 +37,15 @@ const (
 	EdSigning1
 	EdSigning2
 	EdSigning3
+	EdPresign1
+	EdPresignSigning1
+
+	// Schnorr
+	SchnorrKeygen1
+	SchnorrKeygen2
+	SchnorrSigning1
+	SchnorrSigning2
+	SchnorrSigning3
 )
 
 func GetMsgRound(content tss.MessageContent) (Round, error) {
@@ -70,6 +83,22 @@ func GetMsgRound(content tss.MessageContent) (Round, error) {
 		return EdSigning2, nil
 	case *edsigning.SignRound3Message:
 		return EdSigning3, nil
+	case *edpresign.PresignRound1Message:
+		return EdPresign1, nil
+	case *edpresign.SignRound1Message:
+		return EdPresignSigning1, nil
+
+	// Schnorr
+	case *schnorrkeygen.KGRound1Message:
+		return SchnorrKeygen1, nil
+	case *schnorrkeygen.KGRound2Message1, *schnorrkeygen.KGRound2Message2:
+		return SchnorrKeygen2, nil
+	case *schnorrsigning.SignRound1Message:
+		return SchnorrSigning1, nil
+	case *schnorrsigning.SignRound2Message:
+		return SchnorrSigning2, nil
+	case *schnorrsigning.SignRound3Message:
+		return SchnorrSigning3, nil
 
 	default:
 		return 0, errors.New("unknown round")
@@ -89,6 +118,16 @@ func GetMessageCountByWorkType(jobType wtypes.WorkType, isPresign bool) int {
 	case wtypes.EdKeygen:
 		return 3
 	case wtypes.EdSigning:
+		if isPresign {
+			return 1
+		}
+
+		return 3
+	case wtypes.EdPresign:
+		return 1
+	case wtypes.SchnorrKeygen:
+		return 3
+	case wtypes.SchnorrSigning:
 		return 3
 	default:
 		log.Error("Unsupported work type: ", jobType.String())
@@ -96,7 +135,9 @@ func GetMessageCountByWorkType(jobType wtypes.WorkType, isPresign bool) int {
 	}
 }
 
-func GetMessagesByWorkType(jobType wtypes.WorkType) []string {
+// GetMessagesByWorkType returns the messages of a work type in the order they are sent. Signing with
+// presign data only sends the message of the online round.
+func GetMessagesByWorkType(jobType wtypes.WorkType, hasPresignData bool) []string {
 	switch jobType {
 	case wtypes.EcKeygen:
 		return []string{
@@ -107,6 +148,12 @@ func GetMessagesByWorkType(jobType wtypes.WorkType) []string {
 		}
 
 	case wtypes.EcSigning:
+		if hasPresignData {
+			return []string{
+				string(proto.MessageName(&ecsigning.SignRound7Message{})),
+			}
+		}
+
 		return []string{
 			string(proto.MessageName(&ecsigning.SignRound1Message1{})),
 			string(proto.MessageName(&ecsigning.SignRound1Message2{})),
@@ -117,11 +164,81 @@ func GetMessagesByWorkType(jobType wtypes.WorkType) []string {
 			string(proto.MessageName(&ecsigning.SignRound6Message{})),
 			string(proto.MessageName(&ecsigning.SignRound7Message{})),
 		}
+
+	case wtypes.EdKeygen:
+		return []string{
+			string(proto.MessageName(&edkeygen.KGRound1Message{})),
+			string(proto.MessageName(&edkeygen.KGRound2Message1{})),
+			string(proto.MessageName(&edkeygen.KGRound2Message2{})),
+		}
+
+	case wtypes.EdSigning:
+		if hasPresignData {
+			return []string{
+				string(proto.MessageName(&edpresign.SignRound1Message{})),
+			}
+		}
+
+		return []string{
+			string(proto.MessageName(&edsigning.SignRound1Message{})),
+			string(proto.MessageName(&edsigning.SignRound2Message{})),
+			string(proto.MessageName(&edsigning.SignRound3Message{})),
+		}
+
+	case wtypes.EdPresign:
+		return []string{
+			string(proto.MessageName(&edpresign.PresignRound1Message{})),
+		}
+
+	case wtypes.SchnorrKeygen:
+		return []string{
+			string(proto.MessageName(&schnorrkeygen.KGRound1Message{})),
+			string(proto.MessageName(&schnorrkeygen.KGRound2Message1{})),
+			string(proto.MessageName(&schnorrkeygen.KGRound2Message2{})),
+		}
+
+	case wtypes.SchnorrSigning:
+		return []string{
+			string(proto.MessageName(&schnorrsigning.SignRound1Message{})),
+			string(proto.MessageName(&schnorrsigning.SignRound2Message{})),
+			string(proto.MessageName(&schnorrsigning.SignRound3Message{})),
+		}
 	}
 
 	return make([]string, 0)
 }
 
+// IsMessageOfWorkType returns true if the message type is one of the messages of a work type.
+func IsMessageOfWorkType(jobType wtypes.WorkType, hasPresignData bool, msgType string) bool {
+	for _, m := range GetMessagesByWorkType(jobType, hasPresignData) {
+		if m == msgType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetRoundIndex returns the position of the round of a message type among the rounds of a work
+// type, or -1 if the message type is not sent in the work type. Messages that are sent in the same
+// round (e.g. KGRound2Message1 and KGRound2Message2) have the same position.
+func GetRoundIndex(jobType wtypes.WorkType, hasPresignData bool, msgType string) int {
+	index := -1
+	lastRound := ""
+	for _, m := range GetMessagesByWorkType(jobType, hasPresignData) {
+		if round := strings.TrimRight(m, "0123456789"); round != lastRound {
+			index++
+			lastRound = round
+		}
+
+		if m == msgType {
+			return index
+		}
+	}
+
+	return -1
+}
+
 // IsBroadcastMessage return true if it's broadcast message
 func IsBroadcastMessage(msgType string) bool {
 	switch msgType {
@@ -133,7 +250,19 @@ func IsBroadcastMessage(msgType string) bool {
 		string(proto.MessageName(&ecsigning.SignRound4Message{})),
 		string(proto.MessageName(&ecsigning.SignRound5Message{})),
 		string(proto.MessageName(&ecsigning.SignRound6Message{})),
-		string(proto.MessageName(&ecsigning.SignRound7Message{})):
+		string(proto.MessageName(&ecsigning.SignRound7Message{})),
+		string(proto.MessageName(&edkeygen.KGRound1Message{})),
+		string(proto.MessageName(&edkeygen.KGRound2Message2{})),
+		string(proto.MessageName(&edsigning.SignRound1Message{})),
+		string(proto.MessageName(&edsigning.SignRound2Message{})),
+		string(proto.MessageName(&edsigning.SignRound3Message{})),
+		string(proto.MessageName(&edpresign.PresignRound1Message{})),
+		string(proto.MessageName(&edpresign.SignRound1Message{})),
+		string(proto.MessageName(&schnorrkeygen.KGRound1Message{})),
+		string(proto.MessageName(&schnorrkeygen.KGRound2Message2{})),
+		string(proto.MessageName(&schnorrsigning.SignRound1Message{})),
+		string(proto.MessageName(&schnorrsigning.SignRound2Message{})),
+		string(proto.MessageName(&schnorrsigning.SignRound3Message{})):
 
 		return true
 	default:
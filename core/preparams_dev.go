@@ -2,9 +2,10 @@ package core
 
 import (
 	"encoding/json"
+	"time"
 
+	"github.com/sisu-network/dheart/core/components"
 	"github.com/sisu-network/dheart/core/config"
-	"github.com/sisu-network/dheart/db"
 	"github.com/sisu-network/tss-lib/ecdsa/keygen"
 )
 
@@ -24,7 +25,9 @@ var (
 	}
 )
 
-func preloadPreparams(db db.Database, cfg config.HeartConfig) {
+// devPreparams returns a generator that returns the preloaded preparams of a dev node instead of
+// generating new ones. Only use this in local dev mode to speed up dev time.
+func devPreparams(cfg config.HeartConfig) components.GeneratePreparamsFunc {
 	var index int
 
 	switch cfg.Db.Schema {
@@ -50,10 +53,12 @@ func preloadPreparams(db db.Database, cfg config.HeartConfig) {
 		index = 9
 	}
 
-	preparams := &keygen.LocalPreParams{}
-	err := json.Unmarshal([]byte(Preparams[index]), preparams)
-	if err != nil {
-		panic(err)
+	return func(timeout time.Duration, optionalConcurrency ...int) (*keygen.LocalPreParams, error) {
+		preparams := &keygen.LocalPreParams{}
+		if err := json.Unmarshal([]byte(Preparams[index]), preparams); err != nil {
+			return nil, err
+		}
+
+		return preparams, nil
 	}
-	db.SavePreparams(preparams)
 }
@@ -1,44 +1,60 @@
 package core
 
 import (
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/sisu-network/dheart/worker/types"
 	"github.com/sisu-network/lib/log"
 )
 
-// TODO: add job priority for this queue. Keygen &signing should have more priority than presign even
-// though they could be inserted later.
+var (
+	ErrWorkExisted = errors.New("work has already existed in the queue")
+	ErrQueueFull   = errors.New("request queue is full")
+)
+
+// requestQueue is a priority queue of works waiting for an available worker. Works are ordered by
+// their priority class and works in the same class are ordered by insertion time (FIFO).
 type requestQueue struct {
-	queue []*types.WorkRequest
-	lock  *sync.RWMutex
+	queue   []*types.WorkRequest
+	maxSize int
+	lock    *sync.RWMutex
 }
 
-func NewRequestQueue() *requestQueue {
+// NewRequestQueue creates a new request queue. The maxSize is the maximum number of non-keygen works
+// that can wait in the queue. A value <= 0 means the queue is unbounded.
+func NewRequestQueue(maxSize int) *requestQueue {
 	return &requestQueue{
-		queue: make([]*types.WorkRequest, 0),
-		lock:  &sync.RWMutex{},
+		queue:   make([]*types.WorkRequest, 0),
+		maxSize: maxSize,
+		lock:    &sync.RWMutex{},
 	}
 }
 
-// AddWork adds a work in the queue. It returns false if the work has already existed in the queue
-// and true otherwise. Priorities of different work types are as follow:
-//      - 1) keygen
-//      - 2) Forced presign that will be used for signing work
-//      - 3) Signing
-//      - 4) Presign.
-func (q *requestQueue) AddWork(work *types.WorkRequest) bool {
+// AddWork adds a work in the queue. It returns ErrWorkExisted if the work has already existed in
+// the queue and ErrQueueFull if the queue has reached its max size. Keygen works are never rejected
+// because of the queue size. Priorities of different work types are as follow:
+//   - 1) keygen
+//   - 2) Forced presign that will be used for signing work
+//   - 3) Signing
+//   - 4) Presign.
+func (q *requestQueue) AddWork(work *types.WorkRequest) error {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
 	for _, w := range q.queue {
 		if w.WorkId == work.WorkId {
-			return false
+			return ErrWorkExisted
 		}
 	}
 
+	if q.maxSize > 0 && !work.IsKeygen() && len(q.queue) >= q.maxSize {
+		return ErrQueueFull
+	}
+
 	priority := work.GetPriority()
-	// Insert the work into appropriate position of the queue
+	// Insert the work after the last work that has the same or higher priority.
 	var position int
 	for position = len(q.queue) - 1; position >= 0; position-- {
 		if priority <= q.queue[position].GetPriority() {
@@ -51,14 +67,14 @@ func (q *requestQueue) AddWork(work *types.WorkRequest) bool {
 		q.queue = append([]*types.WorkRequest{work}, q.queue...)
 	} else {
 		// Insert into the middle of the queue
-		first := q.queue[:position+1]
-		second := q.queue[position+1:]
+		second := make([]*types.WorkRequest, len(q.queue[position+1:]))
+		copy(second, q.queue[position+1:])
 
-		q.queue = append(first, work)
+		q.queue = append(q.queue[:position+1], work)
 		q.queue = append(q.queue, second...)
 	}
 
-	return true
+	return nil
 }
 
 func (q *requestQueue) Pop() *types.WorkRequest {
@@ -75,6 +91,36 @@ func (q *requestQueue) Pop() *types.WorkRequest {
 	return work
 }
 
+// RemoveExpired removes all works whose deadline has passed and returns them to the caller.
+func (q *requestQueue) RemoveExpired(now time.Time) []*types.WorkRequest {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	expired := make([]*types.WorkRequest, 0)
+	remaining := make([]*types.WorkRequest, 0, len(q.queue))
+	for _, work := range q.queue {
+		if work.IsExpired(now) {
+			expired = append(expired, work)
+		} else {
+			remaining = append(remaining, work)
+		}
+	}
+	q.queue = remaining
+
+	return expired
+}
+
+// RemoveAll removes all works from the queue and returns them to the caller.
+func (q *requestQueue) RemoveAll() []*types.WorkRequest {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	works := q.queue
+	q.queue = make([]*types.WorkRequest, 0)
+
+	return works
+}
+
 func (q *requestQueue) Size() int {
 	q.lock.RLock()
 	defer q.lock.RUnlock()
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sisu-network/dheart/worker/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestQueue_Priority(t *testing.T) {
+	t.Parallel()
+
+	presign := types.NewEcSigningRequest("presign", nil, 0, nil, nil, nil)
+	signing1 := types.NewEcSigningRequest("signing1", nil, 0, [][]byte{[]byte("msg")}, []string{"eth"}, nil)
+	signing2 := types.NewEdSigningRequest("signing2", nil, 0, [][]byte{[]byte("msg")}, []string{"sol"}, nil)
+	forcedPresign := types.NewEcSigningRequest("forced_presign", nil, 0, nil, nil, nil)
+	forcedPresign.ForcedPresign = true
+	keygen := types.NewEdKeygenRequest("keygen", nil, 0)
+
+	q := NewRequestQueue(0)
+	for _, work := range []*types.WorkRequest{presign, signing1, signing2, forcedPresign, keygen} {
+		require.Nil(t, q.AddWork(work))
+	}
+
+	expected := []string{"keygen", "forced_presign", "signing1", "signing2", "presign"}
+	for _, workId := range expected {
+		require.Equal(t, workId, q.Pop().WorkId)
+	}
+	require.Nil(t, q.Pop())
+}
+
+func TestRequestQueue_DuplicateAndFull(t *testing.T) {
+	t.Parallel()
+
+	q := NewRequestQueue(2)
+	require.Nil(t, q.AddWork(types.NewEcSigningRequest("work0", nil, 0, [][]byte{{1}}, []string{"eth"}, nil)))
+	require.Equal(t, ErrWorkExisted, q.AddWork(types.NewEcSigningRequest("work0", nil, 0, [][]byte{{1}}, []string{"eth"}, nil)))
+	require.Nil(t, q.AddWork(types.NewEcSigningRequest("work1", nil, 0, [][]byte{{1}}, []string{"eth"}, nil)))
+	require.Equal(t, ErrQueueFull, q.AddWork(types.NewEcSigningRequest("work2", nil, 0, [][]byte{{1}}, []string{"eth"}, nil)))
+
+	// Keygen is never rejected because of the queue size.
+	require.Nil(t, q.AddWork(types.NewEdKeygenRequest("keygen", nil, 0)))
+	require.Equal(t, 3, q.Size())
+}
+
+func TestRequestQueue_RemoveExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	stale := types.NewEcSigningRequest("stale", nil, 0, [][]byte{{1}}, []string{"eth"}, nil)
+	stale.Deadline = now.Add(-time.Second)
+	fresh := types.NewEcSigningRequest("fresh", nil, 0, [][]byte{{1}}, []string{"eth"}, nil)
+	fresh.Deadline = now.Add(time.Minute)
+	noDeadline := types.NewEcSigningRequest("no_deadline", nil, 0, [][]byte{{1}}, []string{"eth"}, nil)
+
+	q := NewRequestQueue(0)
+	require.Nil(t, q.AddWork(stale))
+	require.Nil(t, q.AddWork(fresh))
+	require.Nil(t, q.AddWork(noDeadline))
+
+	expired := q.RemoveExpired(now)
+	require.Equal(t, 1, len(expired))
+	require.Equal(t, "stale", expired[0].WorkId)
+	require.Equal(t, "fresh", q.Pop().WorkId)
+	require.Equal(t, "no_deadline", q.Pop().WorkId)
+}
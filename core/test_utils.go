@@ -61,8 +61,31 @@ func (mock *MockConnectionManager) IsReady() bool {
 	return false
 }
 
+func (mock *MockConnectionManager) GetPeerStatuses() []p2p.PeerStatus {
+	return nil
+}
+
+func (mock *MockConnectionManager) AddPeer(peer *p2ptypes.Peer) error {
+	return nil
+}
+
+func (mock *MockConnectionManager) RemovePeer(pID peer.ID) error {
+	return nil
+}
+
+func (mock *MockConnectionManager) IsPeerDown(pID peer.ID) bool {
+	return false
+}
+
+func (mock *MockConnectionManager) Stop() error {
+	return nil
+}
+
 // ---- /
-func getEngineTestData(n int) ([]ctypes.PrivKey, []*Node, tss.SortedPartyIDs, []*keygen.LocalPartySaveData) {
+
+// GetEngineTestData returns the keys, nodes and sorted party ids of n test nodes sorted by party id,
+// and the saved ecdsa keygen data of the nodes.
+func GetEngineTestData(n int) ([]ctypes.PrivKey, []*Node, tss.SortedPartyIDs, []*keygen.LocalPartySaveData) {
 	type dataWrapper struct {
 		key    *secp256k1.PrivKey
 		pubKey ctypes.PubKey
@@ -1,7 +1,9 @@
 package db
 
 import (
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -18,7 +20,9 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/sisu-network/dheart/core/config"
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
 	p2ptypes "github.com/sisu-network/dheart/p2p/types"
+	schnorrkeygen "github.com/sisu-network/dheart/schnorr/keygen"
 	"github.com/sisu-network/dheart/utils"
 	"github.com/sisu-network/lib/log"
 	eckeygen "github.com/sisu-network/tss-lib/ecdsa/keygen"
@@ -32,34 +36,57 @@ import (
 const (
 	PresignStatusNotUsed = "not_used"
 	PresignStatusUsed    = "used"
+
+	PreparamsStatusAvailable = "available"
+	PreparamsStatusUsed      = "used"
 )
 
 var (
-	ErrNotFound = errors.New("not found")
+	ErrNotFound    = errors.New("not found")
+	ErrPresignUsed = errors.New("presign is used or not found")
 )
 
 type Database interface {
 	Init() error
 	Close() error
 
+	// Every ECDSA keygen uses fresh preparams from a pool. Used preparams stay in the pool but are
+	// never given out again.
 	SavePreparams(preparams *eckeygen.LocalPreParams) error
-	LoadPreparams() (*eckeygen.LocalPreParams, error)
-
-	SaveEcKeygen(keyType string, workId string, pids []*tss.PartyID, keygenOutput *eckeygen.LocalPartySaveData) error
-	LoadEcKeygen(keyType string) (*eckeygen.LocalPartySaveData, error)
-
-	SaveEdKeygen(keyType string, workId string, pids []*tss.PartyID, keygenOutput *edkeygen.LocalPartySaveData) error
-	LoadEdKeygen(keyType string) (*edkeygen.LocalPartySaveData, error)
-
-	SavePresignData(workId string, pids []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData) error
-	GetAvailablePresignShortForm() ([]string, []string, error) // Returns presignIds, pids, error
+	ConsumePreparams(workId string) (*eckeygen.LocalPreParams, error)
+	CountPreparams() (int, int, error) // Returns the number of available and used preparams, error
+
+	// A key is identified by its key type and key label. Several keys of the same type can co-exist
+	// with different labels. The empty label is the default key of a key type.
+	SaveEcKeygen(keyType string, keyLabel string, workId string, pids []*tss.PartyID, keygenOutput *eckeygen.LocalPartySaveData) error
+	LoadEcKeygen(keyType string, keyLabel string) (*eckeygen.LocalPartySaveData, error)
+
+	SaveEdKeygen(keyType string, keyLabel string, workId string, pids []*tss.PartyID, keygenOutput *edkeygen.LocalPartySaveData) error
+	LoadEdKeygen(keyType string, keyLabel string) (*edkeygen.LocalPartySaveData, error)
+	SaveSchnorrKeygen(keyType string, keyLabel string, workId string, pids []*tss.PartyID, keygenOutput *schnorrkeygen.LocalPartySaveData) error
+	LoadSchnorrKeygen(keyType string, keyLabel string) (*schnorrkeygen.LocalPartySaveData, error)
+	// LoadKeygenCommittee returns the sorted ids of the parties that generated a key.
+	LoadKeygenCommittee(keyType string, keyLabel string) ([]string, error)
+	// LoadKeyLabels returns the labels of all the keys of a key type.
+	LoadKeyLabels(keyType string) ([]string, error)
+
+	// Presigns of ECDSA and EdDSA keys are saved in the same table and told apart by their key type.
+	SavePresignData(keyLabel string, workId string, pids []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData) error
+	SaveEdPresignData(keyLabel string, workId string, pids []*tss.PartyID, presignOutputs []*edpresign.PresignData) error
+	GetAvailablePresignShortForm() ([]string, []string, []string, []string, error) // Returns presignIds, pids, keyTypes, keyLabels, error
 
 	LoadPresign(presignIds []string) ([]*ecsigning.SignatureData_OneRoundData, error)
+	// LoadEdPresign returns the EdDSA presigns in the order of the presign ids.
+	LoadEdPresign(presignIds []string) ([]*edpresign.PresignData, error)
 	LoadPresignStatus(presignIds []string) ([]string, error)
+	// UpdatePresignStatus marks presigns as used. It fails with ErrPresignUsed and changes nothing if
+	// any of the presigns is not found or has been used, a presign must never be used twice.
 	UpdatePresignStatus(presignIds []string) error
 
+	// SavePeers inserts peers or replaces the peers with the same pubkey.
 	SavePeers([]*p2ptypes.Peer) error
 	LoadPeers() []*p2ptypes.Peer
+	DeletePeer(pubkey string) error
 }
 
 type dbLogger struct {
@@ -135,10 +162,17 @@ func (d *SqlDatabase) Connect() error {
 	}
 
 	d.db = database
-	database.SetMaxIdleConns(5)
-	database.SetMaxOpenConns(10)
-	database.SetConnMaxIdleTime(5 * time.Second)
-	database.SetConnMaxLifetime(30 * time.Second)
+	if d.config.InMemory {
+		// Every connection to ":memory:" opens a new empty database. Keep a single connection open
+		// forever so that the data is not lost.
+		database.SetMaxIdleConns(1)
+		database.SetMaxOpenConns(1)
+	} else {
+		database.SetMaxIdleConns(5)
+		database.SetMaxOpenConns(10)
+		database.SetConnMaxIdleTime(5 * time.Second)
+		database.SetConnMaxLifetime(30 * time.Second)
+	}
 
 	log.Info("Db is connected successfully")
 	return nil
@@ -240,54 +274,116 @@ func (d *SqlDatabase) Close() error {
 	return d.db.Close()
 }
 
+// SavePreparams adds fresh preparams to the preparams pool.
 func (d *SqlDatabase) SavePreparams(preparams *eckeygen.LocalPreParams) error {
 	bz, err := json.Marshal(preparams)
 	if err != nil {
 		return err
 	}
 
-	params := []interface{}{libchain.KEY_TYPE_ECDSA, bz}
-	query := "INSERT INTO preparams (key_type, preparams) VALUES (?, ?)"
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+
+	params := []interface{}{hex.EncodeToString(id), bz, PreparamsStatusAvailable}
+	query := "INSERT INTO preparams_pool (preparams_id, preparams, status) VALUES (?, ?, ?)"
 	_, err = d.db.Exec(query, params...)
 
 	return err
 }
 
-func (d *SqlDatabase) LoadPreparams() (*eckeygen.LocalPreParams, error) {
-	query := "SELECT preparams FROM preparams WHERE key_type=?"
-	rows, err := d.db.Query(query, libchain.KEY_TYPE_ECDSA)
+// ConsumePreparams takes the oldest available preparams of the pool and marks it as used by a
+// keygen work so that no other keygen can use it. It returns ErrNotFound if the pool is empty.
+func (d *SqlDatabase) ConsumePreparams(workId string) (*eckeygen.LocalPreParams, error) {
+	for {
+		id, bz, err := d.loadAvailablePreparams()
+		if err != nil {
+			return nil, err
+		}
+
+		query := "UPDATE preparams_pool SET status = ?, work_id = ? WHERE preparams_id = ? AND status = ?"
+		result, err := d.db.Exec(query, PreparamsStatusUsed, workId, id, PreparamsStatusAvailable)
+		if err != nil {
+			return nil, err
+		}
+
+		count, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			// Another keygen has taken these preparams. Try the next ones.
+			continue
+		}
+
+		preparams := &eckeygen.LocalPreParams{}
+		if err := json.Unmarshal(bz, preparams); err != nil {
+			return nil, err
+		}
+
+		return preparams, nil
+	}
+}
+
+func (d *SqlDatabase) loadAvailablePreparams() (string, []byte, error) {
+	query := "SELECT preparams_id, preparams FROM preparams_pool WHERE status = ? ORDER BY created_time LIMIT 1"
+	rows, err := d.db.Query(query, PreparamsStatusAvailable)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, ErrNotFound
+		return "", nil, ErrNotFound
 	}
 
+	var id string
 	var bz []byte
-	err = rows.Scan(&bz)
-	if err != nil {
-		return nil, err
+	if err := rows.Scan(&id, &bz); err != nil {
+		return "", nil, err
 	}
 
-	preparams := &eckeygen.LocalPreParams{}
-	err = json.Unmarshal(bz, preparams)
+	return id, bz, nil
+}
+
+// CountPreparams returns the number of available and used preparams in the pool.
+func (d *SqlDatabase) CountPreparams() (int, int, error) {
+	query := "SELECT status, COUNT(*) FROM preparams_pool GROUP BY status"
+	rows, err := d.db.Query(query)
 	if err != nil {
-		return nil, err
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	var available, used int
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return 0, 0, err
+		}
+
+		switch status {
+		case PreparamsStatusAvailable:
+			available = count
+		case PreparamsStatusUsed:
+			used = count
+		}
 	}
 
-	return preparams, nil
+	return available, used, nil
 }
 
-func (d *SqlDatabase) SaveEcKeygen(keyType string, workId string, pids []*tss.PartyID, keygenOutput *eckeygen.LocalPartySaveData) error {
-	return d.saveKeygen(keyType, workId, pids, keygenOutput)
+func (d *SqlDatabase) SaveEcKeygen(keyType string, keyLabel string, workId string, pids []*tss.PartyID, keygenOutput *eckeygen.LocalPartySaveData) error {
+	return d.saveKeygen(keyType, keyLabel, workId, pids, keygenOutput)
 }
 
-func (d *SqlDatabase) LoadEcKeygen(keyType string) (*eckeygen.LocalPartySaveData, error) {
-	query := "SELECT keygen_output FROM keygen WHERE key_type=?"
+func (d *SqlDatabase) LoadEcKeygen(keyType string, keyLabel string) (*eckeygen.LocalPartySaveData, error) {
+	query := "SELECT keygen_output FROM keygen WHERE key_type=? AND key_label=? ORDER BY created_time DESC"
 	params := []interface{}{
 		keyType,
+		keyLabel,
 	}
 
 	rows, err := d.db.Query(query, params...)
@@ -310,21 +406,22 @@ func (d *SqlDatabase) LoadEcKeygen(keyType string) (*eckeygen.LocalPartySaveData
 			return nil, err
 		}
 	} else {
-		log.Verbose("There is no such keygen output for ", keyType)
+		log.Verbose("There is no such keygen output for ", keyType, " label ", keyLabel)
 		return nil, nil
 	}
 
 	return result, nil
 }
 
-func (d *SqlDatabase) SaveEdKeygen(keyType string, workId string, pids []*tss.PartyID, keygenOutput *edkeygen.LocalPartySaveData) error {
-	return d.saveKeygen(keyType, workId, pids, keygenOutput)
+func (d *SqlDatabase) SaveEdKeygen(keyType string, keyLabel string, workId string, pids []*tss.PartyID, keygenOutput *edkeygen.LocalPartySaveData) error {
+	return d.saveKeygen(keyType, keyLabel, workId, pids, keygenOutput)
 }
 
-func (d *SqlDatabase) LoadEdKeygen(keyType string) (*edkeygen.LocalPartySaveData, error) {
-	query := "SELECT keygen_output FROM keygen WHERE key_type=?"
+func (d *SqlDatabase) LoadEdKeygen(keyType string, keyLabel string) (*edkeygen.LocalPartySaveData, error) {
+	query := "SELECT keygen_output FROM keygen WHERE key_type=? AND key_label=? ORDER BY created_time DESC"
 	params := []interface{}{
 		keyType,
+		keyLabel,
 	}
 
 	rows, err := d.db.Query(query, params...)
@@ -347,14 +444,104 @@ func (d *SqlDatabase) LoadEdKeygen(keyType string) (*edkeygen.LocalPartySaveData
 			return nil, err
 		}
 	} else {
-		log.Verbose("There is no such keygen output for ", keyType)
+		log.Verbose("There is no such keygen output for ", keyType, " label ", keyLabel)
+		return nil, nil
+	}
+
+	return result, nil
+}
+
+func (d *SqlDatabase) SaveSchnorrKeygen(keyType string, keyLabel string, workId string, pids []*tss.PartyID,
+	keygenOutput *schnorrkeygen.LocalPartySaveData) error {
+	return d.saveKeygen(keyType, keyLabel, workId, pids, keygenOutput)
+}
+
+func (d *SqlDatabase) LoadSchnorrKeygen(keyType string, keyLabel string) (*schnorrkeygen.LocalPartySaveData, error) {
+	query := "SELECT keygen_output FROM keygen WHERE key_type=? AND key_label=? ORDER BY created_time DESC"
+	params := []interface{}{
+		keyType,
+		keyLabel,
+	}
+
+	rows, err := d.db.Query(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &schnorrkeygen.LocalPartySaveData{}
+	if rows.Next() {
+		var bz []byte
+
+		if err := rows.Scan(&bz); err != nil {
+			log.Error("Cannot scan row", err)
+			return nil, err
+		}
+
+		if err := json.Unmarshal(bz, result); err != nil {
+			log.Error("Cannot unmarshal result", err)
+			return nil, err
+		}
+	} else {
+		log.Verbose("There is no such keygen output for ", keyType, " label ", keyLabel)
 		return nil, nil
 	}
 
 	return result, nil
 }
 
-func (d *SqlDatabase) saveKeygen(keyType string, workId string, pids []*tss.PartyID, keygenOutput any) error {
+func (d *SqlDatabase) LoadKeygenCommittee(keyType string, keyLabel string) ([]string, error) {
+	query := "SELECT pids_string FROM keygen WHERE key_type=? AND key_label=? ORDER BY created_time DESC"
+	params := []interface{}{
+		keyType,
+		keyLabel,
+	}
+
+	rows, err := d.db.Query(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.Verbose("There is no such keygen committee for ", keyType, " label ", keyLabel)
+		return nil, nil
+	}
+
+	var pidString string
+	if err := rows.Scan(&pidString); err != nil {
+		log.Error("Cannot scan row", err)
+		return nil, err
+	}
+
+	if pidString == "" {
+		return []string{}, nil
+	}
+
+	return strings.Split(pidString, ","), nil
+}
+
+func (d *SqlDatabase) LoadKeyLabels(keyType string) ([]string, error) {
+	rows, err := d.db.Query("SELECT DISTINCT key_label FROM keygen WHERE key_type=? ORDER BY key_label", keyType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keyLabels := make([]string, 0)
+	for rows.Next() {
+		var keyLabel string
+		if err := rows.Scan(&keyLabel); err != nil {
+			log.Error("Cannot scan row", err)
+			return nil, err
+		}
+		keyLabels = append(keyLabels, keyLabel)
+	}
+
+	return keyLabels, rows.Err()
+}
+
+func (d *SqlDatabase) saveKeygen(keyType string, keyLabel string, workId string, pids []*tss.PartyID, keygenOutput any) error {
 	bz, err := json.Marshal(keygenOutput)
 	if err != nil {
 		return err
@@ -362,13 +549,31 @@ func (d *SqlDatabase) saveKeygen(keyType string, workId string, pids []*tss.Part
 
 	pidString := utils.GetPidString(pids)
 
-	query := "INSERT INTO keygen (key_type, work_id, pids_string, keygen_output) VALUES (?, ?, ?, ?)"
-	_, err = d.db.Exec(query, keyType, workId, pidString, bz)
+	query := "INSERT INTO keygen (key_type, key_label, work_id, pids_string, keygen_output) VALUES (?, ?, ?, ?, ?)"
+	_, err = d.db.Exec(query, keyType, keyLabel, workId, pidString, bz)
 
 	return err
 }
 
-func (d *SqlDatabase) SavePresignData(workId string, pids []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData) error {
+func (d *SqlDatabase) SavePresignData(keyLabel string, workId string, pids []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData) error {
+	outputs := make([]interface{}, len(presignOutputs))
+	for i, output := range presignOutputs {
+		outputs[i] = output
+	}
+
+	return d.savePresigns(libchain.KEY_TYPE_ECDSA, keyLabel, workId, pids, outputs)
+}
+
+func (d *SqlDatabase) SaveEdPresignData(keyLabel string, workId string, pids []*tss.PartyID, presignOutputs []*edpresign.PresignData) error {
+	outputs := make([]interface{}, len(presignOutputs))
+	for i, output := range presignOutputs {
+		outputs[i] = output
+	}
+
+	return d.savePresigns(libchain.KEY_TYPE_EDDSA, keyLabel, workId, pids, outputs)
+}
+
+func (d *SqlDatabase) savePresigns(keyType string, keyLabel string, workId string, pids []*tss.PartyID, presignOutputs []interface{}) error {
 	if len(presignOutputs) == 0 {
 		return nil
 	}
@@ -376,8 +581,8 @@ func (d *SqlDatabase) SavePresignData(workId string, pids []*tss.PartyID, presig
 	pidString := utils.GetPidString(pids)
 
 	// Constructs multi-insert query to do all insertion in 1 query.
-	query := "INSERT INTO presign (presign_id, work_id, pids_string, status, presign_output) VALUES "
-	query = query + getQueryQuestionMark(len(presignOutputs), 5)
+	query := "INSERT INTO presign (presign_id, key_type, key_label, work_id, pids_string, status, presign_output) VALUES "
+	query = query + getQueryQuestionMark(len(presignOutputs), 7)
 
 	params := make([]interface{}, 0)
 	for i, output := range presignOutputs {
@@ -389,6 +594,8 @@ func (d *SqlDatabase) SavePresignData(workId string, pids []*tss.PartyID, presig
 		presignId := fmt.Sprintf("%s-%d", workId, i)
 
 		params = append(params, presignId)
+		params = append(params, keyType)
+		params = append(params, keyLabel)
 		params = append(params, workId)
 		params = append(params, pidString)
 
@@ -403,30 +610,35 @@ func (d *SqlDatabase) SavePresignData(workId string, pids []*tss.PartyID, presig
 }
 
 // GetAllPresignIndexes returns all available presign data sets in short form (pids, workId, index)
-// We don't want to load full data of presign sets since it might take too much memmory.
-func (d *SqlDatabase) GetAvailablePresignShortForm() ([]string, []string, error) {
-	query := fmt.Sprintf("SELECT presign_id, pids_string FROM presign WHERE status='%s'", PresignStatusNotUsed)
+// together with the type and the label of the key they belong to. We don't want to load full data
+// of presign sets since it might take too much memmory.
+func (d *SqlDatabase) GetAvailablePresignShortForm() ([]string, []string, []string, []string, error) {
+	query := fmt.Sprintf("SELECT presign_id, pids_string, key_type, key_label FROM presign WHERE status='%s'", PresignStatusNotUsed)
 	pids := make([]string, 0)
 	presignIds := make([]string, 0)
+	keyTypes := make([]string, 0)
+	keyLabels := make([]string, 0)
 
 	rows, err := d.db.Query(query)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var presignId, pid string
-		if err := rows.Scan(&presignId, &pid); err != nil {
+		var presignId, pid, keyType, keyLabel string
+		if err := rows.Scan(&presignId, &pid, &keyType, &keyLabel); err != nil {
 			log.Error("cannot scan row", err)
-			return nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		presignIds = append(presignIds, presignId)
 		pids = append(pids, pid)
+		keyTypes = append(keyTypes, keyType)
+		keyLabels = append(keyLabels, keyLabel)
 	}
 
-	return presignIds, pids, nil
+	return presignIds, pids, keyTypes, keyLabels, nil
 }
 
 // This is not part of Database interface. Should ony be used in testing since we don't want to delete
@@ -480,6 +692,51 @@ func (d *SqlDatabase) LoadPresign(presignIds []string) ([]*ecsigning.SignatureDa
 	return results, nil
 }
 
+func (d *SqlDatabase) LoadEdPresign(presignIds []string) ([]*edpresign.PresignData, error) {
+	questions := getQueryQuestionMark(1, len(presignIds))
+
+	query := "SELECT presign_id, presign_output FROM presign WHERE key_type = ? AND presign_id IN " + questions
+
+	interfaceArr := make([]interface{}, len(presignIds)+1)
+	interfaceArr[0] = libchain.KEY_TYPE_EDDSA
+	for i, s := range presignIds {
+		interfaceArr[i+1] = s
+	}
+
+	rows, err := d.db.Query(query, interfaceArr...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// All parties must use the same presign for the same message, so we keep the order of the ids.
+	loaded := make(map[string]*edpresign.PresignData)
+	for rows.Next() {
+		var presignId string
+		var bz []byte
+		if err := rows.Scan(&presignId, &bz); err != nil {
+			log.Error("Cannot load presign", err)
+			return nil, err
+		}
+
+		data := &edpresign.PresignData{}
+		if err := json.Unmarshal(bz, data); err != nil {
+			log.Error("Cannot unmarshall data", err)
+			return nil, err
+		}
+		loaded[presignId] = data
+	}
+
+	results := make([]*edpresign.PresignData, len(presignIds))
+	for i, presignId := range presignIds {
+		if results[i] = loaded[presignId]; results[i] == nil {
+			return nil, fmt.Errorf("%w: presign %s", ErrNotFound, presignId)
+		}
+	}
+
+	return results, nil
+}
+
 func (d *SqlDatabase) LoadPresignStatus(presignIds []string) ([]string, error) {
 	questions := getQueryQuestionMark(1, len(presignIds))
 
@@ -514,25 +771,57 @@ func (d *SqlDatabase) LoadPresignStatus(presignIds []string) ([]string, error) {
 func (d *SqlDatabase) UpdatePresignStatus(presignIds []string) error {
 	presignString := getQueryQuestionMark(1, len(presignIds))
 	query := fmt.Sprintf( //nolint
-		"UPDATE presign SET status = ? WHERE presign_id IN %s",
+		"UPDATE presign SET status = ? WHERE presign_id IN %s AND status = ?",
 		presignString,
 	)
 
-	interfaceArr := make([]interface{}, len(presignIds)+1)
-	interfaceArr[0] = PresignStatusUsed
-	for i, presignId := range presignIds {
-		interfaceArr[i+1] = presignId
+	interfaceArr := make([]interface{}, 0, len(presignIds)+2)
+	interfaceArr = append(interfaceArr, PresignStatusUsed)
+	for _, presignId := range presignIds {
+		interfaceArr = append(interfaceArr, presignId)
 	}
+	interfaceArr = append(interfaceArr, PresignStatusNotUsed)
 
-	_, err := d.db.Exec(query, interfaceArr...)
-	return err
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(query, interfaceArr...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Every presign must change from not used to used, duplicated ids are refused as well.
+	if count != int64(len(presignIds)) {
+		tx.Rollback()
+		return fmt.Errorf("%w: only %d of %d presigns are available", ErrPresignUsed, count, len(presignIds))
+	}
+
+	return tx.Commit()
 }
 
 func (d *SqlDatabase) SavePeers(peers []*p2ptypes.Peer) error {
-	query := "INSERT INTO peers (`address`, pubkey, pubkey_type) VALUES "
+	if len(peers) == 0 {
+		return nil
+	}
+
+	query := "REPLACE INTO peers (`address`, pubkey, pubkey_type) VALUES "
 	query = query + getQueryQuestionMark(len(peers), 3)
 
-	_, err := d.db.Exec(query)
+	params := make([]interface{}, 0, len(peers)*3)
+	for _, peer := range peers {
+		params = append(params, peer.Address, peer.PubKey, peer.PubKeyType)
+	}
+
+	_, err := d.db.Exec(query, params...)
 	if err != nil {
 		return err
 	}
@@ -540,6 +829,11 @@ func (d *SqlDatabase) SavePeers(peers []*p2ptypes.Peer) error {
 	return nil
 }
 
+func (d *SqlDatabase) DeletePeer(pubkey string) error {
+	_, err := d.db.Exec("DELETE FROM peers WHERE pubkey=?", pubkey)
+	return err
+}
+
 func (d *SqlDatabase) LoadPeers() []*p2ptypes.Peer {
 	peers := make([]*p2ptypes.Peer, 0)
 	query := fmt.Sprintf("SELECT `address`, pubkey, pubkey_type FROM peers")
@@ -562,7 +856,7 @@ func (d *SqlDatabase) LoadPeers() []*p2ptypes.Peer {
 		peer := &p2ptypes.Peer{
 			Address:    nullableAddress.String,
 			PubKey:     nullablePubkey.String,
-			PubKeyType: nullablePubkey.String,
+			PubKeyType: nullableKeytype.String,
 		}
 		peers = append(peers, peer)
 	}
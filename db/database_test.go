@@ -7,6 +7,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/sisu-network/dheart/core/config"
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
+	p2ptypes "github.com/sisu-network/dheart/p2p/types"
 	"github.com/sisu-network/tss-lib/ecdsa/keygen"
 	ecsigning "github.com/sisu-network/tss-lib/ecdsa/signing"
 	"github.com/sisu-network/tss-lib/tss"
@@ -27,7 +29,7 @@ func TestSqlDatabase_SaveEcKeygen(t *testing.T) {
 			Id: "party-0",
 		},
 	}}
-	err := dbInstance.SaveEcKeygen("ecdsa", "keygen0", pids, &keygen.LocalPartySaveData{
+	err := dbInstance.SaveEcKeygen("ecdsa", "", "keygen0", pids, &keygen.LocalPartySaveData{
 		LocalPreParams: keygen.LocalPreParams{
 			P: big.NewInt(10),
 			Q: big.NewInt(20),
@@ -35,12 +37,46 @@ func TestSqlDatabase_SaveEcKeygen(t *testing.T) {
 	})
 	require.Nil(t, err)
 
-	keygenOutput, err := dbInstance.LoadEcKeygen("ecdsa")
+	// Another key of the same type with a different label.
+	err = dbInstance.SaveEcKeygen("ecdsa", "vault1", "keygen1", pids, &keygen.LocalPartySaveData{
+		LocalPreParams: keygen.LocalPreParams{
+			P: big.NewInt(30),
+			Q: big.NewInt(40),
+		},
+	})
+	require.Nil(t, err)
+
+	keygenOutput, err := dbInstance.LoadEcKeygen("ecdsa", "")
 	require.Nil(t, err)
 	require.NotNil(t, keygenOutput)
 
 	require.Equal(t, keygenOutput.LocalPreParams.P, big.NewInt(10))
 	require.Equal(t, keygenOutput.LocalPreParams.Q, big.NewInt(20))
+
+	keygenOutput, err = dbInstance.LoadEcKeygen("ecdsa", "vault1")
+	require.Nil(t, err)
+	require.NotNil(t, keygenOutput)
+	require.Equal(t, keygenOutput.LocalPreParams.P, big.NewInt(30))
+
+	keygenOutput, err = dbInstance.LoadEcKeygen("ecdsa", "vault2")
+	require.Nil(t, err)
+	require.Nil(t, keygenOutput)
+
+	committee, err := dbInstance.LoadKeygenCommittee("ecdsa", "vault1")
+	require.Nil(t, err)
+	require.Equal(t, []string{"party-0"}, committee)
+
+	committee, err = dbInstance.LoadKeygenCommittee("ecdsa", "vault2")
+	require.Nil(t, err)
+	require.Nil(t, committee)
+
+	keyLabels, err := dbInstance.LoadKeyLabels("ecdsa")
+	require.Nil(t, err)
+	require.Equal(t, []string{"", "vault1"}, keyLabels)
+
+	keyLabels, err = dbInstance.LoadKeyLabels("eddsa")
+	require.Nil(t, err)
+	require.Empty(t, keyLabels)
 }
 
 func TestSqlDatabase_SavePresignData(t *testing.T) {
@@ -66,7 +102,7 @@ func TestSqlDatabase_SavePresignData(t *testing.T) {
 		},
 	}
 
-	err := dbInstance.SavePresignData("presign", pids, presignData)
+	err := dbInstance.SavePresignData("vault1", "presign", pids, presignData)
 	require.Nil(t, err)
 
 	presigns, err := dbInstance.LoadPresign([]string{"presign-0"})
@@ -74,10 +110,52 @@ func TestSqlDatabase_SavePresignData(t *testing.T) {
 	require.Equal(t, 1, len(presigns))
 	require.Equal(t, mockKi, presigns[0].KI)
 
-	availPresigns, loadedPids, err := dbInstance.GetAvailablePresignShortForm()
+	availPresigns, loadedPids, keyTypes, keyLabels, err := dbInstance.GetAvailablePresignShortForm()
 	require.Nil(t, err)
 	require.Equal(t, []string{"presign-0"}, availPresigns)
 	require.Equal(t, []string{"party0"}, loadedPids)
+	require.Equal(t, []string{"ecdsa"}, keyTypes)
+	require.Equal(t, []string{"vault1"}, keyLabels)
+}
+
+func TestSqlDatabase_SaveEdPresignData(t *testing.T) {
+	t.Parallel()
+
+	dbConfig := config.GetLocalhostDbConfig()
+	dbConfig.Schema = "dheart"
+	dbConfig.InMemory = true
+
+	dbInstance := NewDatabase(&dbConfig)
+	dbInstance.Init()
+
+	pids := []*tss.PartyID{{
+		MessageWrapper_PartyID: &tss.MessageWrapper_PartyID{
+			Id: "party0",
+		},
+	}}
+	presignData := []*edpresign.PresignData{
+		{
+			Ks: []*big.Int{big.NewInt(1)},
+			Di: big.NewInt(2),
+			Ei: big.NewInt(3),
+		},
+	}
+
+	err := dbInstance.SaveEdPresignData("vault1", "edpresign", pids, presignData)
+	require.Nil(t, err)
+
+	presigns, err := dbInstance.LoadEdPresign([]string{"edpresign-0"})
+	require.Nil(t, err)
+	require.Equal(t, 1, len(presigns))
+	require.Equal(t, big.NewInt(2), presigns[0].Di)
+
+	_, err = dbInstance.LoadEdPresign([]string{"edpresign-1"})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, _, keyTypes, keyLabels, err := dbInstance.GetAvailablePresignShortForm()
+	require.Nil(t, err)
+	require.Equal(t, []string{"eddsa"}, keyTypes)
+	require.Equal(t, []string{"vault1"}, keyLabels)
 }
 
 func TestSqlDatabase_LoadPresignStatus(t *testing.T) {
@@ -103,19 +181,60 @@ func TestSqlDatabase_LoadPresignStatus(t *testing.T) {
 		},
 	}
 
-	err := dbInstance.SavePresignData("presign", pids, presignData)
+	err := dbInstance.SavePresignData("vault1", "presign", pids, presignData)
 	require.Nil(t, err)
 
-	availPresigns, _, err := dbInstance.GetAvailablePresignShortForm()
+	availPresigns, _, _, _, err := dbInstance.GetAvailablePresignShortForm()
 	require.Nil(t, err)
 	require.Equal(t, 1, len(availPresigns))
 
 	err = dbInstance.UpdatePresignStatus([]string{"presign-0"})
 	require.Nil(t, err)
 
-	availPresigns, _, err = dbInstance.GetAvailablePresignShortForm()
+	availPresigns, _, _, _, err = dbInstance.GetAvailablePresignShortForm()
 	require.Nil(t, err)
 	require.Equal(t, 0, len(availPresigns))
+
+	// A presign can only be used once.
+	err = dbInstance.UpdatePresignStatus([]string{"presign-0"})
+	require.ErrorIs(t, err, ErrPresignUsed)
+}
+
+func TestSqlDatabase_UpdatePresignStatusIsAllOrNothing(t *testing.T) {
+	t.Parallel()
+
+	dbConfig := config.GetLocalhostDbConfig()
+	dbConfig.Schema = "dheart"
+	dbConfig.InMemory = true
+
+	dbInstance := NewDatabase(&dbConfig)
+	require.Nil(t, dbInstance.Init())
+
+	pids := []*tss.PartyID{{
+		MessageWrapper_PartyID: &tss.MessageWrapper_PartyID{
+			Id: "party0",
+		},
+	}}
+	presignData := []*ecsigning.SignatureData_OneRoundData{{PartyId: "party0"}}
+	require.Nil(t, dbInstance.SavePresignData("", "presign0", pids, presignData))
+	require.Nil(t, dbInstance.SavePresignData("", "presign1", pids, presignData))
+	require.Nil(t, dbInstance.UpdatePresignStatus([]string{"presign0-0"}))
+
+	// None of the presigns is marked as used if one of them has been used, is unknown or duplicated.
+	for _, presignIds := range [][]string{
+		{"presign1-0", "presign0-0"},
+		{"presign1-0", "unknown"},
+		{"presign1-0", "presign1-0"},
+	} {
+		err := dbInstance.UpdatePresignStatus(presignIds)
+		require.ErrorIs(t, err, ErrPresignUsed)
+
+		statuses, err := dbInstance.LoadPresignStatus([]string{"presign1-0"})
+		require.Nil(t, err)
+		require.Equal(t, []string{PresignStatusNotUsed}, statuses)
+	}
+
+	require.Nil(t, dbInstance.UpdatePresignStatus([]string{"presign1-0"}))
 }
 
 func TestSqlDatabase_SavePreparams(t *testing.T) {
@@ -128,14 +247,58 @@ func TestSqlDatabase_SavePreparams(t *testing.T) {
 	dbInstance := NewDatabase(&dbConfig)
 	dbInstance.Init()
 
-	err := dbInstance.SavePreparams(&keygen.LocalPreParams{
-		P: big.NewInt(10),
-		Q: big.NewInt(20),
-	})
+	for i := 1; i <= 2; i++ {
+		err := dbInstance.SavePreparams(&keygen.LocalPreParams{
+			P: big.NewInt(int64(i)),
+			Q: big.NewInt(20),
+		})
+		require.Nil(t, err)
+	}
+
+	available, used, err := dbInstance.CountPreparams()
 	require.Nil(t, err)
+	require.Equal(t, 2, available)
+	require.Equal(t, 0, used)
 
-	preparams, err := dbInstance.LoadPreparams()
+	// Every keygen gets different preparams.
+	preparams0, err := dbInstance.ConsumePreparams("keygen0")
+	require.Nil(t, err)
+	preparams1, err := dbInstance.ConsumePreparams("keygen1")
 	require.Nil(t, err)
-	require.Equal(t, big.NewInt(10), preparams.P)
-	require.Equal(t, big.NewInt(20), preparams.Q)
+	require.ElementsMatch(t, []*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{preparams0.P, preparams1.P})
+	require.Equal(t, big.NewInt(20), preparams0.Q)
+
+	available, used, err = dbInstance.CountPreparams()
+	require.Nil(t, err)
+	require.Equal(t, 0, available)
+	require.Equal(t, 2, used)
+
+	_, err = dbInstance.ConsumePreparams("keygen2")
+	require.Equal(t, ErrNotFound, err)
+}
+
+func TestSqlDatabase_Peers(t *testing.T) {
+	t.Parallel()
+
+	dbConfig := config.GetLocalhostDbConfig()
+	dbConfig.Schema = "dheart"
+	dbConfig.InMemory = true
+
+	dbInstance := NewDatabase(&dbConfig)
+	dbInstance.Init()
+
+	peers := []*p2ptypes.Peer{
+		{Address: "/ip4/127.0.0.1/tcp/28300/p2p/peer0", PubKey: "pubkey0", PubKeyType: "secp256k1"},
+		{Address: "/ip4/127.0.0.1/tcp/28301/p2p/peer1", PubKey: "pubkey1", PubKeyType: "ed25519"},
+	}
+	require.Nil(t, dbInstance.SavePeers(peers))
+	require.ElementsMatch(t, peers, dbInstance.LoadPeers())
+
+	// Saving a peer with the same pubkey replaces the old one.
+	updated := &p2ptypes.Peer{Address: "/ip4/127.0.0.2/tcp/28300/p2p/peer0", PubKey: "pubkey0", PubKeyType: "secp256k1"}
+	require.Nil(t, dbInstance.SavePeers([]*p2ptypes.Peer{updated}))
+	require.ElementsMatch(t, []*p2ptypes.Peer{updated, peers[1]}, dbInstance.LoadPeers())
+
+	require.Nil(t, dbInstance.DeletePeer("pubkey1"))
+	require.Equal(t, []*p2ptypes.Peer{updated}, dbInstance.LoadPeers())
 }
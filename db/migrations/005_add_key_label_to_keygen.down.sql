@@ -0,0 +1 @@
+ALTER TABLE keygen DROP COLUMN key_label;
@@ -0,0 +1 @@
+ALTER TABLE keygen ADD COLUMN key_label VARCHAR(256) NOT NULL DEFAULT '';
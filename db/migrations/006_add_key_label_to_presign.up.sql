@@ -0,0 +1 @@
+ALTER TABLE presign ADD COLUMN key_label VARCHAR(256) NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE presign DROP COLUMN key_type;
@@ -0,0 +1 @@
+ALTER TABLE presign ADD COLUMN key_type VARCHAR(256) NOT NULL DEFAULT 'ecdsa';
@@ -0,0 +1 @@
+DROP TABLE preparams_pool;
\ No newline at end of file
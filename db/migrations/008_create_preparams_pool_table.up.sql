@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS preparams_pool(
+  preparams_id VARCHAR(256),
+  preparams BLOB,
+  status VARCHAR(64),
+  work_id VARCHAR(256),
+  created_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (preparams_id))
+;
@@ -1,7 +1,9 @@
 package db
 
 import (
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
 	p2ptypes "github.com/sisu-network/dheart/p2p/types"
+	schnorrkeygen "github.com/sisu-network/dheart/schnorr/keygen"
 	"github.com/sisu-network/tss-lib/ecdsa/keygen"
 	eckeygen "github.com/sisu-network/tss-lib/ecdsa/keygen"
 	edkeygen "github.com/sisu-network/tss-lib/eddsa/keygen"
@@ -17,8 +19,15 @@ type MockDatabase struct {
 	// TODO: remove this unused variable
 	ecSigningOneRound []*ecsigning.SignatureData_OneRoundData
 
-	GetAvailablePresignShortFormFunc func() ([]string, []string, error)
+	SavePreparamsFunc                func(preparams *keygen.LocalPreParams) error
+	ConsumePreparamsFunc             func(workId string) (*keygen.LocalPreParams, error)
+	CountPreparamsFunc               func() (int, int, error)
+	GetAvailablePresignShortFormFunc func() ([]string, []string, []string, []string, error)
+	LoadKeygenCommitteeFunc          func(keyType string, keyLabel string) ([]string, error)
+	LoadKeyLabelsFunc                func(keyType string) ([]string, error)
+	UpdatePresignStatusFunc          func(presignIds []string) error
 	LoadPresignFunc                  func(presignIds []string) ([]*ecsigning.SignatureData_OneRoundData, error)
+	LoadEdPresignFunc                func(presignIds []string) ([]*edpresign.PresignData, error)
 }
 
 func NewMockDatabase() Database {
@@ -34,39 +43,83 @@ func (m *MockDatabase) Close() error {
 }
 
 func (m *MockDatabase) SavePreparams(preparams *keygen.LocalPreParams) error {
+	if m.SavePreparamsFunc != nil {
+		return m.SavePreparamsFunc(preparams)
+	}
+
 	return nil
 }
 
-func (m *MockDatabase) LoadPreparams() (*keygen.LocalPreParams, error) {
+func (m *MockDatabase) ConsumePreparams(workId string) (*keygen.LocalPreParams, error) {
+	if m.ConsumePreparamsFunc != nil {
+		return m.ConsumePreparamsFunc(workId)
+	}
+
 	return nil, nil
 }
 
-func (m *MockDatabase) SaveEcKeygen(keyType string, workId string, pids []*tss.PartyID, keygenOutput *eckeygen.LocalPartySaveData) error {
+func (m *MockDatabase) CountPreparams() (int, int, error) {
+	if m.CountPreparamsFunc != nil {
+		return m.CountPreparamsFunc()
+	}
+
+	return 0, 0, nil
+}
+
+func (m *MockDatabase) SaveEcKeygen(keyType string, keyLabel string, workId string, pids []*tss.PartyID, keygenOutput *eckeygen.LocalPartySaveData) error {
 	return nil
 }
 
-func (m *MockDatabase) LoadEcKeygen(keyType string) (*eckeygen.LocalPartySaveData, error) {
+func (m *MockDatabase) LoadEcKeygen(keyType string, keyLabel string) (*eckeygen.LocalPartySaveData, error) {
 	return nil, nil
 }
 
-func (m *MockDatabase) SaveEdKeygen(keyType string, workId string, pids []*tss.PartyID, keygenOutput *edkeygen.LocalPartySaveData) error {
+func (m *MockDatabase) SaveEdKeygen(keyType string, keyLabel string, workId string, pids []*tss.PartyID, keygenOutput *edkeygen.LocalPartySaveData) error {
 	return nil
 }
 
-func (m *MockDatabase) LoadEdKeygen(keyType string) (*edkeygen.LocalPartySaveData, error) {
+func (m *MockDatabase) LoadEdKeygen(keyType string, keyLabel string) (*edkeygen.LocalPartySaveData, error) {
 	return nil, nil
 }
 
-func (m *MockDatabase) SavePresignData(workId string, pids []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData) error {
+func (m *MockDatabase) SaveSchnorrKeygen(keyType string, keyLabel string, workId string, pids []*tss.PartyID, keygenOutput *schnorrkeygen.LocalPartySaveData) error {
 	return nil
 }
 
-func (m *MockDatabase) GetAvailablePresignShortForm() ([]string, []string, error) {
+func (m *MockDatabase) LoadSchnorrKeygen(keyType string, keyLabel string) (*schnorrkeygen.LocalPartySaveData, error) {
+	return nil, nil
+}
+
+func (m *MockDatabase) LoadKeygenCommittee(keyType string, keyLabel string) ([]string, error) {
+	if m.LoadKeygenCommitteeFunc != nil {
+		return m.LoadKeygenCommitteeFunc(keyType, keyLabel)
+	}
+
+	return nil, nil
+}
+
+func (m *MockDatabase) LoadKeyLabels(keyType string) ([]string, error) {
+	if m.LoadKeyLabelsFunc != nil {
+		return m.LoadKeyLabelsFunc(keyType)
+	}
+
+	return nil, nil
+}
+
+func (m *MockDatabase) SavePresignData(keyLabel string, workId string, pids []*tss.PartyID, presignOutputs []*ecsigning.SignatureData_OneRoundData) error {
+	return nil
+}
+
+func (m *MockDatabase) SaveEdPresignData(keyLabel string, workId string, pids []*tss.PartyID, presignOutputs []*edpresign.PresignData) error {
+	return nil
+}
+
+func (m *MockDatabase) GetAvailablePresignShortForm() ([]string, []string, []string, []string, error) {
 	if m.GetAvailablePresignShortFormFunc != nil {
 		return m.GetAvailablePresignShortFormFunc()
 	}
 
-	return []string{}, []string{}, nil
+	return []string{}, []string{}, []string{}, []string{}, nil
 }
 
 func (m *MockDatabase) LoadPresign(presignIds []string) ([]*ecsigning.SignatureData_OneRoundData, error) {
@@ -77,11 +130,23 @@ func (m *MockDatabase) LoadPresign(presignIds []string) ([]*ecsigning.SignatureD
 	return nil, nil
 }
 
+func (m *MockDatabase) LoadEdPresign(presignIds []string) ([]*edpresign.PresignData, error) {
+	if m.LoadEdPresignFunc != nil {
+		return m.LoadEdPresignFunc(presignIds)
+	}
+
+	return nil, nil
+}
+
 func (m *MockDatabase) LoadPresignStatus(presignIds []string) ([]string, error) {
 	return nil, nil
 }
 
 func (m *MockDatabase) UpdatePresignStatus(presignIds []string) error {
+	if m.UpdatePresignStatusFunc != nil {
+		return m.UpdatePresignStatusFunc(presignIds)
+	}
+
 	return nil
 }
 
@@ -92,3 +157,7 @@ func (m *MockDatabase) SavePeers([]*p2ptypes.Peer) error {
 func (m *MockDatabase) LoadPeers() []*p2ptypes.Peer {
 	return nil
 }
+
+func (m *MockDatabase) DeletePeer(pubkey string) error {
+	return nil
+}
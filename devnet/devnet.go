@@ -0,0 +1,382 @@
+package devnet
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	cosmosed25519 "github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	"github.com/ethereum/go-ethereum/crypto"
+	libchain "github.com/sisu-network/lib/chain"
+	"github.com/sisu-network/lib/log"
+
+	"github.com/sisu-network/dheart/core"
+	"github.com/sisu-network/dheart/p2p"
+	p2ptypes "github.com/sisu-network/dheart/p2p/types"
+	"github.com/sisu-network/dheart/types"
+	"github.com/sisu-network/dheart/utils"
+)
+
+var (
+	ErrInvalidConfig    = errors.New("invalid devnet config")
+	ErrTimeout          = errors.New("devnet timeout")
+	ErrWorkFailed       = errors.New("work failed")
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Config is the config of a local devnet.
+type Config struct {
+	// The number of nodes.
+	Nodes int
+	// The key type to generate and sign with, ecdsa or eddsa.
+	KeyType string
+	// The number of messages to sign.
+	Messages int
+	// How long to wait for every step.
+	Timeout time.Duration
+
+	// Runs every node in a child process instead of in this process.
+	ChildProcesses bool
+
+	// The network between the nodes in this process.
+	Loopback p2p.LoopbackConfig
+
+	// The home directory of the child processes. Node i runs in HomeDir/node<i>.
+	HomeDir string
+	// The dheart binary of the child processes. The default is the current executable.
+	Executable string
+	// Node i listens on the ports P2PPort + i and RpcPort + i. Its mock Sisu listens on SisuPort + i.
+	P2PPort  int
+	RpcPort  int
+	SisuPort int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Nodes:    3,
+		KeyType:  libchain.KEY_TYPE_ECDSA,
+		Messages: 1,
+		Timeout:  5 * time.Minute,
+		Loopback: p2p.LoopbackConfig{
+			Seed:    1,
+			Latency: 5 * time.Millisecond,
+			Jitter:  5 * time.Millisecond,
+		},
+		HomeDir:  filepath.Join(os.TempDir(), "dheart-devnet"),
+		P2PPort:  28300,
+		RpcPort:  5678,
+		SisuPort: 25456,
+	}
+}
+
+func (cfg Config) validate() error {
+	// The preparams of every node are preloaded.
+	if cfg.Nodes < 2 || cfg.Nodes > len(core.Preparams) {
+		return fmt.Errorf("%w: the number of nodes must be between 2 and %d", ErrInvalidConfig,
+			len(core.Preparams))
+	}
+	if cfg.KeyType != libchain.KEY_TYPE_ECDSA && cfg.KeyType != libchain.KEY_TYPE_EDDSA {
+		return fmt.Errorf("%w: unsupported key type %s", ErrInvalidConfig, cfg.KeyType)
+	}
+	if cfg.Messages < 1 {
+		return fmt.Errorf("%w: there must be at least 1 message", ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// Result is the outcome of a devnet run.
+type Result struct {
+	PubKey     []byte
+	Messages   [][]byte
+	Signatures [][]byte
+}
+
+// RunCommand runs the devnet command with its command line arguments.
+func RunCommand(args []string) error {
+	cfg := DefaultConfig()
+
+	flags := flag.NewFlagSet("devnet", flag.ContinueOnError)
+	flags.IntVar(&cfg.Nodes, "n", cfg.Nodes, "number of nodes")
+	flags.StringVar(&cfg.KeyType, "key-type", cfg.KeyType, "key type, ecdsa or eddsa")
+	flags.IntVar(&cfg.Messages, "messages", cfg.Messages, "number of messages to sign")
+	flags.DurationVar(&cfg.Timeout, "timeout", cfg.Timeout, "timeout of every step")
+	flags.BoolVar(&cfg.ChildProcesses, "child-processes", cfg.ChildProcesses,
+		"run every node in a child process that talks over libp2p")
+	flags.Int64Var(&cfg.Loopback.Seed, "seed", cfg.Loopback.Seed, "seed of the in-process network")
+	flags.DurationVar(&cfg.Loopback.Latency, "latency", cfg.Loopback.Latency, "latency of the in-process network")
+	flags.DurationVar(&cfg.Loopback.Jitter, "jitter", cfg.Loopback.Jitter, "jitter of the in-process network")
+	flags.StringVar(&cfg.HomeDir, "home", cfg.HomeDir, "home directory of the child processes")
+	flags.StringVar(&cfg.Executable, "executable", cfg.Executable, "dheart binary of the child processes")
+	flags.IntVar(&cfg.P2PPort, "p2p-port", cfg.P2PPort, "first p2p port of the child processes")
+	flags.IntVar(&cfg.RpcPort, "rpc-port", cfg.RpcPort, "first rpc port of the child processes")
+	flags.IntVar(&cfg.SisuPort, "sisu-port", cfg.SisuPort, "first port of the mock Sisu")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	result, err := Run(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "%s public key: %s\n", cfg.KeyType, hex.EncodeToString(result.PubKey))
+	for i := range result.Messages {
+		fmt.Fprintf(os.Stdout, "message %s\n  signature %s\n", hex.EncodeToString(result.Messages[i]),
+			hex.EncodeToString(result.Signatures[i]))
+	}
+	fmt.Fprintln(os.Stdout, "All signatures are valid")
+
+	return nil
+}
+
+// Run starts a devnet, generates a key and signs messages with it. The signatures are verified
+// against the generated public key.
+func Run(cfg Config) (*Result, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	// The tendermint keys of the nodes.
+	privKeys := make([]*cosmosed25519.PrivKey, cfg.Nodes)
+	pubKeys := make([]types.PubKeyWrapper, cfg.Nodes)
+	peers := make([]*p2ptypes.Peer, cfg.Nodes)
+	for i := range privKeys {
+		privKeys[i] = cosmosed25519.GenPrivKey()
+		pubKeys[i] = types.PubKeyWrapper{KeyType: privKeys[i].Type(), Key: privKeys[i].PubKey().Bytes()}
+
+		pubKeyHex := hex.EncodeToString(pubKeys[i].Key)
+		pID, err := p2p.GetPeerId(pubKeyHex, privKeys[i].Type())
+		if err != nil {
+			return nil, err
+		}
+		peers[i] = &p2ptypes.Peer{
+			Address:    fmt.Sprintf("/ip4/127.0.0.1/tcp/%d/p2p/%s", cfg.P2PPort+i, pID),
+			PubKey:     pubKeyHex,
+			PubKeyType: privKeys[i].Type(),
+		}
+	}
+
+	aesKey := make([]byte, 32)
+	if _, err := rand.Read(aesKey); err != nil {
+		return nil, err
+	}
+
+	sisu := newMockSisu(cfg.Nodes)
+	var nodes []dheart
+	var stop func()
+	var err error
+	if cfg.ChildProcesses {
+		nodes, stop, err = startChildProcesses(cfg, peers, aesKey, sisu)
+	} else {
+		nodes, stop, err = startInProcess(cfg, peers, aesKey, sisu)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	// Setting the private key starts the connection manager which waits for the other nodes to
+	// connect, so the keys are set on all the nodes at the same time.
+	errs := make([]error, len(nodes))
+	wg := &sync.WaitGroup{}
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node dheart) {
+			defer wg.Done()
+
+			encrypted, err := utils.AESDEncrypt(privKeys[i].Bytes(), aesKey)
+			if err == nil {
+				err = node.SetPrivKey(hex.EncodeToString(encrypted), privKeys[i].Type())
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("cannot set private key of node %d: %w", i, err)
+				return
+			}
+			node.SetSisuReady(true)
+		}(i, node)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := waitForPeers(nodes, cfg.Timeout); err != nil {
+		return nil, err
+	}
+
+	pubKey, err := keygen(cfg, nodes, pubKeys, sisu)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Generated %s key %s", cfg.KeyType, hex.EncodeToString(pubKey))
+
+	messages := make([][]byte, cfg.Messages)
+	for i := range messages {
+		hash := sha256.Sum256([]byte(fmt.Sprintf("devnet message %d", i)))
+		messages[i] = hash[:]
+	}
+
+	signatures, err := keysign(cfg, nodes, pubKeys, messages, sisu)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range messages {
+		if err := verifySignature(cfg.KeyType, pubKey, messages[i], signatures[i]); err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+	}
+
+	return &Result{
+		PubKey:     pubKey,
+		Messages:   messages,
+		Signatures: signatures,
+	}, nil
+}
+
+// waitForPeers waits until every node is connected to all of its peers.
+func waitForPeers(nodes []dheart, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for i, node := range nodes {
+		for {
+			connected := 0
+			peers := node.ListPeers()
+			for _, peer := range peers {
+				if peer.State == p2p.PeerConnected.String() {
+					connected++
+				}
+			}
+			if connected == len(nodes)-1 {
+				break
+			}
+
+			if time.Now().After(deadline) {
+				return fmt.Errorf("%w: node %d is connected to %d peers", ErrTimeout, i, connected)
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+
+	log.Info("All nodes are connected")
+	return nil
+}
+
+func keygen(cfg Config, nodes []dheart, pubKeys []types.PubKeyWrapper, sisu *mockSisu) ([]byte, error) {
+	for i, node := range nodes {
+		if err := node.KeyGen("devnet-keygen", cfg.KeyType, pubKeys, nil); err != nil {
+			return nil, fmt.Errorf("cannot start keygen on node %d: %w", i, err)
+		}
+	}
+
+	var pubKey []byte
+	for range nodes {
+		select {
+		case result := <-sisu.keygenResults:
+			if result.Outcome != types.OutcomeSuccess {
+				return nil, fmt.Errorf("%w: keygen outcome %d, culprits %v", ErrWorkFailed, result.Outcome,
+					result.Culprits)
+			}
+			if pubKey == nil {
+				pubKey = result.PubKeyBytes
+			}
+			if !bytes.Equal(pubKey, result.PubKeyBytes) {
+				return nil, fmt.Errorf("%w: nodes generated different public keys", ErrWorkFailed)
+			}
+		case <-time.After(cfg.Timeout):
+			return nil, fmt.Errorf("%w: waiting for keygen results", ErrTimeout)
+		}
+	}
+
+	return pubKey, nil
+}
+
+func keysign(cfg Config, nodes []dheart, pubKeys []types.PubKeyWrapper, messages [][]byte,
+	sisu *mockSisu) ([][]byte, error) {
+	request := &types.KeysignRequest{
+		KeyType:         cfg.KeyType,
+		KeysignMessages: make([]*types.KeysignMessage, len(messages)),
+	}
+	for i, msg := range messages {
+		request.KeysignMessages[i] = &types.KeysignMessage{
+			Id:          fmt.Sprintf("devnet-message-%d", i),
+			OutChain:    "devnet",
+			OutHash:     hex.EncodeToString(msg),
+			BytesToSign: msg,
+		}
+	}
+
+	for i, node := range nodes {
+		if err := node.KeySign(request, pubKeys); err != nil {
+			return nil, fmt.Errorf("cannot start keysign on node %d: %w", i, err)
+		}
+	}
+
+	var signatures [][]byte
+	for range nodes {
+		select {
+		case result := <-sisu.keysignResults:
+			// Only threshold + 1 nodes sign, the other nodes are not selected.
+			if result.Outcome == types.OutcometNotSelected {
+				continue
+			}
+			if result.Outcome != types.OutcomeSuccess {
+				return nil, fmt.Errorf("%w: keysign outcome %d, err = %s", ErrWorkFailed, result.Outcome,
+					result.ErrMesage)
+			}
+			if len(result.Signatures) != len(messages) {
+				return nil, fmt.Errorf("%w: expected %d signatures, got %d", ErrWorkFailed, len(messages),
+					len(result.Signatures))
+			}
+			if signatures == nil {
+				signatures = result.Signatures
+			}
+			for i := range signatures {
+				if !bytes.Equal(signatures[i], result.Signatures[i]) {
+					return nil, fmt.Errorf("%w: nodes produced different signatures", ErrWorkFailed)
+				}
+			}
+		case <-time.After(cfg.Timeout):
+			return nil, fmt.Errorf("%w: waiting for keysign results", ErrTimeout)
+		}
+	}
+	if signatures == nil {
+		return nil, fmt.Errorf("%w: no node signed the messages", ErrWorkFailed)
+	}
+
+	return signatures, nil
+}
+
+// verifySignature checks a signature of the generated key. ECDSA signatures are in the
+// [R || S || V] format and the public key is uncompressed.
+func verifySignature(keyType string, pubKey, msg, sig []byte) error {
+	switch keyType {
+	case libchain.KEY_TYPE_ECDSA:
+		recovered, err := crypto.Ecrecover(msg, sig)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		if !bytes.Equal(recovered, pubKey) {
+			return fmt.Errorf("%w: recovered public key %s", ErrInvalidSignature, hex.EncodeToString(recovered))
+		}
+	case libchain.KEY_TYPE_EDDSA:
+		if len(pubKey) != ed25519.PublicKeySize || !ed25519.Verify(pubKey, msg, sig) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("unsupported key type %s", keyType)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package devnet
+
+import (
+	"testing"
+
+	libchain "github.com/sisu-network/lib/chain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_InProcess(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	for _, keyType := range []string{libchain.KEY_TYPE_ECDSA, libchain.KEY_TYPE_EDDSA} {
+		cfg := DefaultConfig()
+		cfg.KeyType = keyType
+		cfg.Messages = 2
+
+		result, err := Run(cfg)
+		require.NoError(t, err, keyType)
+		require.Len(t, result.Signatures, 2)
+		for i := range result.Messages {
+			require.NoError(t, verifySignature(keyType, result.PubKey, result.Messages[i], result.Signatures[i]))
+		}
+
+		// A signature of another message is rejected.
+		require.ErrorIs(t, verifySignature(keyType, result.PubKey, result.Messages[0], result.Signatures[1]),
+			ErrInvalidSignature)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	require.NoError(t, cfg.validate())
+
+	cfg.Nodes = 1
+	require.ErrorIs(t, cfg.validate(), ErrInvalidConfig)
+
+	cfg = DefaultConfig()
+	cfg.KeyType = "rsa"
+	require.ErrorIs(t, cfg.validate(), ErrInvalidConfig)
+}
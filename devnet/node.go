@@ -0,0 +1,245 @@
+package devnet
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sisu-network/lib/log"
+
+	"github.com/sisu-network/dheart/core"
+	"github.com/sisu-network/dheart/core/config"
+	"github.com/sisu-network/dheart/p2p"
+	p2ptypes "github.com/sisu-network/dheart/p2p/types"
+	"github.com/sisu-network/dheart/server"
+	"github.com/sisu-network/dheart/types"
+)
+
+// dheart is the part of the dheart api that the mock Sisu uses to drive a node.
+type dheart interface {
+	SetPrivKey(encodedKey string, keyType string) error
+	SetSisuReady(isReady bool)
+	KeyGen(keygenId string, keyType string, tPubKeys []types.PubKeyWrapper, keyLabel *string) error
+	KeySign(req *types.KeysignRequest, tPubKeys []types.PubKeyWrapper) error
+	ListPeers() []*types.PeerInfo
+}
+
+// getPeers returns the peers of a node, i.e. all the other nodes.
+func getPeers(peers []*p2ptypes.Peer, index int) []*p2ptypes.Peer {
+	result := make([]*p2ptypes.Peer, 0, len(peers)-1)
+	for i, peer := range peers {
+		if i != index {
+			result = append(result, peer)
+		}
+	}
+
+	return result
+}
+
+// startInProcess starts the nodes in this process. The nodes talk to each other on a loopback
+// network.
+func startInProcess(cfg Config, peers []*p2ptypes.Peer, aesKey []byte, sisu *mockSisu) ([]dheart, func(), error) {
+	network := p2p.NewLoopbackNetwork(cfg.Loopback)
+
+	nodes := make([]dheart, cfg.Nodes)
+	apis := make([]*server.TssApi, 0, cfg.Nodes)
+	stop := func() {
+		for _, api := range apis {
+			api.Stop()
+		}
+		network.Close()
+	}
+	for i := range nodes {
+		heartConfig := config.HeartConfig{
+			ShortcutPreparams: true,
+			Db: config.DbConfig{
+				// The schema selects the preloaded preparams.
+				Schema:   fmt.Sprintf("dheart%d", i),
+				InMemory: true,
+			},
+			Connection: p2ptypes.ConnectionsConfig{Peers: getPeers(peers, i)},
+			AesKey:     aesKey,
+		}
+
+		heart := core.NewHeart(heartConfig, &sisuClient{sisu: sisu})
+		heart.SetConnectionManagerFactory(network.NewConnectionManager)
+		if err := heart.Start(); err != nil {
+			stop()
+			return nil, nil, err
+		}
+
+		api := server.NewTssApi(heart)
+		apis = append(apis, api)
+		nodes[i] = api
+	}
+
+	return nodes, stop, nil
+}
+
+// startChildProcesses runs every node in a child process of the dheart binary with its own home
+// directory. The nodes talk to each other over libp2p and to the mock Sisu over rpc.
+func startChildProcesses(cfg Config, peers []*p2ptypes.Peer, aesKey []byte, sisu *mockSisu) ([]dheart, func(), error) {
+	executable := cfg.Executable
+	if executable == "" {
+		var err error
+		if executable, err = os.Executable(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	servers := make([]*http.Server, 0, cfg.Nodes)
+	cmds := make([]*exec.Cmd, 0, cfg.Nodes)
+	stop := func() {
+		for _, cmd := range cmds {
+			cmd.Process.Signal(syscall.SIGTERM)
+			cmd.Wait()
+		}
+		for _, srv := range servers {
+			srv.Close()
+		}
+	}
+
+	nodes := make([]dheart, cfg.Nodes)
+	for i := range nodes {
+		homeDir := filepath.Join(cfg.HomeDir, fmt.Sprintf("node%d", i))
+		if err := writeNodeConfig(cfg, homeDir, i, getPeers(peers, i), aesKey); err != nil {
+			stop()
+			return nil, nil, err
+		}
+
+		// The mock Sisu must listen before the node starts since the node dials it right away.
+		handler := rpc.NewServer()
+		if err := handler.RegisterName("tss", &sisuApi{sisu: sisu}); err != nil {
+			stop()
+			return nil, nil, err
+		}
+		listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", cfg.SisuPort+i))
+		if err != nil {
+			stop()
+			return nil, nil, err
+		}
+		srv := &http.Server{Handler: handler}
+		servers = append(servers, srv)
+		go srv.Serve(listener)
+
+		logFile, err := os.Create(filepath.Join(homeDir, "dheart.log"))
+		if err != nil {
+			stop()
+			return nil, nil, err
+		}
+		defer logFile.Close()
+
+		cmd := exec.Command(executable)
+		cmd.Dir = homeDir
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+		if err := cmd.Start(); err != nil {
+			stop()
+			return nil, nil, err
+		}
+		cmds = append(cmds, cmd)
+		log.Infof("Started node %d, pid = %d, home = %s", i, cmd.Process.Pid, homeDir)
+
+		client, err := rpc.Dial(fmt.Sprintf("http://127.0.0.1:%d", cfg.RpcPort+i))
+		if err != nil {
+			stop()
+			return nil, nil, err
+		}
+		nodes[i] = &dheartClient{client: client, timeout: cfg.Timeout}
+	}
+
+	// Every node pings the mock Sisu once its rpc server is up.
+	for i := 0; i < cfg.Nodes; i++ {
+		select {
+		case <-sisu.pings:
+		case <-time.After(cfg.Timeout):
+			stop()
+			return nil, nil, fmt.Errorf("%w: waiting for nodes to start, see dheart.log in %s", ErrTimeout,
+				cfg.HomeDir)
+		}
+	}
+
+	return nodes, stop, nil
+}
+
+func writeNodeConfig(cfg Config, homeDir string, index int, peers []*p2ptypes.Peer, aesKey []byte) error {
+	if err := os.MkdirAll(homeDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	config.WriteConfigFile(filepath.Join(homeDir, "dheart.toml"), config.HeartConfig{
+		HomeDir:           homeDir,
+		ShortcutPreparams: true,
+		SisuServerUrl:     fmt.Sprintf("http://127.0.0.1:%d", cfg.SisuPort+index),
+		Port:              cfg.RpcPort + index,
+		Db: config.DbConfig{
+			Schema:   fmt.Sprintf("dheart%d", index),
+			InMemory: true,
+		},
+		Connection: p2ptypes.ConnectionsConfig{
+			Host:       "127.0.0.1",
+			Port:       cfg.P2PPort + index,
+			Rendezvous: "rendezvous",
+			Peers:      peers,
+		},
+	})
+
+	env := fmt.Sprintf("HOME_DIR=.\nAES_KEY_HEX=%s\n", hex.EncodeToString(aesKey))
+	return os.WriteFile(filepath.Join(homeDir, ".env"), []byte(env), 0600)
+}
+
+// dheartClient drives a node in a child process over rpc.
+type dheartClient struct {
+	client  *rpc.Client
+	timeout time.Duration
+}
+
+func (c *dheartClient) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	return c.client.CallContext(ctx, result, method, args...)
+}
+
+func (c *dheartClient) SetPrivKey(encodedKey string, keyType string) error {
+	var result interface{}
+	return c.call(&result, "tss_setPrivKey", encodedKey, keyType)
+}
+
+func (c *dheartClient) SetSisuReady(isReady bool) {
+	var result interface{}
+	if err := c.call(&result, "tss_setSisuReady", isReady); err != nil {
+		log.Error("Cannot set sisu ready, err = ", err)
+	}
+}
+
+func (c *dheartClient) KeyGen(keygenId string, keyType string, tPubKeys []types.PubKeyWrapper, keyLabel *string) error {
+	var result interface{}
+	if keyLabel == nil {
+		return c.call(&result, "tss_keyGen", keygenId, keyType, tPubKeys)
+	}
+	return c.call(&result, "tss_keyGen", keygenId, keyType, tPubKeys, *keyLabel)
+}
+
+func (c *dheartClient) KeySign(req *types.KeysignRequest, tPubKeys []types.PubKeyWrapper) error {
+	var result interface{}
+	return c.call(&result, "tss_keySign", req, tPubKeys)
+}
+
+func (c *dheartClient) ListPeers() []*types.PeerInfo {
+	var result []*types.PeerInfo
+	if err := c.call(&result, "tss_listPeers"); err != nil {
+		log.Error("Cannot list peers, err = ", err)
+		return nil
+	}
+
+	return result
+}
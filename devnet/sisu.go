@@ -0,0 +1,76 @@
+package devnet
+
+import (
+	"github.com/sisu-network/dheart/client"
+	"github.com/sisu-network/dheart/types"
+	"github.com/sisu-network/lib/log"
+)
+
+// mockSisu plays the role of Sisu for all the nodes of a devnet. It collects the results posted by
+// the nodes.
+type mockSisu struct {
+	pings          chan string
+	keygenResults  chan *types.KeygenResult
+	keysignResults chan *types.KeysignResult
+}
+
+func newMockSisu(n int) *mockSisu {
+	return &mockSisu{
+		pings:          make(chan string, n),
+		keygenResults:  make(chan *types.KeygenResult, n),
+		keysignResults: make(chan *types.KeysignResult, n),
+	}
+}
+
+// sisuClient is the client of a node that runs in the same process as the mock Sisu.
+type sisuClient struct {
+	sisu *mockSisu
+}
+
+var _ client.Client = (*sisuClient)(nil)
+
+func (c *sisuClient) TryDial() {
+	c.sisu.pings <- "dheart"
+}
+
+func (c *sisuClient) PostKeygenResult(result *types.KeygenResult) error {
+	c.sisu.keygenResults <- result
+	return nil
+}
+
+func (c *sisuClient) PostPresignResult(result *types.PresignResult) error {
+	return nil
+}
+
+func (c *sisuClient) PostKeysignResult(result *types.KeysignResult) error {
+	c.sisu.keysignResults <- result
+	return nil
+}
+
+// sisuApi is the rpc api of the mock Sisu for the nodes that run as child processes.
+type sisuApi struct {
+	sisu *mockSisu
+}
+
+func (api *sisuApi) Version() string {
+	return "1.0"
+}
+
+func (api *sisuApi) Ping(source string) {
+	api.sisu.pings <- source
+}
+
+func (api *sisuApi) KeygenResult(result *types.KeygenResult) bool {
+	log.Info("Received keygen result, outcome = ", result.Outcome)
+	api.sisu.keygenResults <- result
+
+	return true
+}
+
+func (api *sisuApi) PresignResult(result *types.PresignResult) {
+}
+
+func (api *sisuApi) KeysignResult(result *types.KeysignResult) {
+	log.Info("Received keysign result, outcome = ", result.Outcome)
+	api.sisu.keysignResults <- result
+}
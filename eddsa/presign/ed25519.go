@@ -0,0 +1,77 @@
+package presign
+
+import (
+	"crypto/sha512"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/edwards/v2"
+	"github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+// bindingFactorTag separates the hashes of the binding factors from other uses of SHA-512.
+const bindingFactorTag = "dheart-eddsa-presign-binding"
+
+// encodePoint returns the 32-byte Ed25519 encoding of a point.
+func encodePoint(p *crypto.ECPoint) []byte {
+	return edwards.NewPublicKey(p.X(), p.Y()).Serialize()
+}
+
+// encodeScalar returns the 32-byte little endian encoding of a scalar.
+func encodeScalar(s *big.Int) []byte {
+	return reverse(s.FillBytes(make([]byte, 32)))
+}
+
+// hashToScalar reads a SHA-512 digest as a little endian integer and reduces it modulo the order of
+// the curve.
+func hashToScalar(digest []byte) *big.Int {
+	s := new(big.Int).SetBytes(reverse(digest))
+	return s.Mod(s, tss.EC(tss.EddsaScheme).Params().N)
+}
+
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+// bindingFactors returns the binding factor of every party of a presign for a message. The factors
+// bind the nonces of each party to the message and to the nonce points of all other parties.
+func bindingFactors(msg []byte, presign *PresignData) []*big.Int {
+	commitments := make([]byte, 0)
+	for j := range presign.Ks {
+		commitments = appendInt(commitments, presign.Ks[j])
+		commitments = append(commitments, encodePoint(presign.BigDs[j])...)
+		commitments = append(commitments, encodePoint(presign.BigEs[j])...)
+	}
+
+	rhos := make([]*big.Int, len(presign.Ks))
+	for j := range presign.Ks {
+		h := sha512.New()
+		h.Write([]byte(bindingFactorTag))
+		h.Write(appendInt(nil, presign.Ks[j]))
+		h.Write(commitments)
+		h.Write(msg)
+		rhos[j] = hashToScalar(h.Sum(nil))
+	}
+
+	return rhos
+}
+
+// appendInt appends a length prefixed big endian encoding of x to b.
+func appendInt(b []byte, x *big.Int) []byte {
+	bz := x.Bytes()
+	b = append(b, byte(len(bz)>>8), byte(len(bz)))
+	return append(b, bz...)
+}
+
+// challenge returns the Ed25519 challenge SHA-512(R || A || M) of a signature.
+func challenge(r, pubKey *crypto.ECPoint, msg []byte) *big.Int {
+	h := sha512.New()
+	h.Write(encodePoint(r))
+	h.Write(encodePoint(pubKey))
+	h.Write(msg)
+	return hashToScalar(h.Sum(nil))
+}
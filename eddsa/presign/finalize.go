@@ -0,0 +1,87 @@
+package presign
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+
+	"github.com/sisu-network/tss-lib/common"
+	"github.com/sisu-network/tss-lib/crypto"
+	edsigning "github.com/sisu-network/tss-lib/eddsa/signing"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+func (round *finalization) Start() *tss.Error {
+	if round.started {
+		return round.WrapError(errors.New("round already started"))
+	}
+	round.number = 2
+	round.started = true
+	round.resetOK()
+
+	ec := tss.EC(tss.EddsaScheme)
+	modQ := common.ModInt(ec.Params().N)
+	ks := round.key.Ks
+
+	// 1. check the share of every party: zj * G == Dj + rhoj * Ej + c * lambdaj * Xj
+	sumZ := round.temp.zi
+	culprits := make([]*tss.PartyID, 0)
+	for j, Pj := range round.Parties().IDs() {
+		round.ok[j] = true
+		if j == round.PartyID().Index {
+			continue
+		}
+		r1msg := round.temp.signRound1Messages[j].Content().(*SignRound1Message)
+		zj := r1msg.UnmarshalZ()
+
+		lambdaj := edsigning.PrepareForSigning(j, len(ks), big.NewInt(1), ks)
+		bigWj := round.key.BigXj[j].ScalarMult(modQ.Mul(round.temp.c, lambdaj))
+		expected, err := round.presign.BigDs[j].Add(round.presign.BigEs[j].ScalarMult(round.temp.rhos[j]))
+		if err == nil {
+			expected, err = expected.Add(bigWj)
+		}
+		if err != nil || !crypto.ScalarBaseMult(ec, zj).Equals(expected) {
+			culprits = append(culprits, Pj)
+			continue
+		}
+		sumZ = modQ.Add(sumZ, zj)
+	}
+	if len(culprits) > 0 {
+		return round.WrapError(errors.New("signature share verify failed"), culprits...)
+	}
+
+	// 2. the signature is R || s in the Ed25519 encoding
+	encodedR := encodePoint(round.temp.r)
+	signature := append(encodedR, encodeScalar(sumZ)...)
+
+	pubKey := ed25519.PublicKey(encodePoint(round.key.EDDSAPub))
+	if !ed25519.Verify(pubKey, round.temp.m, signature) {
+		return round.WrapError(errors.New("signature verification failed"))
+	}
+
+	// save the signature for final output. R and S are the encoded values read as little endian
+	// integers like the signatures of tss-lib.
+	round.data.Signature = &common.ECSignature{
+		Signature: signature,
+		R:         new(big.Int).SetBytes(reverse(encodedR)).Bytes(),
+		S:         sumZ.Bytes(),
+		M:         round.temp.m,
+	}
+	round.end <- round.data
+
+	return nil
+}
+
+func (round *finalization) CanAccept(msg tss.ParsedMessage) bool {
+	// not expecting any incoming messages in this round
+	return false
+}
+
+func (round *finalization) Update() (bool, *tss.Error) {
+	// not expecting any incoming messages in this round
+	return false, nil
+}
+
+func (round *finalization) NextRound() tss.Round {
+	return nil // finished!
+}
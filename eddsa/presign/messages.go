@@ -0,0 +1,76 @@
+package presign
+
+import (
+	"math/big"
+
+	"github.com/sisu-network/tss-lib/common"
+	"github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+// These messages were generated from Protocol Buffers definitions into presign.pb.go
+
+var (
+	// Ensure that presign messages implement ValidateBasic
+	_ = []tss.MessageContent{
+		(*PresignRound1Message)(nil),
+		(*SignRound1Message)(nil),
+	}
+)
+
+// ----- //
+
+func NewPresignRound1Message(from *tss.PartyID, bigD, bigE *crypto.ECPoint) tss.ParsedMessage {
+	meta := tss.MessageRouting{
+		From:        from,
+		IsBroadcast: true,
+	}
+	content := &PresignRound1Message{
+		DX: bigD.X().Bytes(),
+		DY: bigD.Y().Bytes(),
+		EX: bigE.X().Bytes(),
+		EY: bigE.Y().Bytes(),
+	}
+	msg := tss.NewMessageWrapper(meta, content)
+	return tss.NewMessage(meta, content, msg)
+}
+
+func (m *PresignRound1Message) ValidateBasic() bool {
+	return m != nil &&
+		common.NonEmptyBytes(m.GetDX()) &&
+		common.NonEmptyBytes(m.GetDY()) &&
+		common.NonEmptyBytes(m.GetEX()) &&
+		common.NonEmptyBytes(m.GetEY())
+}
+
+func (m *PresignRound1Message) UnmarshalD() (*crypto.ECPoint, error) {
+	return crypto.NewECPoint(tss.EC(tss.EddsaScheme), new(big.Int).SetBytes(m.GetDX()),
+		new(big.Int).SetBytes(m.GetDY()))
+}
+
+func (m *PresignRound1Message) UnmarshalE() (*crypto.ECPoint, error) {
+	return crypto.NewECPoint(tss.EC(tss.EddsaScheme), new(big.Int).SetBytes(m.GetEX()),
+		new(big.Int).SetBytes(m.GetEY()))
+}
+
+// ----- //
+
+func NewSignRound1Message(from *tss.PartyID, zi *big.Int) tss.ParsedMessage {
+	meta := tss.MessageRouting{
+		From:        from,
+		IsBroadcast: true,
+	}
+	content := &SignRound1Message{
+		Z: zi.Bytes(),
+	}
+	msg := tss.NewMessageWrapper(meta, content)
+	return tss.NewMessage(meta, content, msg)
+}
+
+func (m *SignRound1Message) ValidateBasic() bool {
+	return m != nil && common.NonEmptyBytes(m.GetZ())
+}
+
+func (m *SignRound1Message) UnmarshalZ() *big.Int {
+	return new(big.Int).SetBytes(m.GetZ())
+}
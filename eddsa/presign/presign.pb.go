@@ -0,0 +1,238 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.19.3
+// source: proto/eddsa/presign.proto
+
+package presign
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// A BROADCAST message sent during round 1 of the EdDSA presign protocol. It carries the two nonce
+// points of the sender.
+type PresignRound1Message struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DX []byte `protobuf:"bytes,1,opt,name=d_x,json=dX,proto3" json:"d_x,omitempty"`
+	DY []byte `protobuf:"bytes,2,opt,name=d_y,json=dY,proto3" json:"d_y,omitempty"`
+	EX []byte `protobuf:"bytes,3,opt,name=e_x,json=eX,proto3" json:"e_x,omitempty"`
+	EY []byte `protobuf:"bytes,4,opt,name=e_y,json=eY,proto3" json:"e_y,omitempty"`
+}
+
+func (x *PresignRound1Message) Reset() {
+	*x = PresignRound1Message{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_eddsa_presign_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PresignRound1Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresignRound1Message) ProtoMessage() {}
+
+func (x *PresignRound1Message) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_eddsa_presign_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresignRound1Message.ProtoReflect.Descriptor instead.
+func (*PresignRound1Message) Descriptor() ([]byte, []int) {
+	return file_proto_eddsa_presign_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PresignRound1Message) GetDX() []byte {
+	if x != nil {
+		return x.DX
+	}
+	return nil
+}
+
+func (x *PresignRound1Message) GetDY() []byte {
+	if x != nil {
+		return x.DY
+	}
+	return nil
+}
+
+func (x *PresignRound1Message) GetEX() []byte {
+	if x != nil {
+		return x.EX
+	}
+	return nil
+}
+
+func (x *PresignRound1Message) GetEY() []byte {
+	if x != nil {
+		return x.EY
+	}
+	return nil
+}
+
+// A BROADCAST message sent during the only round of EdDSA signing with a presign.
+type SignRound1Message struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Z []byte `protobuf:"bytes,1,opt,name=z,proto3" json:"z,omitempty"`
+}
+
+func (x *SignRound1Message) Reset() {
+	*x = SignRound1Message{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_eddsa_presign_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SignRound1Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignRound1Message) ProtoMessage() {}
+
+func (x *SignRound1Message) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_eddsa_presign_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignRound1Message.ProtoReflect.Descriptor instead.
+func (*SignRound1Message) Descriptor() ([]byte, []int) {
+	return file_proto_eddsa_presign_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SignRound1Message) GetZ() []byte {
+	if x != nil {
+		return x.Z
+	}
+	return nil
+}
+
+var File_proto_eddsa_presign_proto protoreflect.FileDescriptor
+
+var file_proto_eddsa_presign_proto_rawDesc = []byte{
+	0x0a, 0x19, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x65, 0x64, 0x64, 0x73, 0x61, 0x2f, 0x70, 0x72,
+	0x65, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d, 0x65, 0x64, 0x64,
+	0x73, 0x61, 0x2e, 0x70, 0x72, 0x65, 0x73, 0x69, 0x67, 0x6e, 0x22, 0x5a, 0x0a, 0x14, 0x50, 0x72,
+	0x65, 0x73, 0x69, 0x67, 0x6e, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x31, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x0f, 0x0a, 0x03, 0x64, 0x5f, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x02, 0x64, 0x58, 0x12, 0x0f, 0x0a, 0x03, 0x64, 0x5f, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x02, 0x64, 0x59, 0x12, 0x0f, 0x0a, 0x03, 0x65, 0x5f, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x02, 0x65, 0x58, 0x12, 0x0f, 0x0a, 0x03, 0x65, 0x5f, 0x79, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x02, 0x65, 0x59, 0x22, 0x21, 0x0a, 0x11, 0x53, 0x69, 0x67, 0x6e, 0x52, 0x6f,
+	0x75, 0x6e, 0x64, 0x31, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x0c, 0x0a, 0x01, 0x7a,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x7a, 0x42, 0x2e, 0x5a, 0x2c, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x73, 0x69, 0x73, 0x75, 0x2d, 0x6e, 0x65, 0x74,
+	0x77, 0x6f, 0x72, 0x6b, 0x2f, 0x64, 0x68, 0x65, 0x61, 0x72, 0x74, 0x2f, 0x65, 0x64, 0x64, 0x73,
+	0x61, 0x2f, 0x70, 0x72, 0x65, 0x73, 0x69, 0x67, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_proto_eddsa_presign_proto_rawDescOnce sync.Once
+	file_proto_eddsa_presign_proto_rawDescData = file_proto_eddsa_presign_proto_rawDesc
+)
+
+func file_proto_eddsa_presign_proto_rawDescGZIP() []byte {
+	file_proto_eddsa_presign_proto_rawDescOnce.Do(func() {
+		file_proto_eddsa_presign_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_eddsa_presign_proto_rawDescData)
+	})
+	return file_proto_eddsa_presign_proto_rawDescData
+}
+
+var file_proto_eddsa_presign_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_eddsa_presign_proto_goTypes = []interface{}{
+	(*PresignRound1Message)(nil), // 0: eddsa.presign.PresignRound1Message
+	(*SignRound1Message)(nil),    // 1: eddsa.presign.SignRound1Message
+}
+var file_proto_eddsa_presign_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_proto_eddsa_presign_proto_init() }
+func file_proto_eddsa_presign_proto_init() {
+	if File_proto_eddsa_presign_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_proto_eddsa_presign_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PresignRound1Message); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_eddsa_presign_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SignRound1Message); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_eddsa_presign_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_eddsa_presign_proto_goTypes,
+		DependencyIndexes: file_proto_eddsa_presign_proto_depIdxs,
+		MessageInfos:      file_proto_eddsa_presign_proto_msgTypes,
+	}.Build()
+	File_proto_eddsa_presign_proto = out.File
+	file_proto_eddsa_presign_proto_rawDesc = nil
+	file_proto_eddsa_presign_proto_goTypes = nil
+	file_proto_eddsa_presign_proto_depIdxs = nil
+}
@@ -0,0 +1,42 @@
+package presign
+
+import (
+	"math/big"
+
+	"github.com/sisu-network/tss-lib/crypto"
+)
+
+// PresignData is the output of a presign work. It holds the nonces of this party and the nonce
+// points of all parties that made the presign. A presign can only be used once and only by the
+// same set of parties.
+type PresignData struct {
+	// Ks are the keys of the parties that made this presign, in the order of their indexes.
+	Ks []*big.Int
+
+	Di, Ei *big.Int
+
+	BigDs, BigEs []*crypto.ECPoint
+}
+
+func NewPresignData(partyCount int) *PresignData {
+	return &PresignData{
+		Ks:    make([]*big.Int, partyCount),
+		BigDs: make([]*crypto.ECPoint, partyCount),
+		BigEs: make([]*crypto.ECPoint, partyCount),
+	}
+}
+
+// IsMadeBy returns true if this presign was made by the parties with the given keys.
+func (data *PresignData) IsMadeBy(ks []*big.Int) bool {
+	if len(data.Ks) != len(ks) {
+		return false
+	}
+
+	for i := range ks {
+		if data.Ks[i] == nil || data.Ks[i].Cmp(ks[i]) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,71 @@
+package presign
+
+import (
+	"errors"
+
+	"github.com/sisu-network/tss-lib/common"
+	"github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+func newRound1(params *tss.Parameters, data *PresignData, temp *presignTempData, out chan<- tss.Message,
+	end chan<- *PresignData) tss.Round {
+	return &round1{
+		&presignBase{
+			&base{params, TaskName, out, make([]bool, len(params.Parties().IDs())), false, 1},
+			data, temp, end,
+		},
+	}
+}
+
+func (round *round1) Start() *tss.Error {
+	if round.started {
+		return round.WrapError(errors.New("round already started"))
+	}
+	round.number = 1
+	round.started = true
+	round.resetOK()
+
+	ec := tss.EC(tss.EddsaScheme)
+	Pi := round.PartyID()
+	i := Pi.Index
+
+	// 1. sample the two nonces of this presign
+	round.temp.di = common.GetRandomPositiveInt(ec.Params().N)
+	round.temp.ei = common.GetRandomPositiveInt(ec.Params().N)
+
+	// 2. BROADCAST the nonce points
+	bigDi := crypto.ScalarBaseMult(ec, round.temp.di)
+	bigEi := crypto.ScalarBaseMult(ec, round.temp.ei)
+	msg := NewPresignRound1Message(Pi, bigDi, bigEi)
+	round.temp.presignRound1Messages[i] = msg
+	round.out <- msg
+
+	return nil
+}
+
+func (round *round1) CanAccept(msg tss.ParsedMessage) bool {
+	if _, ok := msg.Content().(*PresignRound1Message); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+func (round *round1) Update() (bool, *tss.Error) {
+	for j, msg := range round.temp.presignRound1Messages {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !round.CanAccept(msg) {
+			return false, nil
+		}
+		// the nonce points are checked in round 2
+		round.ok[j] = true
+	}
+	return true, nil
+}
+
+func (round *round1) NextRound() tss.Round {
+	round.started = false
+	return &round2{round}
+}
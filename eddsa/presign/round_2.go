@@ -0,0 +1,69 @@
+package presign
+
+import (
+	"errors"
+
+	"github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+func (round *round2) Start() *tss.Error {
+	if round.started {
+		return round.WrapError(errors.New("round already started"))
+	}
+	round.number = 2
+	round.started = true
+	round.resetOK()
+
+	// 1. check and save the nonce points of all parties
+	culprits := make([]*tss.PartyID, 0)
+	for j, Pj := range round.Parties().IDs() {
+		round.ok[j] = true
+		r1msg := round.temp.presignRound1Messages[j].Content().(*PresignRound1Message)
+		bigDj, errD := r1msg.UnmarshalD()
+		bigEj, errE := r1msg.UnmarshalE()
+		if errD != nil || errE != nil {
+			culprits = append(culprits, Pj)
+			continue
+		}
+
+		// Clear the small order part of the points. A point of small order becomes the identity.
+		bigDj, bigEj = bigDj.EightInvEight(), bigEj.EightInvEight()
+		if isIdentity(bigDj) || isIdentity(bigEj) {
+			culprits = append(culprits, Pj)
+			continue
+		}
+
+		round.data.Ks[j] = Pj.KeyInt()
+		round.data.BigDs[j] = bigDj
+		round.data.BigEs[j] = bigEj
+	}
+	if len(culprits) > 0 {
+		return round.WrapError(errors.New("invalid nonce points"), culprits...)
+	}
+
+	round.data.Di = round.temp.di
+	round.data.Ei = round.temp.ei
+	round.end <- round.data
+
+	return nil
+}
+
+func (round *round2) CanAccept(msg tss.ParsedMessage) bool {
+	// not expecting any incoming messages in this round
+	return false
+}
+
+func (round *round2) Update() (bool, *tss.Error) {
+	// not expecting any incoming messages in this round
+	return false, nil
+}
+
+func (round *round2) NextRound() tss.Round {
+	return nil // finished!
+}
+
+// isIdentity returns true if p is the identity point (0, 1) of the Edwards curve.
+func isIdentity(p *crypto.ECPoint) bool {
+	return p.X().Sign() == 0
+}
@@ -0,0 +1,105 @@
+package presign
+
+import (
+	edkeygen "github.com/sisu-network/tss-lib/eddsa/keygen"
+	edsigning "github.com/sisu-network/tss-lib/eddsa/signing"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+const (
+	TaskName        = "eddsa-presign"
+	SigningTaskName = "eddsa-presign-signing"
+)
+
+type (
+	base struct {
+		*tss.Parameters
+		task    string
+		out     chan<- tss.Message
+		ok      []bool // `ok` tracks parties which have been verified by Update()
+		started bool
+		number  int
+	}
+
+	// Presign rounds
+	presignBase struct {
+		*base
+		data *PresignData
+		temp *presignTempData
+		end  chan<- *PresignData
+	}
+	round1 struct {
+		*presignBase
+	}
+	round2 struct {
+		*round1
+	}
+
+	// Signing rounds
+	signingBase struct {
+		*base
+		key     *edkeygen.LocalPartySaveData
+		presign *PresignData
+		data    *edsigning.SignatureData
+		temp    *signingTempData
+		end     chan<- *edsigning.SignatureData
+	}
+	signRound1 struct {
+		*signingBase
+	}
+	finalization struct {
+		*signRound1
+	}
+)
+
+var (
+	_ tss.Round = (*round1)(nil)
+	_ tss.Round = (*round2)(nil)
+	_ tss.Round = (*signRound1)(nil)
+	_ tss.Round = (*finalization)(nil)
+)
+
+func (round *base) Params() *tss.Parameters {
+	return round.Parameters
+}
+
+func (round *base) RoundNumber() int {
+	return round.number
+}
+
+// CanProceed is inherited by other rounds
+func (round *base) CanProceed() bool {
+	if !round.started {
+		return false
+	}
+	for _, ok := range round.ok {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// WaitingFor is called by a Party for reporting back to the caller
+func (round *base) WaitingFor() []*tss.PartyID {
+	Ps := round.Parties().IDs()
+	ids := make([]*tss.PartyID, 0, len(round.ok))
+	for j, ok := range round.ok {
+		if ok {
+			continue
+		}
+		ids = append(ids, Ps[j])
+	}
+	return ids
+}
+
+func (round *base) WrapError(err error, culprits ...*tss.PartyID) *tss.Error {
+	return tss.NewError(err, round.task, round.number, round.PartyID(), culprits...)
+}
+
+// `ok` tracks parties which have been verified by Update()
+func (round *base) resetOK() {
+	for j := range round.ok {
+		round.ok[j] = false
+	}
+}
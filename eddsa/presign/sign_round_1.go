@@ -0,0 +1,100 @@
+package presign
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sisu-network/tss-lib/common"
+	"github.com/sisu-network/tss-lib/crypto"
+	edkeygen "github.com/sisu-network/tss-lib/eddsa/keygen"
+	edsigning "github.com/sisu-network/tss-lib/eddsa/signing"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+func newSignRound1(params *tss.Parameters, key *edkeygen.LocalPartySaveData, presign *PresignData,
+	data *edsigning.SignatureData, temp *signingTempData, out chan<- tss.Message,
+	end chan<- *edsigning.SignatureData) tss.Round {
+	return &signRound1{
+		&signingBase{
+			&base{params, SigningTaskName, out, make([]bool, len(params.Parties().IDs())), false, 1},
+			key, presign, data, temp, end,
+		},
+	}
+}
+
+func (round *signRound1) Start() *tss.Error {
+	if round.started {
+		return round.WrapError(errors.New("round already started"))
+	}
+	round.number = 1
+	round.started = true
+	round.resetOK()
+
+	Pi := round.PartyID()
+	i := Pi.Index
+	ks := round.key.Ks
+	if round.presign == nil || !round.presign.IsMadeBy(ks) {
+		return round.WrapError(errors.New("the presign was not made by the parties of this signing"))
+	}
+
+	ec := tss.EC(tss.EddsaScheme)
+	modQ := common.ModInt(ec.Params().N)
+
+	// 1. compute the binding factors and the nonce point R = sum(Dj + rhoj * Ej)
+	rhos := bindingFactors(round.temp.m, round.presign)
+	var r *crypto.ECPoint
+	for j := range ks {
+		sum, err := round.presign.BigDs[j].Add(round.presign.BigEs[j].ScalarMult(rhos[j]))
+		if err == nil && r != nil {
+			sum, err = r.Add(sum)
+		}
+		if err != nil {
+			return round.WrapError(fmt.Errorf("cannot compute the nonce point: %w", err))
+		}
+		r = sum
+	}
+	round.temp.rhos = rhos
+	round.temp.r = r
+
+	// 2. compute the challenge c = H(R || A || M)
+	c := challenge(r, round.key.EDDSAPub, round.temp.m)
+	round.temp.c = c
+
+	// 3. compute our share zi = di + ei * rhoi + c * wi and BROADCAST it
+	wi := edsigning.PrepareForSigning(i, len(ks), round.key.Xi, ks)
+	zi := modQ.Add(round.presign.Di, modQ.Mul(round.presign.Ei, rhos[i]))
+	zi = modQ.Add(zi, modQ.Mul(c, wi))
+	round.temp.zi = zi
+
+	msg := NewSignRound1Message(Pi, zi)
+	round.temp.signRound1Messages[i] = msg
+	round.out <- msg
+
+	return nil
+}
+
+func (round *signRound1) CanAccept(msg tss.ParsedMessage) bool {
+	if _, ok := msg.Content().(*SignRound1Message); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+func (round *signRound1) Update() (bool, *tss.Error) {
+	for j, msg := range round.temp.signRound1Messages {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !round.CanAccept(msg) {
+			return false, nil
+		}
+		// the signature shares are checked in the finalization
+		round.ok[j] = true
+	}
+	return true, nil
+}
+
+func (round *signRound1) NextRound() tss.Round {
+	round.started = false
+	return &finalization{round}
+}
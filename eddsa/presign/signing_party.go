@@ -0,0 +1,137 @@
+package presign
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/sisu-network/tss-lib/common"
+	"github.com/sisu-network/tss-lib/crypto"
+	edkeygen "github.com/sisu-network/tss-lib/eddsa/keygen"
+	edsigning "github.com/sisu-network/tss-lib/eddsa/signing"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+// Implements Party
+// Implements Stringer
+var _ tss.Party = (*SigningParty)(nil)
+var _ fmt.Stringer = (*SigningParty)(nil)
+
+type (
+	// SigningParty is a party that signs a message with a presign. The parties of the signing must
+	// be the parties that made the presign.
+	SigningParty struct {
+		*tss.BaseParty
+		params *tss.Parameters
+
+		keys    edkeygen.LocalPartySaveData
+		presign *PresignData
+		temp    signingTempData
+		data    edsigning.SignatureData
+
+		// outbound messaging
+		out chan<- tss.Message
+		end chan<- *edsigning.SignatureData
+	}
+
+	signingMessageStore struct {
+		signRound1Messages []tss.ParsedMessage
+	}
+
+	signingTempData struct {
+		signingMessageStore
+
+		// round 1
+		m    []byte
+		rhos []*big.Int
+		r    *crypto.ECPoint
+		c    *big.Int
+		zi   *big.Int
+	}
+)
+
+// NewSigningParty creates a party that signs msg with its share of key and a presign. The message
+// is signed as is, like Ed25519 does.
+func NewSigningParty(
+	msg []byte,
+	params *tss.Parameters,
+	key edkeygen.LocalPartySaveData,
+	presign *PresignData,
+	out chan<- tss.Message,
+	end chan<- *edsigning.SignatureData,
+) tss.Party {
+	partyCount := len(params.Parties().IDs())
+	p := &SigningParty{
+		BaseParty: new(tss.BaseParty),
+		params:    params,
+		keys:      edkeygen.BuildLocalSaveDataSubset(key, params.Parties().IDs()),
+		presign:   presign,
+		temp:      signingTempData{},
+		data:      edsigning.SignatureData{},
+		out:       out,
+		end:       end,
+	}
+	// msgs init
+	p.temp.signRound1Messages = make([]tss.ParsedMessage, partyCount)
+	// temp data init
+	p.temp.m = msg
+	return p
+}
+
+func (p *SigningParty) FirstRound() tss.Round {
+	return newSignRound1(p.params, &p.keys, p.presign, &p.data, &p.temp, p.out, p.end)
+}
+
+func (p *SigningParty) Start() *tss.Error {
+	return tss.BaseStart(p, SigningTaskName)
+}
+
+func (p *SigningParty) Update(msg tss.ParsedMessage) (ok bool, err *tss.Error) {
+	return tss.BaseUpdate(p, msg, SigningTaskName)
+}
+
+func (p *SigningParty) UpdateFromBytes(wireBytes []byte, from *tss.PartyID, isBroadcast bool) (bool, *tss.Error) {
+	msg, err := tss.ParseWireMessage(wireBytes, from, isBroadcast)
+	if err != nil {
+		return false, p.WrapError(err)
+	}
+	return p.Update(msg)
+}
+
+func (p *SigningParty) ValidateMessage(msg tss.ParsedMessage) (bool, *tss.Error) {
+	if ok, err := p.BaseParty.ValidateMessage(msg); !ok || err != nil {
+		return ok, err
+	}
+	// check that the message's "from index" will fit into the array
+	if maxFromIdx := len(p.params.Parties().IDs()) - 1; maxFromIdx < msg.GetFrom().Index {
+		return false, p.WrapError(fmt.Errorf("received msg with a sender index too great (%d <= %d)",
+			maxFromIdx+1, msg.GetFrom().Index), msg.GetFrom())
+	}
+	return true, nil
+}
+
+func (p *SigningParty) StoreMessage(msg tss.ParsedMessage) (bool, *tss.Error) {
+	// ValidateBasic is cheap; double-check the message here in case the public StoreMessage was called externally
+	if ok, err := p.ValidateMessage(msg); !ok || err != nil {
+		return ok, err
+	}
+	fromPIdx := msg.GetFrom().Index
+
+	// switch/case is necessary to store any messages beyond current round
+	// this does not handle message replays. we expect the caller to apply replay and spoofing protection.
+	switch msg.Content().(type) {
+	case *SignRound1Message:
+		p.temp.signRound1Messages[fromPIdx] = msg
+	default: // unrecognised message, just ignore!
+		common.Logger.Warnf("unrecognised message ignored: %v", msg)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (p *SigningParty) PartyID() *tss.PartyID {
+	return p.params.PartyID()
+}
+
+func (p *SigningParty) String() string {
+	return fmt.Sprintf("id: %s, %s", p.PartyID(), p.BaseParty.String())
+}
@@ -5,13 +5,26 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/sisu-network/dheart/devnet"
 	"github.com/sisu-network/dheart/run"
+	"github.com/sisu-network/lib/log"
 )
 
 func main() {
-	run.Run()
+	if len(os.Args) > 1 && os.Args[1] == "devnet" {
+		if err := devnet.RunCommand(os.Args[2:]); err != nil {
+			log.Error("Devnet failed, err = ", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	stop := run.Run()
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	<-c
+
+	log.Info("Shutting down")
+	stop()
 }
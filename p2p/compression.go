@@ -0,0 +1,121 @@
+package p2p
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// CompressionType is the compression algorithm of a frame payload. It is stored in the highest 4
+// bits of the frame header.
+type CompressionType byte
+
+const (
+	CompressionNone   CompressionType = 0
+	CompressionSnappy CompressionType = 1
+	CompressionZstd   CompressionType = 2
+
+	// Payloads smaller than this size are always sent uncompressed.
+	MinCompressionSize = 1024
+)
+
+var (
+	// All compression types that this node can decompress.
+	supportedCompressions = []CompressionType{CompressionZstd, CompressionSnappy, CompressionNone}
+
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxPayload))
+)
+
+// ParseCompressionType converts a compression name in the config into a compression type. An empty
+// name means no compression.
+func ParseCompressionType(name string) (CompressionType, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return CompressionNone, nil
+	case "snappy":
+		return CompressionSnappy, nil
+	case "zstd":
+		return CompressionZstd, nil
+	default:
+		return CompressionNone, fmt.Errorf("unknown compression type %s", name)
+	}
+}
+
+func (c CompressionType) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionSnappy:
+		return "snappy"
+	case CompressionZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("unknown(%d)", c)
+	}
+}
+
+// getCompressedProtocolId returns the protocol id used to negotiate a compression type for a
+// protocol. Peers that do not support the compression type do not support the returned protocol
+// and the stream falls back to the uncompressed protocol.
+func getCompressedProtocolId(protocolId protocol.ID, compression CompressionType) protocol.ID {
+	if compression == CompressionNone {
+		return protocolId
+	}
+
+	return protocol.ID(fmt.Sprintf("%s/%s", protocolId, compression))
+}
+
+// parseProtocolId returns the base protocol and the compression type of a negotiated protocol id.
+func parseProtocolId(protocolId protocol.ID) (protocol.ID, CompressionType) {
+	for _, compression := range supportedCompressions {
+		if compression == CompressionNone {
+			continue
+		}
+
+		suffix := "/" + compression.String()
+		if strings.HasSuffix(string(protocolId), suffix) {
+			return protocol.ID(strings.TrimSuffix(string(protocolId), suffix)), compression
+		}
+	}
+
+	return protocolId, CompressionNone
+}
+
+func compress(compression CompressionType, msg []byte) ([]byte, error) {
+	switch compression {
+	case CompressionNone:
+		return msg, nil
+	case CompressionSnappy:
+		return snappy.Encode(nil, msg), nil
+	case CompressionZstd:
+		return zstdEncoder.EncodeAll(msg, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown compression type %d", compression)
+	}
+}
+
+func decompress(compression CompressionType, data []byte) ([]byte, error) {
+	switch compression {
+	case CompressionNone:
+		return data, nil
+	case CompressionSnappy:
+		length, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, err
+		}
+		if length > MaxPayload {
+			return nil, fmt.Errorf("decompressed length:%d exceed max payload length:%d", length, MaxPayload)
+		}
+
+		return snappy.Decode(nil, data)
+	case CompressionZstd:
+		// The decoder refuses to decode more than MaxPayload bytes.
+		return zstdDecoder.DecodeAll(data, nil)
+	default:
+		return nil, fmt.Errorf("unknown compression type %d", compression)
+	}
+}
@@ -0,0 +1,134 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/sisu-network/dheart/types/common"
+	"github.com/sisu-network/dheart/utils"
+	"github.com/sisu-network/tss-lib/crypto"
+	cmts "github.com/sisu-network/tss-lib/crypto/commitments"
+	"github.com/sisu-network/tss-lib/crypto/vss"
+	"github.com/sisu-network/tss-lib/ecdsa/keygen"
+	"github.com/sisu-network/tss-lib/tss"
+	"github.com/stretchr/testify/require"
+)
+
+// getKGRound2Payloads returns the serialized signed messages that a node sends in round 2 of an
+// ecdsa keygen with a committee of n nodes: one unicast KGRound2Message1 for every other node and
+// one broadcast KGRound2Message2.
+func getKGRound2Payloads(t testing.TB, n int) [][]byte {
+	pids := make([]*tss.PartyID, n)
+	indexes := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		pids[i] = tss.NewPartyID(fmt.Sprintf("node%d", i), "", big.NewInt(int64(i+1)))
+		indexes[i] = pids[i].KeyInt()
+	}
+
+	secret, err := rand.Int(rand.Reader, tss.EC(tss.EcdsaScheme).Params().N)
+	require.NoError(t, err)
+	vs, shares, err := vss.Create(tss.EcdsaScheme, utils.GetThreshold(n), secret, indexes)
+	require.NoError(t, err)
+	flat, err := crypto.FlattenECPoints(vs)
+	require.NoError(t, err)
+	cmt := cmts.NewHashCommitment(flat...)
+
+	msgs := []tss.Message{keygen.NewKGRound2Message2(pids[0], cmt.D)}
+	for i := 1; i < n; i++ {
+		msgs = append(msgs, keygen.NewKGRound2Message1(pids[i], pids[0], shares[i]))
+	}
+
+	payloads := make([][]byte, 0, len(msgs))
+	for _, msg := range msgs {
+		tssMsg, err := common.NewTssMessage(pids[0].Id, "", "keygen0", []tss.Message{msg}, msg.Type())
+		require.NoError(t, err)
+
+		bz, err := common.MarshalSignedMessage(&common.SignedMessage{
+			From:       pids[0].Id,
+			TssMessage: tssMsg,
+			Signature:  bytes.Repeat([]byte{1}, 64),
+		})
+		require.NoError(t, err)
+		payloads = append(payloads, bz)
+	}
+
+	return payloads
+}
+
+func TestCompression_ReadWriteFrame(t *testing.T) {
+	t.Parallel()
+
+	small := []byte("small message")
+	large := bytes.Repeat([]byte("large message "), 1000)
+
+	for _, compression := range supportedCompressions {
+		for _, msg := range [][]byte{small, large} {
+			buf := &bytes.Buffer{}
+			require.NoError(t, WriteStreamWithCompression(msg, buf, compression))
+
+			header := CompressionType(binary.LittleEndian.Uint32(buf.Bytes()[:HeaderLength]) >> headerCompressionShift)
+			if len(msg) < MinCompressionSize {
+				require.Equal(t, CompressionNone, header)
+			} else {
+				require.Equal(t, compression, header)
+			}
+
+			read, err := ReadStreamWithBuffer(buf)
+			require.NoError(t, err)
+			require.Equal(t, msg, read)
+		}
+	}
+}
+
+func TestCompression_ProtocolId(t *testing.T) {
+	t.Parallel()
+
+	for _, compression := range supportedCompressions {
+		protocolId := getCompressedProtocolId(TSSProtocolID, compression)
+		base, parsed := parseProtocolId(protocolId)
+		require.Equal(t, TSSProtocolID, base)
+		require.Equal(t, compression, parsed)
+	}
+
+	_, err := ParseCompressionType("gzip")
+	require.Error(t, err)
+}
+
+func BenchmarkCompression_KGRound2Message(b *testing.B) {
+	payloads := getKGRound2Payloads(b, 30)
+	total := 0
+	for _, payload := range payloads {
+		total += len(payload)
+	}
+
+	for _, compression := range supportedCompressions {
+		b.Run(compression.String(), func(b *testing.B) {
+			compressed := 0
+			for _, payload := range payloads {
+				bz, err := compress(compression, payload)
+				require.NoError(b, err)
+				compressed += len(bz)
+			}
+
+			b.ReportAllocs()
+			b.SetBytes(int64(total))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, payload := range payloads {
+					bz, err := compress(compression, payload)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := decompress(compression, bz); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			b.ReportMetric(float64(compressed)/float64(total), "ratio")
+		})
+	}
+}
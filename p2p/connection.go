@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
@@ -20,41 +21,108 @@ type Connection struct {
 
 	streams map[protocol.ID]network.Stream
 	lock    sync.RWMutex
-	status  int
+
+	// The compression type this node prefers to use with the peer.
+	compression CompressionType
+	// The compression type agreed with the peer for each stream.
+	streamCompressions map[protocol.ID]CompressionType
 }
 
-func NewConnection(pID peer.ID, addr maddr.Multiaddr, host *host.Host) *Connection {
+func NewConnection(pID peer.ID, addr maddr.Multiaddr, host *host.Host, compression CompressionType) *Connection {
 	return &Connection{
-		peerId:  pID,
-		addr:    addr,
-		host:    host,
-		streams: make(map[protocol.ID]network.Stream),
+		peerId:             pID,
+		addr:               addr,
+		host:               host,
+		streams:            make(map[protocol.ID]network.Stream),
+		compression:        compression,
+		streamCompressions: make(map[protocol.ID]CompressionType),
 	}
 }
 
+// ReleaseStream resets all the cached streams of this connection. New streams are created on the
+// next write.
 func (con *Connection) ReleaseStream() {
-	for _, stream := range con.streams {
+	con.lock.Lock()
+	defer con.lock.Unlock()
+
+	for protocolId, stream := range con.streams {
 		if stream != nil {
 			stream.Reset()
 		}
+		delete(con.streams, protocolId)
+		delete(con.streamCompressions, protocolId)
+	}
+}
+
+// CloseStreams closes all the cached streams of this connection. Unlike ReleaseStream, the messages
+// written to the streams are still delivered.
+func (con *Connection) CloseStreams() {
+	con.lock.Lock()
+	defer con.lock.Unlock()
+
+	for protocolId, stream := range con.streams {
+		if stream != nil {
+			stream.Close()
+		}
+		delete(con.streams, protocolId)
+		delete(con.streamCompressions, protocolId)
+	}
+}
+
+// resetStream resets a broken stream and removes it from the cache. The caller must hold the lock.
+func (con *Connection) resetStream(protocolId protocol.ID) {
+	if stream := con.streams[protocolId]; stream != nil {
+		stream.Reset()
 	}
+	delete(con.streams, protocolId)
+	delete(con.streamCompressions, protocolId)
 }
 
+// createStream opens a new stream for a protocol. The compressed variant of the protocol is offered
+// first so that the stream uses compression only if the peer supports it.
 func (con *Connection) createStream(protocolId protocol.ID) (network.Stream, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), TimeoutConnecting)
 	defer cancel()
-	stream, err := (*con.host).NewStream(ctx, con.peerId, protocolId)
+
+	protocolIds := []protocol.ID{protocolId}
+	if con.compression != CompressionNone {
+		protocolIds = append([]protocol.ID{getCompressedProtocolId(protocolId, con.compression)}, protocolIds...)
+	}
+	stream, err := (*con.host).NewStream(ctx, con.peerId, protocolIds...)
 
 	if err != nil {
 		return nil, fmt.Errorf("fail to create new stream to peer: %s, %w", con.peerId, err)
 	}
 
+	_, compression := parseProtocolId(stream.Protocol())
+	log.Verbosef("Created stream %s with peer %s, compression = %s", protocolId, con.peerId, compression)
+
 	con.streams[protocolId] = stream
+	con.streamCompressions[protocolId] = compression
 
 	return stream, nil
 }
 
+// writeToStream writes a message to the cached stream of a protocol. If the cached stream is
+// broken, it is reset and the message is written once more to a new stream.
 func (con *Connection) writeToStream(msg []byte, protocolId protocol.ID) error {
+	con.lock.Lock()
+	defer con.lock.Unlock()
+
+	_, cached := con.streams[protocolId]
+	err := con.write(msg, protocolId)
+	if err == nil || !cached {
+		return err
+	}
+
+	log.Warnf("Failed to write to stream %s of peer %s, retrying with a new stream, err = %v",
+		protocolId, con.peerId, err)
+	return con.write(msg, protocolId)
+}
+
+// write writes a message to the stream of a protocol, creating the stream if needed. The stream is
+// reset if the write fails. The caller must hold the lock.
+func (con *Connection) write(msg []byte, protocolId protocol.ID) error {
 	stream := con.streams[protocolId]
 	if stream == nil {
 		var err error
@@ -65,8 +133,11 @@ func (con *Connection) writeToStream(msg []byte, protocolId protocol.ID) error {
 		}
 	}
 
-	con.lock.Lock()
-	defer con.lock.Unlock()
+	stream.SetWriteDeadline(time.Now().Add(TimeoutWritePayload))
+	if err := WriteStreamWithCompression(msg, stream, con.streamCompressions[protocolId]); err != nil {
+		con.resetStream(protocolId)
+		return err
+	}
 
-	return WriteStreamWithBuffer(msg, stream)
+	return nil
 }
@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
@@ -19,7 +20,9 @@ import (
 	"github.com/sisu-network/lib/log"
 	"go.uber.org/atomic"
 
+	"github.com/sisu-network/dheart/blame"
 	types "github.com/sisu-network/dheart/p2p/types"
+	"github.com/sisu-network/dheart/types/common"
 )
 
 const (
@@ -27,6 +30,10 @@ const (
 	PingProtocolID    protocol.ID = "/p2p-ping"
 	KEY_secp256k1                 = "secp256k1"
 	TimeoutConnecting             = time.Second * 20
+
+	// The maximum time Start waits for all peers to connect before the node becomes ready. Peers
+	// that are still not connected keep being reconnected in the background.
+	TimeoutInitialConnections = time.Second * 150
 )
 
 type P2PDataListener interface {
@@ -44,6 +51,22 @@ type ConnectionManager interface {
 	AddListener(protocol protocol.ID, listener P2PDataListener)
 
 	IsReady() bool
+
+	// Returns the connection state of every peer, sorted by peer id.
+	GetPeerStatuses() []PeerStatus
+
+	// Adds a new peer and connects to it.
+	AddPeer(peer *types.Peer) error
+
+	// Disconnects from a peer and removes it.
+	RemovePeer(pID peer.ID) error
+
+	// Returns true if a peer is known to be unreachable because it does not answer pings, or if it
+	// has recently misbehaved too often (e.g. by exceeding its rate limits).
+	IsPeerDown(pID peer.ID) bool
+
+	// Tells the peers that this node is leaving, then closes all streams and connections.
+	Stop() error
 }
 
 // DefaultConnectionManager implements ConnectionManager interface.
@@ -55,18 +78,37 @@ type DefaultConnectionManager struct {
 	rendezvous       string
 	bootstrapPeers   []maddr.Multiaddr
 	connections      map[peer.ID]*Connection
+	connLock         *sync.RWMutex
+	supervisor       *connectionSupervisor
+	pinger           *pingService
+	startLock        *sync.RWMutex // Guards supervisor and pinger which are set by Start.
+	rateLimiter      *rateLimiter
+	inbound          *inboundPool
+	reputation       *blame.Reputation
+	ctx              context.Context
+	cancel           context.CancelFunc
 	listenerLock     sync.RWMutex
 	protocolListener map[protocol.ID]P2PDataListener
 	ready            *atomic.Bool
+	compression      CompressionType
 }
 
 func NewConnectionManager(config types.ConnectionsConfig) ConnectionManager {
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &DefaultConnectionManager{
 		config:           config,
 		rendezvous:       config.Rendezvous,
 		connections:      make(map[peer.ID]*Connection),
+		connLock:         &sync.RWMutex{},
+		startLock:        &sync.RWMutex{},
 		protocolListener: make(map[protocol.ID]P2PDataListener),
 		ready:            atomic.NewBool(false),
+		rateLimiter:      newRateLimiter(config.Inbound.RateLimits),
+		inbound:          newInboundPool(config.Inbound.Workers, config.Inbound.QueueSize),
+		reputation:       blame.NewReputation(),
+		ctx:              ctx,
+		cancel:           cancel,
 	}
 }
 
@@ -74,7 +116,7 @@ func (cm *DefaultConnectionManager) Start(privKeyBytes []byte, keyType string) e
 	log.Info("Starting connection manager. Config =", cm.connections)
 	log.Info("keyType = ", keyType)
 
-	ctx := context.Background()
+	ctx := cm.ctx
 	var p2pPriKey crypto.PrivKey
 	var err error
 	switch keyType {
@@ -88,6 +130,11 @@ func (cm *DefaultConnectionManager) Start(privKeyBytes []byte, keyType string) e
 		return err
 	}
 
+	cm.compression, err = ParseCompressionType(cm.config.Compression)
+	if err != nil {
+		return err
+	}
+
 	selfUrl := fmt.Sprintf("/ip4/%s/tcp/%d", cm.config.Host, cm.config.Port)
 	log.Info("selfUrl = ", selfUrl)
 
@@ -108,9 +155,20 @@ func (cm *DefaultConnectionManager) Start(privKeyBytes []byte, keyType string) e
 		cm.bootstrapPeers[i] = peer
 	}
 
+	// Creates connection objects. The connections are the peer table which the connection gater uses
+	// to refuse unknown peers, they must be created before the host starts listening.
+	cm.connLock.Lock()
+	for _, peerAddr := range cm.bootstrapPeers {
+		addrInfo, _ := peer.AddrInfoFromP2pAddr(peerAddr)
+		conn := NewConnection(addrInfo.ID, peerAddr, &cm.host, cm.compression)
+		cm.connections[addrInfo.ID] = conn
+	}
+	cm.connLock.Unlock()
+
 	host, err := libp2p.New(
 		libp2p.ListenAddrs([]maddr.Multiaddr{listenAddr}...),
 		libp2p.Identity(p2pPriKey),
+		libp2p.ConnectionGater(newPeerGater(cm.isKnownPeer)),
 	)
 	if err != nil {
 		log.Critical("Failed to get Peer ID from private key. err = ", err)
@@ -120,9 +178,22 @@ func (cm *DefaultConnectionManager) Start(privKeyBytes []byte, keyType string) e
 
 	log.Info("My address = ", host.Addrs(), host.ID())
 
-	// Set stream handlers
-	host.SetStreamHandler(TSSProtocolID, cm.handleStream)
-	host.SetStreamHandler(PingProtocolID, cm.handleStream)
+	cm.inbound.start(ctx)
+
+	// Answers pings of other peers and measures the liveness of our peers.
+	pinger := newPingService(cm, cm.getPeerIds)
+	cm.startLock.Lock()
+	cm.pinger = pinger
+	cm.startLock.Unlock()
+	cm.AddListener(PingProtocolID, pinger)
+
+	// Set stream handlers. We accept all the compression types for every protocol, the peer that
+	// opens the stream chooses one of them.
+	for _, protocolId := range []protocol.ID{TSSProtocolID, PingProtocolID} {
+		for _, compression := range supportedCompressions {
+			host.SetStreamHandler(getCompressedProtocolId(protocolId, compression), cm.handleStream)
+		}
+	}
 
 	// Advertise this node and discover other nodes
 	if err := cm.discover(ctx, host); err != nil {
@@ -131,15 +202,87 @@ func (cm *DefaultConnectionManager) Start(privKeyBytes []byte, keyType string) e
 	}
 
 	// Connect to predefined peers.
+	go pinger.run(ctx)
 	cm.createConnections(ctx)
 
 	return nil
 }
 
+func (cm *DefaultConnectionManager) Stop() error {
+	// Stop delivering inbound messages, pinging and reconnecting to peers. Peers that ping this node
+	// do not get answers anymore.
+	cm.cancel()
+	cm.ready.Store(false)
+
+	pinger := cm.getPinger()
+	if pinger == nil {
+		return nil
+	}
+
+	log.Info("Stopping connection manager")
+
+	// Tell the peers that we are leaving so that they do not select us for new works.
+	pinger.leave()
+
+	cm.connLock.RLock()
+	for _, conn := range cm.connections {
+		conn.CloseStreams()
+	}
+	cm.connLock.RUnlock()
+
+	return cm.host.Close()
+}
+
 func (cm *DefaultConnectionManager) IsReady() bool {
 	return cm.ready.Load()
 }
 
+func (cm *DefaultConnectionManager) GetPeerStatuses() []PeerStatus {
+	supervisor := cm.getSupervisor()
+	if supervisor == nil {
+		return nil
+	}
+
+	pinger := cm.getPinger()
+	statuses := supervisor.getPeerStatuses()
+	for i := range statuses {
+		pingStatus := pinger.getStatus(statuses[i].PeerId)
+		statuses[i].Healthy = pingStatus.Healthy
+		statuses[i].Rtt = pingStatus.Rtt
+		statuses[i].Offenses = cm.reputation.GetOffenseCount(statuses[i].PeerId.String())
+	}
+
+	return statuses
+}
+
+func (cm *DefaultConnectionManager) IsPeerDown(pID peer.ID) bool {
+	// A misbehaving peer is treated as down so that it is not selected for new works.
+	if cm.reputation.IsMisbehaving(pID.String()) {
+		return true
+	}
+
+	pinger := cm.getPinger()
+	if pinger == nil {
+		return false
+	}
+
+	return !pinger.getStatus(pID).Healthy
+}
+
+func (cm *DefaultConnectionManager) getSupervisor() *connectionSupervisor {
+	cm.startLock.RLock()
+	defer cm.startLock.RUnlock()
+
+	return cm.supervisor
+}
+
+func (cm *DefaultConnectionManager) getPinger() *pingService {
+	cm.startLock.RLock()
+	defer cm.startLock.RUnlock()
+
+	return cm.pinger
+}
+
 func (cm *DefaultConnectionManager) AddListener(protocol protocol.ID, listener P2PDataListener) {
 	cm.listenerLock.Lock()
 	defer cm.listenerLock.Unlock()
@@ -165,16 +308,21 @@ func (cm *DefaultConnectionManager) getListener(protocol protocol.ID) P2PDataLis
 }
 
 func (cm *DefaultConnectionManager) handleStream(stream network.Stream) {
-	peerIDString := stream.Conn().RemotePeer().String()
-	protocol := stream.Protocol()
+	pID := stream.Conn().RemotePeer()
+	peerIDString := pID.String()
+	// The payload of every frame is decompressed when it is read so listeners only care about the
+	// base protocol.
+	protocol, _ := parseProtocolId(stream.Protocol())
+	reader := bufio.NewReader(stream)
 
 	for {
 		// TODO: Add cancel channel here.
-		dataBuf, err := ReadStreamWithBuffer(stream)
+		dataBuf, err := ReadStreamWithBuffer(reader)
 
 		if err != nil {
-			log.Warn(err)
-			// TODO: handle retry here.
+			// The stream is broken or closed by the peer. The peer opens a new stream on its next write.
+			log.Warnf("Failed to read from stream %s of peer %s, err = %v", protocol, peerIDString, err)
+			stream.Reset()
 			return
 		}
 		if dataBuf != nil {
@@ -184,16 +332,38 @@ func (cm *DefaultConnectionManager) handleStream(stream network.Stream) {
 				return
 			}
 
-			go func(peerIDString string, dataBuf []byte) {
-				listener.OnNetworkMessage(&types.P2PMessage{
-					FromPeerId: peerIDString,
-					Data:       dataBuf,
-				})
-			}(peerIDString, dataBuf)
+			msgType := getMessageType(protocol, dataBuf)
+			if !cm.rateLimiter.allow(pID, msgType) {
+				log.Verbosef("Peer %s exceeds the rate limit of %s messages, dropping the message", peerIDString, msgType)
+				cm.reputation.ReportOffense(peerIDString, blame.OffenseRateLimited)
+				continue
+			}
+
+			if !cm.inbound.submit(cm.ctx, listener, &types.P2PMessage{
+				FromPeerId: peerIDString,
+				Data:       dataBuf,
+			}) {
+				stream.Reset()
+				return
+			}
 		}
 	}
 }
 
+// getMessageType returns the type of a message used for rate limiting.
+func getMessageType(protocolId protocol.ID, data []byte) string {
+	if protocolId == PingProtocolID {
+		return MessageTypePing
+	}
+
+	msgType, err := common.GetSignedMessageType(data)
+	if err != nil {
+		return MessageTypeDefault
+	}
+
+	return msgType.String()
+}
+
 func (cm *DefaultConnectionManager) discover(ctx context.Context, host host.Host) error {
 	kademliaDHT, err := dht.New(ctx, host)
 	if err != nil {
@@ -212,42 +382,115 @@ func (cm *DefaultConnectionManager) discover(ctx context.Context, host host.Host
 }
 
 func (cm *DefaultConnectionManager) createConnections(ctx context.Context) {
-	// Creates connection objects.
+	// Broken streams of a disconnected peer are reset so that new streams are created once the peer
+	// is reconnected.
+	supervisor := newConnectionSupervisor(ctx, cm.host, func(pID peer.ID) {
+		if conn := cm.getConnection(pID); conn != nil {
+			conn.ReleaseStream()
+		}
+	})
+	cm.startLock.Lock()
+	cm.supervisor = supervisor
+	cm.startLock.Unlock()
+
+	// Attempts to connect to every bootstrapped peers. The supervisor keeps reconnecting to peers
+	// that are disconnected.
+	log.Info("Trying to create connections with peers...")
 	for _, peerAddr := range cm.bootstrapPeers {
 		addrInfo, _ := peer.AddrInfoFromP2pAddr(peerAddr)
-		conn := NewConnection(addrInfo.ID, peerAddr, &cm.host)
-		cm.connections[addrInfo.ID] = conn
+		supervisor.addPeer(*addrInfo)
 	}
 
-	// Attempts to connect to every bootstrapped peers.
-	log.Info("Trying to create connections with peers...")
-	wg := &sync.WaitGroup{}
-	for _, peerAddr := range cm.bootstrapPeers {
-		peerinfo, _ := peer.AddrInfoFromP2pAddr(peerAddr)
-		wg.Add(1)
-
-		go func() {
-			defer wg.Done()
-
-			// Retry 50 times at max.
-			for i := 0; i < 50; i++ {
-				if err := cm.host.Connect(ctx, *peerinfo); err != nil {
-					log.Warn(fmt.Sprintf("Error while connecting to node %q: %-v", peerinfo, err))
-					time.Sleep(time.Second * 3)
-				} else {
-					log.Infof("%s Connection established with bootstrap node: %q", cm.myNetworkId, *peerinfo)
-					break
-				}
-			}
+	deadline := time.Now().Add(TimeoutInitialConnections)
+	for !supervisor.allConnected() && time.Now().Before(deadline) && ctx.Err() == nil {
+		time.Sleep(time.Millisecond * 200)
+	}
+	if !supervisor.allConnected() {
+		log.Warn("Not all peers are connected, continue reconnecting in the background")
+	}
+
+	cm.ready.Store(true)
+}
+
+func (cm *DefaultConnectionManager) getConnection(pID peer.ID) *Connection {
+	cm.connLock.RLock()
+	defer cm.connLock.RUnlock()
+
+	return cm.connections[pID]
+}
+
+// isKnownPeer returns true if a peer is in the peer table of this node.
+func (cm *DefaultConnectionManager) isKnownPeer(pID peer.ID) bool {
+	return cm.getConnection(pID) != nil
+}
+
+func (cm *DefaultConnectionManager) getPeerIds() []peer.ID {
+	cm.connLock.RLock()
+	defer cm.connLock.RUnlock()
 
-			cm.ready.Store(true)
-		}()
+	pIDs := make([]peer.ID, 0, len(cm.connections))
+	for pID := range cm.connections {
+		pIDs = append(pIDs, pID)
 	}
-	wg.Wait()
+
+	return pIDs
 }
 
-func (cm *DefaultConnectionManager) WriteToStream(pID peer.ID, protocolId protocol.ID, msg []byte) error {
+func (cm *DefaultConnectionManager) AddPeer(p *types.Peer) error {
+	addrInfo, err := GetPeerAddrInfo(p)
+	if err != nil {
+		return err
+	}
+
+	supervisor := cm.getSupervisor()
+	if supervisor == nil {
+		return ErrCmNotStarted
+	}
+
+	// The address is validated above.
+	addr, _ := maddr.NewMultiaddr(p.Address)
+
+	cm.connLock.Lock()
+	if cm.connections[addrInfo.ID] != nil {
+		cm.connLock.Unlock()
+		return fmt.Errorf("%w: %s", ErrPeerExisted, addrInfo.ID)
+	}
+	cm.connections[addrInfo.ID] = NewConnection(addrInfo.ID, addr, &cm.host, cm.compression)
+	cm.connLock.Unlock()
+
+	log.Infof("Adding peer %s", addrInfo.ID)
+	supervisor.addPeer(*addrInfo)
+
+	return nil
+}
+
+func (cm *DefaultConnectionManager) RemovePeer(pID peer.ID) error {
+	supervisor := cm.getSupervisor()
+	if supervisor == nil {
+		return ErrCmNotStarted
+	}
+
+	cm.connLock.Lock()
 	conn := cm.connections[pID]
+	delete(cm.connections, pID)
+	cm.connLock.Unlock()
+
+	if conn == nil {
+		return fmt.Errorf("%w: %s", ErrPeerNotFound, pID)
+	}
+
+	log.Infof("Removing peer %s", pID)
+	supervisor.removePeer(pID)
+	cm.getPinger().removePeer(pID)
+	cm.rateLimiter.removePeer(pID)
+	cm.reputation.RemovePeer(pID.String())
+	conn.ReleaseStream()
+
+	return cm.host.Network().ClosePeer(pID)
+}
+
+func (cm *DefaultConnectionManager) WriteToStream(pID peer.ID, protocolId protocol.ID, msg []byte) error {
+	conn := cm.getConnection(pID)
 	if conn == nil {
 		log.Error("Connection to pid not found, pid = ", pID)
 		return errors.New("pID not found")
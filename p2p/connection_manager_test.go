@@ -0,0 +1,71 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sisu-network/dheart/blame"
+	types "github.com/sisu-network/dheart/p2p/types"
+)
+
+func TestConnectionManager_StatusesWhileStarting(t *testing.T) {
+	t.Parallel()
+
+	// The peer is unreachable so Start keeps waiting for it.
+	unreachable, pID := generatePeer(t, crypto.Ed25519, "ed25519")
+	cm := NewConnectionManager(types.ConnectionsConfig{
+		Host:  "127.0.0.1",
+		Port:  0,
+		Peers: []*types.Peer{unreachable},
+	})
+	require.Nil(t, cm.GetPeerStatuses())
+	require.ErrorIs(t, cm.RemovePeer(pID), ErrCmNotStarted)
+
+	privKey, _, err := crypto.GenerateKeyPairWithReader(crypto.Ed25519, 256, rand.Reader)
+	require.NoError(t, err)
+	privBz, err := privKey.Raw()
+	require.NoError(t, err)
+
+	started := make(chan error, 1)
+	go func() {
+		started <- cm.Start(privBz, "ed25519")
+	}()
+
+	// The peers can be read and changed while the node is connecting to its peers.
+	require.Eventually(t, func() bool {
+		return len(cm.GetPeerStatuses()) == 1 && !cm.IsPeerDown(pID)
+	}, 10*time.Second, 10*time.Millisecond)
+	require.False(t, cm.IsReady())
+
+	other, otherID := generatePeer(t, crypto.Ed25519, "ed25519")
+	require.NoError(t, cm.AddPeer(other))
+	require.Len(t, cm.GetPeerStatuses(), 2)
+	require.NoError(t, cm.RemovePeer(otherID))
+
+	// Stopping the node ends the wait for the peers.
+	require.NoError(t, cm.Stop())
+	select {
+	case err := <-started:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Start does not return after Stop")
+	}
+}
+
+func TestConnectionManager_MisbehavingPeerIsDown(t *testing.T) {
+	t.Parallel()
+
+	_, pID := generatePeer(t, crypto.Ed25519, "ed25519")
+	cm := NewConnectionManager(types.ConnectionsConfig{}).(*DefaultConnectionManager)
+	require.False(t, cm.IsPeerDown(pID))
+
+	// The peer keeps exceeding its rate limit.
+	for !cm.reputation.IsMisbehaving(pID.String()) {
+		cm.reputation.ReportOffense(pID.String(), blame.OffenseRateLimited)
+	}
+	require.True(t, cm.IsPeerDown(pID))
+}
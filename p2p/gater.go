@@ -0,0 +1,51 @@
+package p2p
+
+import (
+	"github.com/libp2p/go-libp2p/core/connmgr"
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/sisu-network/lib/log"
+)
+
+// peerGater is a libp2p connection gater that only allows connections with known peers. Connections
+// of other peers are closed right after the security handshake, before any stream is accepted.
+type peerGater struct {
+	isAllowed func(pID peer.ID) bool
+}
+
+var _ connmgr.ConnectionGater = (*peerGater)(nil)
+
+func newPeerGater(isAllowed func(pID peer.ID) bool) *peerGater {
+	return &peerGater{
+		isAllowed: isAllowed,
+	}
+}
+
+func (g *peerGater) InterceptPeerDial(pID peer.ID) bool {
+	return g.isAllowed(pID)
+}
+
+func (g *peerGater) InterceptAddrDial(pID peer.ID, addr maddr.Multiaddr) bool {
+	return g.isAllowed(pID)
+}
+
+// InterceptAccept allows all inbound connections since the peer id is not known before the security
+// handshake.
+func (g *peerGater) InterceptAccept(addrs network.ConnMultiaddrs) bool {
+	return true
+}
+
+func (g *peerGater) InterceptSecured(dir network.Direction, pID peer.ID, addrs network.ConnMultiaddrs) bool {
+	if !g.isAllowed(pID) {
+		log.Verbosef("Refused connection with unknown peer %s, address = %s", pID, addrs.RemoteMultiaddr())
+		return false
+	}
+
+	return true
+}
+
+func (g *peerGater) InterceptUpgraded(conn network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
@@ -0,0 +1,48 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerGater(t *testing.T) {
+	t.Parallel()
+
+	allowed := newTestHost(t)
+	stranger := newTestHost(t)
+
+	h, err := libp2p.New(
+		libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"),
+		libp2p.ConnectionGater(newPeerGater(func(pID peer.ID) bool {
+			return pID == allowed.ID()
+		})),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { h.Close() })
+
+	connect := func(from host.Host) error {
+		return from.Connect(context.Background(), peer.AddrInfo{ID: h.ID(), Addrs: h.Addrs()})
+	}
+
+	// Inbound connections.
+	require.NoError(t, connect(allowed))
+	// The stranger's dial can finish its side of the handshake before the connection is closed.
+	connect(stranger)
+	require.Eventually(t, func() bool {
+		return stranger.Network().Connectedness(h.ID()) != network.Connected
+	}, time.Second*5, time.Millisecond*10)
+	require.NotEqual(t, network.Connected, h.Network().Connectedness(stranger.ID()))
+	_, err = stranger.NewStream(context.Background(), h.ID(), TSSProtocolID)
+	require.Error(t, err)
+
+	// Outbound connections.
+	require.Error(t, h.Connect(context.Background(), peer.AddrInfo{ID: stranger.ID(), Addrs: stranger.Addrs()}))
+	require.Equal(t, network.Connected, h.Network().Connectedness(allowed.ID()))
+}
@@ -6,8 +6,6 @@ import (
 	"fmt"
 	"io"
 	"time"
-
-	"github.com/libp2p/go-libp2p/core/network"
 )
 
 const (
@@ -15,16 +13,29 @@ const (
 	TimeoutReadPayload  = time.Second * 10
 	TimeoutWritePayload = time.Second * 10
 	MaxPayload          = 20000000 // 20M
+
+	// The frame header is a 4-byte little endian integer. The lowest 28 bits are the length of the
+	// payload and the highest 4 bits are the compression type of the payload.
+	headerLengthMask       = 0x0FFFFFFF
+	headerCompressionShift = 28
 )
 
-func ReadStreamWithBuffer(stream network.Stream) ([]byte, error) {
-	streamReader := bufio.NewReader(stream)
+// ReadStreamWithBuffer reads a frame from a stream and returns its decompressed payload. Callers
+// that read several frames should pass the same *bufio.Reader every time so that bytes buffered
+// after a frame are not lost.
+func ReadStreamWithBuffer(stream io.Reader) ([]byte, error) {
+	streamReader, ok := stream.(*bufio.Reader)
+	if !ok {
+		streamReader = bufio.NewReader(stream)
+	}
 	lengthBytes := make([]byte, HeaderLength)
 	n, err := io.ReadFull(streamReader, lengthBytes)
 	if n != HeaderLength || err != nil {
 		return nil, fmt.Errorf("error in read the message head %w", err)
 	}
-	length := binary.LittleEndian.Uint32(lengthBytes)
+	header := binary.LittleEndian.Uint32(lengthBytes)
+	length := header & headerLengthMask
+	compression := CompressionType(header >> headerCompressionShift)
 	if length > MaxPayload {
 		return nil, fmt.Errorf("payload length:%d exceed max payload length:%d", length, MaxPayload)
 	}
@@ -33,20 +44,51 @@ func ReadStreamWithBuffer(stream network.Stream) ([]byte, error) {
 	if uint32(n) != length || err != nil {
 		return nil, fmt.Errorf("short read err(%w), we would like to read: %d, however we only read: %d", err, length, n)
 	}
-	return dataBuf, nil
+
+	decompressed, err := decompress(compression, dataBuf)
+	if err != nil {
+		return nil, fmt.Errorf("fail to decompress payload with %s: %w", compression, err)
+	}
+
+	return decompressed, nil
+}
+
+// WriteStreamWithBuffer writes an uncompressed frame to a stream.
+func WriteStreamWithBuffer(msg []byte, stream io.Writer) error {
+	return WriteStreamWithCompression(msg, stream, CompressionNone)
 }
 
-func WriteStreamWithBuffer(msg []byte, stream network.Stream) error {
-	length := uint32(len(msg))
+// WriteStreamWithCompression writes a frame to a stream. The payload is compressed if it is large
+// enough and the compression makes it smaller. The compression type of the payload is marked in
+// the frame header.
+func WriteStreamWithCompression(msg []byte, stream io.Writer, compression CompressionType) error {
+	if len(msg) < MinCompressionSize {
+		compression = CompressionNone
+	}
+
+	payload, err := compress(compression, msg)
+	if err != nil {
+		return err
+	}
+	if len(payload) >= len(msg) {
+		// Compression does not help, send the raw message instead.
+		payload = msg
+		compression = CompressionNone
+	}
+
+	length := uint32(len(payload))
+	if length > MaxPayload {
+		return fmt.Errorf("payload length:%d exceed max payload length:%d", length, MaxPayload)
+	}
 	lengthBytes := make([]byte, HeaderLength)
-	binary.LittleEndian.PutUint32(lengthBytes, length)
+	binary.LittleEndian.PutUint32(lengthBytes, length|uint32(compression)<<headerCompressionShift)
 
 	streamWrite := bufio.NewWriter(stream)
 	n, err := streamWrite.Write(lengthBytes)
 	if n != HeaderLength || err != nil {
 		return fmt.Errorf("fail to write head: %w", err)
 	}
-	n, err = streamWrite.Write(msg)
+	n, err = streamWrite.Write(payload)
 	if err != nil {
 		return err
 	}
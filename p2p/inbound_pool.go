@@ -0,0 +1,63 @@
+package p2p
+
+import (
+	"context"
+
+	types "github.com/sisu-network/dheart/p2p/types"
+)
+
+const (
+	DefaultInboundWorkers   = 32
+	DefaultInboundQueueSize = 1024
+)
+
+type inboundMessage struct {
+	listener P2PDataListener
+	message  *types.P2PMessage
+}
+
+// inboundPool delivers inbound messages to their listeners with a fixed number of goroutines.
+type inboundPool struct {
+	queue   chan *inboundMessage
+	workers int
+}
+
+func newInboundPool(workers, queueSize int) *inboundPool {
+	if workers <= 0 {
+		workers = DefaultInboundWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultInboundQueueSize
+	}
+
+	return &inboundPool{
+		queue:   make(chan *inboundMessage, queueSize),
+		workers: workers,
+	}
+}
+
+func (p *inboundPool) start(ctx context.Context) {
+	for i := 0; i < p.workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg := <-p.queue:
+					msg.listener.OnNetworkMessage(msg.message)
+				}
+			}
+		}()
+	}
+}
+
+// submit queues a message for the workers. It blocks while the queue is full so that reading from
+// the stream pauses and the peer is slowed down by the stream's flow control.
+func (p *inboundPool) submit(ctx context.Context, listener P2PDataListener, message *types.P2PMessage) bool {
+	select {
+	case p.queue <- &inboundMessage{listener: listener, message: message}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,454 @@
+package p2p
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/sisu-network/lib/log"
+	"go.uber.org/atomic"
+
+	types "github.com/sisu-network/dheart/p2p/types"
+)
+
+var (
+	ErrPeerUnreachable = errors.New("peer is unreachable")
+)
+
+// LoopbackConfig describes the behavior of the links of a loopback network.
+type LoopbackConfig struct {
+	// The seed of the random decisions of every link. Networks with the same seed drop, delay and
+	// reorder the n-th message of a link in the same way.
+	Seed int64
+	// The delay of every message.
+	Latency time.Duration
+	// A random delay in [0, Jitter) added to the latency of every message.
+	Jitter time.Duration
+	// The probability in [0, 1] that a message does not wait for the messages sent before it and
+	// is delayed by an extra random time up to Latency + Jitter.
+	ReorderRate float64
+	// The probability in [0, 1] that a message is dropped.
+	LossRate float64
+}
+
+// LoopbackNetwork connects loopback connection managers in the same process. It is used to simulate
+// a network of many nodes in tests without libp2p.
+type LoopbackNetwork struct {
+	config LoopbackConfig
+
+	nodes map[peer.ID]*LoopbackConnectionManager
+	links map[string]*loopbackLink
+	// Nodes can only reach nodes in the same partition group. All nodes are in group 0 by default.
+	partitions map[peer.ID]int
+	lock       *sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewLoopbackNetwork(config LoopbackConfig) *LoopbackNetwork {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &LoopbackNetwork{
+		config:     config,
+		nodes:      make(map[peer.ID]*LoopbackConnectionManager),
+		links:      make(map[string]*loopbackLink),
+		partitions: make(map[peer.ID]int),
+		lock:       &sync.RWMutex{},
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// NewConnectionManager creates a connection manager on this network. The node joins the network
+// when the connection manager starts.
+func (n *LoopbackNetwork) NewConnectionManager(config types.ConnectionsConfig) ConnectionManager {
+	return &LoopbackConnectionManager{
+		network:          n,
+		config:           config,
+		peers:            make(map[peer.ID]bool),
+		protocolListener: make(map[protocol.ID]P2PDataListener),
+		lock:             &sync.RWMutex{},
+		ready:            atomic.NewBool(false),
+	}
+}
+
+// Partition splits the network into groups. Nodes can only reach nodes in the same group. Nodes that
+// are not in any group form a group together.
+func (n *LoopbackNetwork) Partition(groups ...[]peer.ID) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.partitions = make(map[peer.ID]int)
+	for i, group := range groups {
+		for _, pID := range group {
+			n.partitions[pID] = i + 1
+		}
+	}
+}
+
+// Heal removes all the partitions.
+func (n *LoopbackNetwork) Heal() {
+	n.Partition()
+}
+
+// Close stops delivering messages.
+func (n *LoopbackNetwork) Close() {
+	n.cancel()
+}
+
+func (n *LoopbackNetwork) join(pID peer.ID, cm *LoopbackConnectionManager) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.nodes[pID] != nil {
+		return fmt.Errorf("node %s has joined the network", pID)
+	}
+	n.nodes[pID] = cm
+
+	return nil
+}
+
+func (n *LoopbackNetwork) leave(pID peer.ID) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	delete(n.nodes, pID)
+}
+
+// getReachableNode returns the node of a peer if it is reachable from another node.
+func (n *LoopbackNetwork) getReachableNode(from, to peer.ID) *LoopbackConnectionManager {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	if n.partitions[from] != n.partitions[to] {
+		return nil
+	}
+
+	return n.nodes[to]
+}
+
+func (n *LoopbackNetwork) getLink(from, to peer.ID) *loopbackLink {
+	key := fmt.Sprintf("%s->%s", from, to)
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	link := n.links[key]
+	if link == nil {
+		hash := fnv.New64a()
+		hash.Write([]byte(key))
+
+		link = newLoopbackLink(n, from, to, rand.New(rand.NewSource(n.config.Seed^int64(hash.Sum64()))))
+		n.links[key] = link
+		go link.run(n.ctx)
+	}
+
+	return link
+}
+
+// loopbackMessage is a message in flight on a link.
+type loopbackMessage struct {
+	protocolId protocol.ID
+	data       []byte
+	deliverAt  time.Time
+	seq        uint64
+}
+
+type loopbackMessageQueue []*loopbackMessage
+
+func (q loopbackMessageQueue) Len() int { return len(q) }
+func (q loopbackMessageQueue) Less(i, j int) bool {
+	if q[i].deliverAt.Equal(q[j].deliverAt) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].deliverAt.Before(q[j].deliverAt)
+}
+func (q loopbackMessageQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *loopbackMessageQueue) Push(x interface{}) { *q = append(*q, x.(*loopbackMessage)) }
+func (q *loopbackMessageQueue) Pop() interface{} {
+	old := *q
+	msg := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return msg
+}
+
+// loopbackLink delivers the messages from a node to another node in the order of their delivery
+// time. Messages are delivered one by one like they are read from a stream.
+type loopbackLink struct {
+	network  *LoopbackNetwork
+	from, to peer.ID
+	rand     *rand.Rand
+
+	queue         loopbackMessageQueue
+	seq           uint64
+	lastDeliverAt time.Time
+	lock          *sync.Mutex
+	notify        chan struct{}
+}
+
+func newLoopbackLink(network *LoopbackNetwork, from, to peer.ID, rand *rand.Rand) *loopbackLink {
+	return &loopbackLink{
+		network: network,
+		from:    from,
+		to:      to,
+		rand:    rand,
+		lock:    &sync.Mutex{},
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+// send schedules a message. It returns false if the message is dropped.
+func (l *loopbackLink) send(protocolId protocol.ID, data []byte) bool {
+	cfg := l.network.config
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	// Always draw the same random numbers for every message so that the decisions of a message do not
+	// depend on the decisions of the messages before it.
+	lossRoll := l.rand.Float64()
+	reorderRoll := l.rand.Float64()
+	jitterRoll := l.rand.Float64()
+	reorderDelayRoll := l.rand.Float64()
+
+	if lossRoll < cfg.LossRate {
+		return false
+	}
+
+	deliverAt := time.Now().Add(cfg.Latency + time.Duration(jitterRoll*float64(cfg.Jitter)))
+	if reorderRoll < cfg.ReorderRate {
+		deliverAt = deliverAt.Add(time.Duration(reorderDelayRoll * float64(cfg.Latency+cfg.Jitter)))
+	} else if deliverAt.Before(l.lastDeliverAt) {
+		// Keep the order of the messages.
+		deliverAt = l.lastDeliverAt
+	}
+	if deliverAt.After(l.lastDeliverAt) {
+		l.lastDeliverAt = deliverAt
+	}
+
+	l.seq++
+	heap.Push(&l.queue, &loopbackMessage{
+		protocolId: protocolId,
+		data:       data,
+		deliverAt:  deliverAt,
+		seq:        l.seq,
+	})
+
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+
+	return true
+}
+
+func (l *loopbackLink) run(ctx context.Context) {
+	for {
+		l.lock.Lock()
+		var wait <-chan time.Time
+		var next *loopbackMessage
+		if len(l.queue) > 0 {
+			if d := time.Until(l.queue[0].deliverAt); d > 0 {
+				wait = time.After(d)
+			} else {
+				next = heap.Pop(&l.queue).(*loopbackMessage)
+			}
+		}
+		l.lock.Unlock()
+
+		if next != nil {
+			l.deliver(next)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.notify:
+		case <-wait:
+		}
+	}
+}
+
+func (l *loopbackLink) deliver(msg *loopbackMessage) {
+	// The partition can change while the message is in flight.
+	node := l.network.getReachableNode(l.from, l.to)
+	if node == nil {
+		return
+	}
+
+	listener := node.getListener(msg.protocolId)
+	if listener == nil {
+		return
+	}
+
+	listener.OnNetworkMessage(&types.P2PMessage{
+		FromPeerId: l.from.String(),
+		Data:       msg.data,
+	})
+}
+
+// LoopbackConnectionManager implements ConnectionManager on a loopback network.
+type LoopbackConnectionManager struct {
+	network *LoopbackNetwork
+	config  types.ConnectionsConfig
+	myId    peer.ID
+
+	peers            map[peer.ID]bool
+	protocolListener map[protocol.ID]P2PDataListener
+	lock             *sync.RWMutex
+
+	ready *atomic.Bool
+}
+
+func (cm *LoopbackConnectionManager) Start(privKeyBytes []byte, keyType string) error {
+	var privKey crypto.PrivKey
+	var err error
+	switch keyType {
+	case "secp256k1":
+		privKey, err = crypto.UnmarshalSecp256k1PrivateKey(privKeyBytes)
+	case "ed25519":
+		privKey, err = crypto.UnmarshalEd25519PrivateKey(privKeyBytes)
+	default:
+		err = fmt.Errorf("unsupported key type %s", keyType)
+	}
+	if err != nil {
+		return err
+	}
+
+	cm.myId, err = peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range cm.config.Peers {
+		if err := cm.AddPeer(p); err != nil {
+			return err
+		}
+	}
+
+	if err := cm.network.join(cm.myId, cm); err != nil {
+		return err
+	}
+
+	log.Infof("Loopback node %s joined the network", cm.myId)
+	cm.ready.Store(true)
+
+	return nil
+}
+
+func (cm *LoopbackConnectionManager) WriteToStream(pID peer.ID, protocolId protocol.ID, msg []byte) error {
+	cm.lock.RLock()
+	known := cm.peers[pID]
+	cm.lock.RUnlock()
+
+	if !known {
+		return fmt.Errorf("%w: %s", ErrPeerNotFound, pID)
+	}
+	if cm.network.getReachableNode(cm.myId, pID) == nil {
+		return fmt.Errorf("%w: %s", ErrPeerUnreachable, pID)
+	}
+
+	// Copy the message since the caller can reuse it.
+	data := make([]byte, len(msg))
+	copy(data, msg)
+
+	if !cm.network.getLink(cm.myId, pID).send(protocolId, data) {
+		log.Verbosef("Loopback network dropped a message from %s to %s", cm.myId, pID)
+	}
+
+	return nil
+}
+
+func (cm *LoopbackConnectionManager) AddListener(protocol protocol.ID, listener P2PDataListener) {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	cm.protocolListener[protocol] = listener
+}
+
+func (cm *LoopbackConnectionManager) getListener(protocol protocol.ID) P2PDataListener {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	return cm.protocolListener[protocol]
+}
+
+func (cm *LoopbackConnectionManager) IsReady() bool {
+	return cm.ready.Load()
+}
+
+func (cm *LoopbackConnectionManager) GetPeerStatuses() []PeerStatus {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	statuses := make([]PeerStatus, 0, len(cm.peers))
+	for pID := range cm.peers {
+		status := PeerStatus{PeerId: pID, State: PeerDisconnected}
+		if cm.network.getReachableNode(cm.myId, pID) != nil {
+			status.State = PeerConnected
+			status.Healthy = true
+			status.Rtt = 2 * cm.network.config.Latency
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].PeerId < statuses[j].PeerId
+	})
+
+	return statuses
+}
+
+func (cm *LoopbackConnectionManager) AddPeer(p *types.Peer) error {
+	addrInfo, err := GetPeerAddrInfo(p)
+	if err != nil {
+		return err
+	}
+
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	if cm.peers[addrInfo.ID] {
+		return fmt.Errorf("%w: %s", ErrPeerExisted, addrInfo.ID)
+	}
+	cm.peers[addrInfo.ID] = true
+
+	return nil
+}
+
+func (cm *LoopbackConnectionManager) RemovePeer(pID peer.ID) error {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	if !cm.peers[pID] {
+		return fmt.Errorf("%w: %s", ErrPeerNotFound, pID)
+	}
+	delete(cm.peers, pID)
+
+	return nil
+}
+
+// IsPeerDown returns true if the peer has not joined the network or is in another partition.
+func (cm *LoopbackConnectionManager) IsPeerDown(pID peer.ID) bool {
+	return cm.network.getReachableNode(cm.myId, pID) == nil
+}
+
+// Stop leaves the network. The other nodes see this node as down.
+func (cm *LoopbackConnectionManager) Stop() error {
+	cm.ready.Store(false)
+	cm.network.leave(cm.myId)
+
+	log.Infof("Loopback node %s left the network", cm.myId)
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	types "github.com/sisu-network/dheart/p2p/types"
+)
+
+type loopbackNode struct {
+	privKey []byte
+	peer    *types.Peer
+	pID     peer.ID
+}
+
+// recordingListener keeps the sequence numbers of the messages it receives.
+type recordingListener struct {
+	received []uint64
+	lock     *sync.Mutex
+}
+
+func (l *recordingListener) OnNetworkMessage(message *types.P2PMessage) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.received = append(l.received, binary.BigEndian.Uint64(message.Data))
+}
+
+func (l *recordingListener) getReceived() []uint64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return append([]uint64{}, l.received...)
+}
+
+func generateLoopbackNodes(t *testing.T, n int) []*loopbackNode {
+	nodes := make([]*loopbackNode, n)
+	for i := range nodes {
+		privKey, pubKey, err := crypto.GenerateKeyPairWithReader(crypto.Ed25519, 256, rand.Reader)
+		require.NoError(t, err)
+		privBz, err := privKey.Raw()
+		require.NoError(t, err)
+		pubBz, err := pubKey.Raw()
+		require.NoError(t, err)
+		pID, err := peer.IDFromPublicKey(pubKey)
+		require.NoError(t, err)
+
+		nodes[i] = &loopbackNode{
+			privKey: privBz,
+			peer: &types.Peer{
+				Address:    fmt.Sprintf("/ip4/127.0.0.1/tcp/28300/p2p/%s", pID),
+				PubKey:     hex.EncodeToString(pubBz),
+				PubKeyType: "ed25519",
+			},
+			pID: pID,
+		}
+	}
+
+	return nodes
+}
+
+// startLoopbackNetwork starts a fully connected network of the nodes.
+func startLoopbackNetwork(t *testing.T, config LoopbackConfig, nodes []*loopbackNode) (
+	*LoopbackNetwork, []ConnectionManager, []*recordingListener) {
+	network := NewLoopbackNetwork(config)
+	t.Cleanup(network.Close)
+
+	cms := make([]ConnectionManager, len(nodes))
+	listeners := make([]*recordingListener, len(nodes))
+	for i, node := range nodes {
+		peers := make([]*types.Peer, 0)
+		for j, other := range nodes {
+			if i != j {
+				peers = append(peers, other.peer)
+			}
+		}
+
+		cms[i] = network.NewConnectionManager(types.ConnectionsConfig{Peers: peers})
+		listeners[i] = &recordingListener{lock: &sync.Mutex{}}
+		cms[i].AddListener(TSSProtocolID, listeners[i])
+		require.NoError(t, cms[i].Start(node.privKey, "ed25519"))
+	}
+
+	return network, cms, listeners
+}
+
+func sendSequence(t *testing.T, cm ConnectionManager, pID peer.ID, count int) {
+	for i := 0; i < count; i++ {
+		msg := make([]byte, 8)
+		binary.BigEndian.PutUint64(msg, uint64(i))
+		require.NoError(t, cm.WriteToStream(pID, TSSProtocolID, msg))
+	}
+}
+
+func TestLoopback_InOrder(t *testing.T) {
+	t.Parallel()
+
+	nodes := generateLoopbackNodes(t, 2)
+	_, cms, listeners := startLoopbackNetwork(t, LoopbackConfig{
+		Seed:    1,
+		Latency: 5 * time.Millisecond,
+		Jitter:  5 * time.Millisecond,
+	}, nodes)
+
+	sendSequence(t, cms[0], nodes[1].pID, 100)
+	require.Eventually(t, func() bool {
+		return len(listeners[1].getReceived()) == 100
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The jitter does not change the order of the messages.
+	for i, seq := range listeners[1].getReceived() {
+		require.Equal(t, uint64(i), seq)
+	}
+	require.Empty(t, listeners[0].getReceived())
+}
+
+func TestLoopback_LossIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	nodes := generateLoopbackNodes(t, 2)
+	config := LoopbackConfig{Seed: 42, LossRate: 0.5}
+
+	run := func() []uint64 {
+		network, cms, listeners := startLoopbackNetwork(t, config, nodes)
+		sendSequence(t, cms[0], nodes[1].pID, 200)
+		// Messages without latency are delivered right away, wait for the link to drain.
+		time.Sleep(200 * time.Millisecond)
+		network.Close()
+
+		return listeners[1].getReceived()
+	}
+
+	received := run()
+	require.Greater(t, len(received), 0)
+	require.Less(t, len(received), 200)
+	require.Equal(t, received, run())
+}
+
+func TestLoopback_Reorder(t *testing.T) {
+	t.Parallel()
+
+	nodes := generateLoopbackNodes(t, 2)
+	_, cms, listeners := startLoopbackNetwork(t, LoopbackConfig{
+		Seed:        7,
+		Latency:     10 * time.Millisecond,
+		ReorderRate: 0.5,
+	}, nodes)
+
+	sendSequence(t, cms[0], nodes[1].pID, 100)
+	require.Eventually(t, func() bool {
+		return len(listeners[1].getReceived()) == 100
+	}, 5*time.Second, 10*time.Millisecond)
+
+	reordered := false
+	received := listeners[1].getReceived()
+	for i := 1; i < len(received); i++ {
+		if received[i] < received[i-1] {
+			reordered = true
+		}
+	}
+	require.True(t, reordered)
+}
+
+func TestLoopback_Partition(t *testing.T) {
+	t.Parallel()
+
+	nodes := generateLoopbackNodes(t, 3)
+	network, cms, listeners := startLoopbackNetwork(t, LoopbackConfig{Seed: 3}, nodes)
+	for _, cm := range cms {
+		require.True(t, cm.IsReady())
+	}
+
+	network.Partition([]peer.ID{nodes[0].pID})
+	require.True(t, cms[0].IsPeerDown(nodes[1].pID))
+	require.True(t, cms[1].IsPeerDown(nodes[0].pID))
+	require.False(t, cms[1].IsPeerDown(nodes[2].pID))
+	require.ErrorIs(t, cms[0].WriteToStream(nodes[1].pID, TSSProtocolID, make([]byte, 8)), ErrPeerUnreachable)
+
+	statuses := cms[1].GetPeerStatuses()
+	require.Len(t, statuses, 2)
+	for _, status := range statuses {
+		require.Equal(t, status.PeerId != nodes[0].pID, status.Healthy)
+	}
+
+	network.Heal()
+	require.False(t, cms[0].IsPeerDown(nodes[1].pID))
+	sendSequence(t, cms[0], nodes[1].pID, 1)
+	require.Eventually(t, func() bool {
+		return len(listeners[1].getReceived()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Messages are only sent to known peers.
+	require.NoError(t, cms[0].RemovePeer(nodes[1].pID))
+	require.ErrorIs(t, cms[0].WriteToStream(nodes[1].pID, TSSProtocolID, make([]byte, 8)), ErrPeerNotFound)
+}
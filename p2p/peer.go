@@ -0,0 +1,69 @@
+package p2p
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+
+	types "github.com/sisu-network/dheart/p2p/types"
+)
+
+var (
+	ErrInvalidPeer    = errors.New("invalid peer")
+	ErrPeerIdMismatch = errors.New("peer id of the address does not match the pubkey")
+	ErrPeerNotFound   = errors.New("peer not found")
+	ErrPeerExisted    = errors.New("peer existed")
+	ErrCmNotStarted   = errors.New("connection manager is not started")
+)
+
+// GetPeerId returns the libp2p peer id of a hex encoded pubkey.
+func GetPeerId(pubKeyHex string, pubKeyType string) (peer.ID, error) {
+	bz, err := hex.DecodeString(pubKeyHex)
+	if err != nil {
+		return "", fmt.Errorf("%w: cannot decode pubkey: %v", ErrInvalidPeer, err)
+	}
+
+	var pubKey crypto.PubKey
+	switch pubKeyType {
+	case "secp256k1":
+		pubKey, err = crypto.UnmarshalSecp256k1PublicKey(bz)
+	case "ed25519":
+		pubKey, err = crypto.UnmarshalEd25519PublicKey(bz)
+	default:
+		return "", fmt.Errorf("%w: unsupported pubkey type %s", ErrInvalidPeer, pubKeyType)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: cannot unmarshal pubkey: %v", ErrInvalidPeer, err)
+	}
+
+	return peer.IDFromPublicKey(pubKey)
+}
+
+// GetPeerAddrInfo parses the address of a peer and checks that the peer id in the address belongs
+// to the peer's pubkey.
+func GetPeerAddrInfo(p *types.Peer) (*peer.AddrInfo, error) {
+	addr, err := maddr.NewMultiaddr(p.Address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot parse address %s: %v", ErrInvalidPeer, p.Address, err)
+	}
+
+	addrInfo, err := peer.AddrInfoFromP2pAddr(addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: address %s has no peer id: %v", ErrInvalidPeer, p.Address, err)
+	}
+
+	pID, err := GetPeerId(p.PubKey, p.PubKeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	if addrInfo.ID != pID {
+		return nil, fmt.Errorf("%w: address peer id = %s, pubkey peer id = %s", ErrPeerIdMismatch, addrInfo.ID, pID)
+	}
+
+	return addrInfo, nil
+}
@@ -0,0 +1,57 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	types "github.com/sisu-network/dheart/p2p/types"
+)
+
+func generatePeer(t *testing.T, keyType int, pubKeyType string) (*types.Peer, peer.ID) {
+	_, pubKey, err := crypto.GenerateKeyPairWithReader(keyType, 256, rand.Reader)
+	require.NoError(t, err)
+	bz, err := pubKey.Raw()
+	require.NoError(t, err)
+	pID, err := peer.IDFromPublicKey(pubKey)
+	require.NoError(t, err)
+
+	return &types.Peer{
+		Address:    fmt.Sprintf("/ip4/127.0.0.1/tcp/28300/p2p/%s", pID),
+		PubKey:     hex.EncodeToString(bz),
+		PubKeyType: pubKeyType,
+	}, pID
+}
+
+func TestGetPeerAddrInfo(t *testing.T) {
+	t.Parallel()
+
+	for keyType, pubKeyType := range map[int]string{crypto.Secp256k1: "secp256k1", crypto.Ed25519: "ed25519"} {
+		p, pID := generatePeer(t, keyType, pubKeyType)
+		addrInfo, err := GetPeerAddrInfo(p)
+		require.NoError(t, err)
+		require.Equal(t, pID, addrInfo.ID)
+	}
+
+	// The peer id of the address belongs to another key.
+	p1, _ := generatePeer(t, crypto.Secp256k1, "secp256k1")
+	_, pID2 := generatePeer(t, crypto.Secp256k1, "secp256k1")
+	p1.Address = fmt.Sprintf("/ip4/127.0.0.1/tcp/28300/p2p/%s", pID2)
+	_, err := GetPeerAddrInfo(p1)
+	require.ErrorIs(t, err, ErrPeerIdMismatch)
+
+	// The address has no peer id.
+	p1.Address = "/ip4/127.0.0.1/tcp/28300"
+	_, err = GetPeerAddrInfo(p1)
+	require.ErrorIs(t, err, ErrInvalidPeer)
+
+	// Wrong pubkey type.
+	p3, _ := generatePeer(t, crypto.Secp256k1, "sr25519")
+	_, err = GetPeerAddrInfo(p3)
+	require.ErrorIs(t, err, ErrInvalidPeer)
+}
@@ -0,0 +1,246 @@
+package p2p
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/sisu-network/lib/log"
+
+	types "github.com/sisu-network/dheart/p2p/types"
+)
+
+const (
+	PingInterval = time.Second * 5
+	// A peer is unhealthy if it does not answer this many pings in a row.
+	MaxMissedPings = 3
+
+	pingTypePing      byte = 1
+	pingTypePong      byte = 2
+	pingTypeLeave     byte = 3 // the sender is shutting down
+	pingMessageLength      = 9 // 1 byte type + 8 bytes nonce
+)
+
+// PingStatus is the liveness of a peer measured by the ping service.
+type PingStatus struct {
+	Healthy bool
+	// The round trip time of the last answered ping.
+	Rtt time.Duration
+	// The number of pings in a row that the peer has not answered.
+	MissedPings int
+}
+
+type pingState struct {
+	status PingStatus
+	// The nonce and sending time of the last ping that has not been answered. The nonce is 0 if the
+	// last ping was answered.
+	nonce    uint64
+	sentTime time.Time
+}
+
+type streamWriter interface {
+	WriteToStream(pID peer.ID, protocolId protocol.ID, msg []byte) error
+}
+
+// pingService sends a ping to every peer periodically on PingProtocolID and answers the pings of
+// other peers. A peer becomes unhealthy after MaxMissedPings unanswered pings and healthy again
+// once it answers a ping.
+type pingService struct {
+	writer   streamWriter
+	getPeers func() []peer.ID
+
+	peers map[peer.ID]*pingState
+	lock  *sync.RWMutex
+
+	interval  time.Duration
+	maxMissed int
+}
+
+func newPingService(writer streamWriter, getPeers func() []peer.ID) *pingService {
+	return &pingService{
+		writer:    writer,
+		getPeers:  getPeers,
+		peers:     make(map[peer.ID]*pingState),
+		lock:      &sync.RWMutex{},
+		interval:  PingInterval,
+		maxMissed: MaxMissedPings,
+	}
+}
+
+func (ps *pingService) run(ctx context.Context) {
+	ticker := time.NewTicker(ps.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ps.tick()
+		}
+	}
+}
+
+// tick counts the unanswered pings and sends a new ping to every peer.
+func (ps *pingService) tick() {
+	for _, pID := range ps.getPeers() {
+		nonce := ps.nextPing(pID)
+
+		msg := newPingMessage(pingTypePing, nonce)
+		if err := ps.writer.WriteToStream(pID, PingProtocolID, msg); err != nil {
+			// The ping stays unanswered and is counted as missed at the next tick.
+			log.Verbosef("Failed to ping peer %s, err = %v", pID, err)
+		}
+	}
+}
+
+// nextPing updates the missed ping count of a peer and returns the nonce of the next ping.
+func (ps *pingService) nextPing(pID peer.ID) uint64 {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	state := ps.peers[pID]
+	if state == nil {
+		state = &pingState{status: PingStatus{Healthy: true}}
+		ps.peers[pID] = state
+	}
+
+	if state.nonce != 0 {
+		state.status.MissedPings++
+		if state.status.Healthy && state.status.MissedPings >= ps.maxMissed {
+			log.Warnf("Peer %s missed %d pings, marking it as unhealthy", pID, state.status.MissedPings)
+			state.status.Healthy = false
+		}
+	}
+
+	state.nonce = newPingNonce()
+	state.sentTime = time.Now()
+
+	return state.nonce
+}
+
+// OnNetworkMessage implements P2PDataListener interface.
+func (ps *pingService) OnNetworkMessage(message *types.P2PMessage) {
+	if len(message.Data) != pingMessageLength {
+		log.Warnf("Invalid ping message length %d from %s", len(message.Data), message.FromPeerId)
+		return
+	}
+
+	pID, err := peer.Decode(message.FromPeerId)
+	if err != nil {
+		log.Warn("Invalid ping sender ", message.FromPeerId)
+		return
+	}
+
+	nonce := binary.BigEndian.Uint64(message.Data[1:])
+	switch message.Data[0] {
+	case pingTypePing:
+		if err := ps.writer.WriteToStream(pID, PingProtocolID, newPingMessage(pingTypePong, nonce)); err != nil {
+			log.Verbosef("Failed to answer ping of peer %s, err = %v", pID, err)
+		}
+	case pingTypePong:
+		ps.onPong(pID, nonce)
+	case pingTypeLeave:
+		ps.onLeave(pID)
+	default:
+		log.Warnf("Invalid ping message type %d from %s", message.Data[0], pID)
+	}
+}
+
+func (ps *pingService) onPong(pID peer.ID, nonce uint64) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	state := ps.peers[pID]
+	if state == nil || state.nonce == 0 || state.nonce != nonce {
+		// An old or unknown pong.
+		return
+	}
+
+	if !state.status.Healthy {
+		log.Infof("Peer %s answers ping again, marking it as healthy", pID)
+	}
+	state.status = PingStatus{
+		Healthy: true,
+		Rtt:     time.Since(state.sentTime),
+	}
+	state.nonce = 0
+}
+
+// onLeave marks a peer that is shutting down as unhealthy so that it is not selected for new works.
+// The peer becomes healthy again once it answers a ping.
+func (ps *pingService) onLeave(pID peer.ID) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	log.Infof("Peer %s is leaving, marking it as unhealthy", pID)
+	ps.peers[pID] = &pingState{
+		status: PingStatus{
+			Healthy:     false,
+			MissedPings: ps.maxMissed,
+		},
+	}
+}
+
+// leave tells every peer that this node is shutting down.
+func (ps *pingService) leave() {
+	wg := &sync.WaitGroup{}
+	for _, pID := range ps.getPeers() {
+		wg.Add(1)
+		go func(pID peer.ID) {
+			defer wg.Done()
+
+			if err := ps.writer.WriteToStream(pID, PingProtocolID, newPingMessage(pingTypeLeave, 0)); err != nil {
+				log.Verbosef("Failed to tell peer %s that we are leaving, err = %v", pID, err)
+			}
+		}(pID)
+	}
+	wg.Wait()
+}
+
+// getStatus returns the ping status of a peer. A peer that has not been pinged is healthy.
+func (ps *pingService) getStatus(pID peer.ID) PingStatus {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	state := ps.peers[pID]
+	if state == nil {
+		return PingStatus{Healthy: true}
+	}
+
+	return state.status
+}
+
+func (ps *pingService) removePeer(pID peer.ID) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	delete(ps.peers, pID)
+}
+
+func newPingMessage(msgType byte, nonce uint64) []byte {
+	msg := make([]byte, pingMessageLength)
+	msg[0] = msgType
+	binary.BigEndian.PutUint64(msg[1:], nonce)
+
+	return msg
+}
+
+func newPingNonce() uint64 {
+	bz := make([]byte, 8)
+	for {
+		if _, err := rand.Read(bz); err != nil {
+			// This should not happen. Fall back to the current time which is unique enough for pings.
+			return uint64(time.Now().UnixNano())
+		}
+
+		// 0 means no outstanding ping.
+		if nonce := binary.BigEndian.Uint64(bz); nonce != 0 {
+			return nonce
+		}
+	}
+}
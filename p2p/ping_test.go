@@ -0,0 +1,124 @@
+package p2p
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/stretchr/testify/require"
+
+	types "github.com/sisu-network/dheart/p2p/types"
+)
+
+// pingNetwork delivers messages between ping services synchronously. Links can be cut to simulate
+// unreachable peers.
+type pingNetwork struct {
+	services map[peer.ID]*pingService
+	down     map[peer.ID]bool
+	lock     *sync.RWMutex
+}
+
+type pingNetworkWriter struct {
+	network *pingNetwork
+	from    peer.ID
+}
+
+func (w *pingNetworkWriter) WriteToStream(pID peer.ID, protocolId protocol.ID, msg []byte) error {
+	w.network.lock.RLock()
+	service := w.network.services[pID]
+	down := w.network.down[pID] || w.network.down[w.from]
+	w.network.lock.RUnlock()
+
+	if service == nil || down {
+		return errors.New("peer is unreachable")
+	}
+
+	service.OnNetworkMessage(&types.P2PMessage{FromPeerId: w.from.String(), Data: msg})
+	return nil
+}
+
+func (n *pingNetwork) setDown(pID peer.ID, down bool) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.down[pID] = down
+}
+
+func TestPingService(t *testing.T) {
+	t.Parallel()
+
+	_, pID1 := generatePeer(t, crypto.Ed25519, "ed25519")
+	_, pID2 := generatePeer(t, crypto.Ed25519, "ed25519")
+
+	network := &pingNetwork{
+		services: make(map[peer.ID]*pingService),
+		down:     make(map[peer.ID]bool),
+		lock:     &sync.RWMutex{},
+	}
+	ps1 := newPingService(&pingNetworkWriter{network, pID1}, func() []peer.ID { return []peer.ID{pID2} })
+	ps2 := newPingService(&pingNetworkWriter{network, pID2}, func() []peer.ID { return []peer.ID{pID1} })
+	network.services[pID1] = ps1
+	network.services[pID2] = ps2
+
+	// A peer that has not been pinged is healthy.
+	require.True(t, ps1.getStatus(pID2).Healthy)
+
+	ps1.tick()
+	status := ps1.getStatus(pID2)
+	require.True(t, status.Healthy)
+	require.Equal(t, 0, status.MissedPings)
+	require.Greater(t, status.Rtt.Nanoseconds(), int64(0))
+
+	// The peer stays healthy until it misses MaxMissedPings pings.
+	network.setDown(pID2, true)
+	for i := 0; i < MaxMissedPings; i++ {
+		require.True(t, ps1.getStatus(pID2).Healthy)
+		ps1.tick()
+	}
+	ps1.tick()
+	status = ps1.getStatus(pID2)
+	require.False(t, status.Healthy)
+	require.Equal(t, MaxMissedPings, status.MissedPings)
+
+	// The peer is healthy again once it answers.
+	network.setDown(pID2, false)
+	ps1.tick()
+	status = ps1.getStatus(pID2)
+	require.True(t, status.Healthy)
+	require.Equal(t, 0, status.MissedPings)
+
+	// Pongs with a wrong nonce are ignored.
+	ps1.nextPing(pID2)
+	ps1.OnNetworkMessage(&types.P2PMessage{FromPeerId: pID2.String(), Data: newPingMessage(pingTypePong, 1)})
+	require.NotEqual(t, uint64(0), ps1.peers[pID2].nonce)
+}
+
+func TestPingService_Leave(t *testing.T) {
+	t.Parallel()
+
+	_, pID1 := generatePeer(t, crypto.Ed25519, "ed25519")
+	_, pID2 := generatePeer(t, crypto.Ed25519, "ed25519")
+
+	network := &pingNetwork{
+		services: make(map[peer.ID]*pingService),
+		down:     make(map[peer.ID]bool),
+		lock:     &sync.RWMutex{},
+	}
+	ps1 := newPingService(&pingNetworkWriter{network, pID1}, func() []peer.ID { return []peer.ID{pID2} })
+	ps2 := newPingService(&pingNetworkWriter{network, pID2}, func() []peer.ID { return []peer.ID{pID1} })
+	network.services[pID1] = ps1
+	network.services[pID2] = ps2
+
+	// A leaving peer is unhealthy right away.
+	ps2.leave()
+	status := ps1.getStatus(pID2)
+	require.False(t, status.Healthy)
+	require.Equal(t, MaxMissedPings, status.MissedPings)
+
+	// The peer is healthy again once it answers a ping.
+	ps1.tick()
+	require.True(t, ps1.getStatus(pID2).Healthy)
+}
@@ -0,0 +1,120 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	types "github.com/sisu-network/dheart/p2p/types"
+)
+
+const (
+	MessageTypeDefault = "default"
+	MessageTypePing    = "ping"
+)
+
+var (
+	// Default rate limits of every peer. A tss work sends a few messages per round to every peer, the
+	// burst allows many works to run at the same time.
+	defaultRateLimits = map[string]types.RateLimit{
+		MessageTypeDefault: {Rate: 200, Burst: 1000},
+		MessageTypePing:    {Rate: 1, Burst: 5},
+	}
+)
+
+// tokenBucket allows a number of events per second with bursts up to its capacity.
+type tokenBucket struct {
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(limit types.RateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:     limit.Rate,
+		capacity: float64(limit.Burst),
+		tokens:   float64(limit.Burst),
+		last:     now,
+	}
+}
+
+func (b *tokenBucket) allow(now time.Time) bool {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// rateLimiter keeps a token bucket for every peer and message type.
+type rateLimiter struct {
+	limits map[string]types.RateLimit
+	// Key: peer id, message type.
+	buckets map[peer.ID]map[string]*tokenBucket
+	lock    *sync.Mutex
+
+	now func() time.Time
+}
+
+// newRateLimiter creates a rate limiter with the configured limits on top of the default limits.
+func newRateLimiter(limits map[string]types.RateLimit) *rateLimiter {
+	merged := make(map[string]types.RateLimit)
+	for msgType, limit := range defaultRateLimits {
+		merged[msgType] = limit
+	}
+	for msgType, limit := range limits {
+		merged[msgType] = limit
+	}
+
+	return &rateLimiter{
+		limits:  merged,
+		buckets: make(map[peer.ID]map[string]*tokenBucket),
+		lock:    &sync.Mutex{},
+		now:     time.Now,
+	}
+}
+
+// allow returns true if a peer can send one more message of a type.
+func (l *rateLimiter) allow(pID peer.ID, msgType string) bool {
+	limit, ok := l.limits[msgType]
+	if !ok {
+		msgType = MessageTypeDefault
+		limit = l.limits[MessageTypeDefault]
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	peerBuckets := l.buckets[pID]
+	if peerBuckets == nil {
+		peerBuckets = make(map[string]*tokenBucket)
+		l.buckets[pID] = peerBuckets
+	}
+
+	now := l.now()
+	bucket := peerBuckets[msgType]
+	if bucket == nil {
+		bucket = newTokenBucket(limit, now)
+		peerBuckets[msgType] = bucket
+	}
+
+	return bucket.allow(now)
+}
+
+func (l *rateLimiter) removePeer(pID peer.ID) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.buckets, pID)
+}
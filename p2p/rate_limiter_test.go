@@ -0,0 +1,113 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	types "github.com/sisu-network/dheart/p2p/types"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	limiter := newRateLimiter(map[string]types.RateLimit{
+		"UPDATE_MESSAGES": {Rate: 10, Burst: 2},
+	})
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	// Burst is allowed, then the peer has to wait for new tokens.
+	require.True(t, limiter.allow("peer0", "UPDATE_MESSAGES"))
+	require.True(t, limiter.allow("peer0", "UPDATE_MESSAGES"))
+	require.False(t, limiter.allow("peer0", "UPDATE_MESSAGES"))
+	now = now.Add(100 * time.Millisecond)
+	require.True(t, limiter.allow("peer0", "UPDATE_MESSAGES"))
+	require.False(t, limiter.allow("peer0", "UPDATE_MESSAGES"))
+
+	// Every peer and every message type has its own bucket.
+	require.True(t, limiter.allow("peer1", "UPDATE_MESSAGES"))
+	require.True(t, limiter.allow("peer0", MessageTypePing))
+
+	// Message types without a limit share the default limit.
+	burst := defaultRateLimits[MessageTypeDefault].Burst
+	for i := 0; i < burst; i++ {
+		msgType := "AVAILABILITY_REQUEST"
+		if i%2 == 1 {
+			msgType = "ASK_MESSAGE_REQUEST"
+		}
+		require.True(t, limiter.allow("peer0", msgType))
+	}
+	require.False(t, limiter.allow("peer0", "AVAILABILITY_REQUEST"))
+
+	limiter.removePeer("peer0")
+	require.True(t, limiter.allow("peer0", "AVAILABILITY_REQUEST"))
+}
+
+type blockingListener struct {
+	running    *atomic.Int32
+	maxRunning *atomic.Int32
+	release    chan struct{}
+	done       *sync.WaitGroup
+}
+
+func (l *blockingListener) OnNetworkMessage(message *types.P2PMessage) {
+	running := l.running.Inc()
+	for {
+		max := l.maxRunning.Load()
+		if running <= max || l.maxRunning.CAS(max, running) {
+			break
+		}
+	}
+
+	<-l.release
+	l.running.Dec()
+	l.done.Done()
+}
+
+func TestInboundPool(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	workers := 4
+	pool := newInboundPool(workers, 2)
+	pool.start(ctx)
+
+	listener := &blockingListener{
+		running:    atomic.NewInt32(0),
+		maxRunning: atomic.NewInt32(0),
+		release:    make(chan struct{}),
+		done:       &sync.WaitGroup{},
+	}
+
+	// Workers plus queue size messages can be submitted before submit blocks.
+	n := workers + 2
+	listener.done.Add(n + 1)
+	for i := 0; i < n; i++ {
+		require.True(t, pool.submit(ctx, listener, &types.P2PMessage{}))
+	}
+	require.Eventually(t, func() bool {
+		return listener.running.Load() == int32(workers)
+	}, time.Second, time.Millisecond)
+
+	submitted := make(chan bool)
+	go func() {
+		submitted <- pool.submit(ctx, listener, &types.P2PMessage{})
+	}()
+	select {
+	case <-submitted:
+		t.Fatal("submit should block when the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(listener.release)
+	require.True(t, <-submitted)
+	listener.done.Wait()
+	require.Equal(t, int32(workers), listener.maxRunning.Load())
+}
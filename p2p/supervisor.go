@@ -0,0 +1,280 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/sisu-network/lib/log"
+)
+
+const (
+	MinReconnectBackoff = time.Second
+	MaxReconnectBackoff = time.Minute
+)
+
+var errConnectionClosed = errors.New("connection is closed right after connecting")
+
+// PeerState is the connection state of a peer.
+type PeerState int
+
+const (
+	PeerDisconnected PeerState = iota
+	PeerConnecting
+	PeerConnected
+)
+
+func (s PeerState) String() string {
+	switch s {
+	case PeerDisconnected:
+		return "disconnected"
+	case PeerConnecting:
+		return "connecting"
+	case PeerConnected:
+		return "connected"
+	default:
+		return "unknown"
+	}
+}
+
+// PeerStatus is a snapshot of the connection state of a peer.
+type PeerStatus struct {
+	PeerId    peer.ID
+	State     PeerState
+	Retries   int
+	LastError string
+	// The last time the peer became connected. It is zero if the peer has never been connected.
+	ConnectedTime time.Time
+
+	// Liveness of the peer measured by pings.
+	Healthy bool
+	Rtt     time.Duration
+
+	// The number of offenses of the peer, e.g. exceeding its rate limit.
+	Offenses int
+}
+
+type supervisedPeer struct {
+	addrInfo     peer.AddrInfo
+	status       PeerStatus
+	reconnecting bool
+}
+
+// connectionSupervisor watches the libp2p network for connection changes of a set of peers and
+// reconnects to a peer with exponential backoff whenever the connection to it is lost.
+type connectionSupervisor struct {
+	ctx  context.Context
+	host host.Host
+
+	peers map[peer.ID]*supervisedPeer
+	lock  *sync.RWMutex
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	// Called when all connections to a peer are closed.
+	onDisconnected func(pID peer.ID)
+}
+
+func newConnectionSupervisor(ctx context.Context, host host.Host, onDisconnected func(pID peer.ID)) *connectionSupervisor {
+	s := &connectionSupervisor{
+		ctx:            ctx,
+		host:           host,
+		peers:          make(map[peer.ID]*supervisedPeer),
+		lock:           &sync.RWMutex{},
+		minBackoff:     MinReconnectBackoff,
+		maxBackoff:     MaxReconnectBackoff,
+		onDisconnected: onDisconnected,
+	}
+
+	host.Network().Notify(&network.NotifyBundle{
+		ConnectedF: func(n network.Network, conn network.Conn) {
+			s.onConnected(conn.RemotePeer())
+		},
+		DisconnectedF: func(n network.Network, conn network.Conn) {
+			// A peer can have several connections, it is only disconnected when the last one is closed.
+			if n.Connectedness(conn.RemotePeer()) != network.Connected {
+				s.onDisconnect(conn.RemotePeer())
+			}
+		},
+	})
+
+	return s
+}
+
+// addPeer starts supervising a peer and connects to it in the background.
+func (s *connectionSupervisor) addPeer(addrInfo peer.AddrInfo) {
+	s.lock.Lock()
+	if _, ok := s.peers[addrInfo.ID]; ok {
+		s.lock.Unlock()
+		return
+	}
+	s.peers[addrInfo.ID] = &supervisedPeer{
+		addrInfo: addrInfo,
+		status:   PeerStatus{PeerId: addrInfo.ID, State: PeerDisconnected},
+	}
+	s.lock.Unlock()
+
+	s.startReconnect(addrInfo.ID)
+}
+
+// removePeer stops supervising a peer. A running reconnect loop of the peer stops at its next
+// iteration.
+func (s *connectionSupervisor) removePeer(pID peer.ID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.peers, pID)
+}
+
+func (s *connectionSupervisor) getPeerStatuses() []PeerStatus {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	statuses := make([]PeerStatus, 0, len(s.peers))
+	for _, p := range s.peers {
+		statuses = append(statuses, p.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].PeerId < statuses[j].PeerId
+	})
+
+	return statuses
+}
+
+// allConnected returns true if every supervised peer is connected.
+func (s *connectionSupervisor) allConnected() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, p := range s.peers {
+		if p.status.State != PeerConnected {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *connectionSupervisor) onConnected(pID peer.ID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p := s.peers[pID]
+	if p == nil || p.status.State == PeerConnected {
+		return
+	}
+
+	log.Infof("Connection established with peer %s", pID)
+	p.status.State = PeerConnected
+	p.status.Retries = 0
+	p.status.LastError = ""
+	p.status.ConnectedTime = time.Now()
+}
+
+func (s *connectionSupervisor) onDisconnect(pID peer.ID) {
+	s.lock.Lock()
+	p := s.peers[pID]
+	if p == nil {
+		s.lock.Unlock()
+		return
+	}
+	log.Warnf("Lost connection with peer %s", pID)
+	p.status.State = PeerDisconnected
+	s.lock.Unlock()
+
+	if s.onDisconnected != nil {
+		s.onDisconnected(pID)
+	}
+
+	s.startReconnect(pID)
+}
+
+// startReconnect starts a reconnect loop for a peer unless there is one running already.
+func (s *connectionSupervisor) startReconnect(pID peer.ID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p := s.peers[pID]
+	if p == nil || p.reconnecting {
+		return
+	}
+	p.reconnecting = true
+
+	go s.reconnect(pID, p)
+}
+
+// reconnect connects to a peer until it succeeds. The loop stops if the peer is removed from the
+// supervisor or the supervisor's context is done.
+func (s *connectionSupervisor) reconnect(pID peer.ID, p *supervisedPeer) {
+	for {
+		s.lock.Lock()
+		if s.peers[pID] != p || s.ctx.Err() != nil {
+			p.reconnecting = false
+			s.lock.Unlock()
+			return
+		}
+		if s.host.Network().Connectedness(pID) == network.Connected {
+			p.reconnecting = false
+			s.lock.Unlock()
+			s.onConnected(pID)
+			return
+		}
+		p.status.State = PeerConnecting
+		addrInfo := p.addrInfo
+		s.lock.Unlock()
+
+		ctx, cancel := context.WithTimeout(s.ctx, TimeoutConnecting)
+		err := s.host.Connect(ctx, addrInfo)
+		cancel()
+		if err == nil && s.host.Network().Connectedness(pID) != network.Connected {
+			// Connect can return a connection that is being closed.
+			err = errConnectionClosed
+		}
+
+		s.lock.Lock()
+		if s.peers[pID] != p {
+			s.lock.Unlock()
+			return
+		}
+		if err == nil {
+			p.reconnecting = false
+			s.lock.Unlock()
+			// The connected notification may have been delivered before the flag is cleared.
+			s.onConnected(pID)
+			return
+		}
+
+		p.status.State = PeerDisconnected
+		p.status.LastError = err.Error()
+		backoff := getReconnectBackoff(p.status.Retries, s.minBackoff, s.maxBackoff)
+		p.status.Retries++
+		s.lock.Unlock()
+
+		log.Warnf("Failed to connect to peer %s, retrying in %s, err = %v", pID, backoff, err)
+
+		select {
+		case <-s.ctx.Done():
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// getReconnectBackoff returns the wait time before the next connection attempt. It doubles with
+// every failed attempt and is capped at max.
+func getReconnectBackoff(retries int, min, max time.Duration) time.Duration {
+	backoff := min
+	for i := 0; i < retries && backoff < max; i++ {
+		backoff *= 2
+	}
+
+	if backoff > max {
+		return max
+	}
+	return backoff
+}
@@ -0,0 +1,103 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHost(t *testing.T) host.Host {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	t.Cleanup(func() { h.Close() })
+
+	return h
+}
+
+func getPeerState(s *connectionSupervisor, pID peer.ID) PeerState {
+	for _, status := range s.getPeerStatuses() {
+		if status.PeerId == pID {
+			return status.State
+		}
+	}
+
+	return PeerDisconnected
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, time.Second, getReconnectBackoff(0, time.Second, time.Minute))
+	require.Equal(t, 2*time.Second, getReconnectBackoff(1, time.Second, time.Minute))
+	require.Equal(t, 32*time.Second, getReconnectBackoff(5, time.Second, time.Minute))
+	require.Equal(t, time.Minute, getReconnectBackoff(6, time.Second, time.Minute))
+	require.Equal(t, time.Minute, getReconnectBackoff(1000, time.Second, time.Minute))
+}
+
+func TestSupervisor_Reconnect(t *testing.T) {
+	t.Parallel()
+
+	h1 := newTestHost(t)
+	h2 := newTestHost(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	disconnected := make(chan peer.ID, 10)
+	s := newConnectionSupervisor(ctx, h1, func(pID peer.ID) {
+		disconnected <- pID
+	})
+	s.minBackoff = 10 * time.Millisecond
+	s.maxBackoff = 50 * time.Millisecond
+
+	s.addPeer(peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()})
+	require.Eventually(t, func() bool {
+		return getPeerState(s, h2.ID()) == PeerConnected
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Drop the connection, the supervisor should notice it and reconnect.
+	require.NoError(t, h1.Network().ClosePeer(h2.ID()))
+	select {
+	case pID := <-disconnected:
+		require.Equal(t, h2.ID(), pID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("disconnect is not detected")
+	}
+
+	require.Eventually(t, func() bool {
+		return getPeerState(s, h2.ID()) == PeerConnected && len(h1.Network().ConnsToPeer(h2.ID())) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.True(t, s.allConnected())
+}
+
+func TestSupervisor_UnreachablePeer(t *testing.T) {
+	t.Parallel()
+
+	h1 := newTestHost(t)
+	h2 := newTestHost(t)
+	addrInfo := peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}
+	require.NoError(t, h2.Close())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := newConnectionSupervisor(ctx, h1, nil)
+	s.minBackoff = 10 * time.Millisecond
+	s.maxBackoff = 20 * time.Millisecond
+	s.addPeer(addrInfo)
+
+	require.Eventually(t, func() bool {
+		statuses := s.getPeerStatuses()
+		return len(statuses) == 1 && statuses[0].Retries >= 2 && statuses[0].LastError != ""
+	}, 10*time.Second, 10*time.Millisecond)
+	require.False(t, s.allConnected())
+
+	// Removing the peer stops the reconnect loop.
+	s.removePeer(h2.ID())
+	require.Empty(t, s.getPeerStatuses())
+}
@@ -22,4 +22,27 @@ type ConnectionsConfig struct {
 	Protocol       protocol.ID
 	Peers          []*Peer `toml:"peers"`
 	PrivateKeyType string
+	// Compression of large payloads sent to peers: "none" (default), "snappy" or "zstd". The
+	// compression is only used with peers that support it.
+	Compression string `toml:"compression"`
+	// Limits of the messages received from peers.
+	Inbound InboundConfig `toml:"inbound"`
+}
+
+type RateLimit struct {
+	// The number of messages per second that a peer can send.
+	Rate float64 `toml:"rate"`
+	// The maximum number of messages that a peer can send in a burst.
+	Burst int `toml:"burst"`
+}
+
+type InboundConfig struct {
+	// The number of goroutines that process inbound messages.
+	Workers int `toml:"workers"`
+	// The maximum number of inbound messages waiting for a worker. Reading from peers pauses when the
+	// queue is full.
+	QueueSize int `toml:"queue_size"`
+	// Rate limits of every peer by message type. The key is a tss message type (e.g.
+	// "UPDATE_MESSAGES"), "ping" or "default". Message types without a limit share the default limit.
+	RateLimits map[string]RateLimit `toml:"rate_limits"`
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/joho/godotenv"
@@ -15,6 +16,9 @@ import (
 	"github.com/sisu-network/lib/log"
 )
 
+// The time the rpc server waits for running calls when it stops.
+const ServerStopTimeout = time.Second * 10
+
 func LoadConfigEnv(filenames ...string) {
 	err := godotenv.Load(filenames...)
 	if err != nil {
@@ -22,7 +26,8 @@ func LoadConfigEnv(filenames ...string) {
 	}
 }
 
-func SetupApiServer() {
+// SetupApiServer starts dheart and its rpc server. It returns a function that shuts them down.
+func SetupApiServer() func() {
 	homeDir := os.Getenv("HOME_DIR")
 	if _, err := os.Stat(homeDir); os.IsNotExist(err) {
 		err := os.MkdirAll(homeDir, os.ModePerm)
@@ -64,6 +69,14 @@ func SetupApiServer() {
 	go s.Run()
 
 	go c.TryDial()
+
+	return func() {
+		// The api rejects new requests while the running works finish.
+		serverApi.Stop()
+		if err := s.Stop(ServerStopTimeout); err != nil {
+			log.Error("Cannot stop the rpc server, err = ", err)
+		}
+	}
 }
 
 func setupLogger(key string, options logger.Options) {
@@ -71,7 +84,8 @@ func setupLogger(key string, options logger.Options) {
 	log.SetLogger(logDNA)
 }
 
-func Run() {
+// Run starts dheart and returns a function that shuts it down.
+func Run() func() {
 	LoadConfigEnv()
-	SetupApiServer()
+	return SetupApiServer()
 }
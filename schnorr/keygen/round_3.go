@@ -0,0 +1,150 @@
+package keygen
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/sisu-network/tss-lib/common"
+	"github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/crypto/commitments"
+	"github.com/sisu-network/tss-lib/crypto/vss"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+func (round *round3) Start() *tss.Error {
+	if round.started {
+		return round.WrapError(errors.New("round already started"))
+	}
+	round.number = 3
+	round.started = true
+	round.resetOK()
+
+	Ps := round.Parties().IDs()
+	PIdx := round.PartyID().Index
+	modQ := common.ModInt(tss.EC(tss.EcdsaScheme).Params().N)
+
+	// 1. calculate xi
+	xi := new(big.Int).Set(round.temp.shares[PIdx].Share)
+	for j := range Ps {
+		if j == PIdx {
+			continue
+		}
+		r2msg1 := round.temp.kgRound2Message1s[j].Content().(*KGRound2Message1)
+		xi = modQ.Add(xi, r2msg1.UnmarshalShare())
+	}
+	round.save.Xi = xi
+
+	// 2-12. verify the de-commitments, proofs and shares of all Pj and add up their polynomial
+	// commitments.
+	Vc := make(vss.Vs, round.Threshold()+1)
+	copy(Vc, round.temp.vs)
+	var firstErr error
+	culprits := make([]*tss.PartyID, 0, len(Ps))
+	for j, Pj := range Ps {
+		if j == PIdx {
+			continue
+		}
+
+		PjVs, err := round.verifyParty(j)
+		if err == nil {
+			for c := range Vc {
+				if Vc[c], err = Vc[c].Add(PjVs[c]); err != nil {
+					err = fmt.Errorf("adding PjVs[c] to Vc[c] resulted in a point not on the curve: %w", err)
+					break
+				}
+			}
+		}
+
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			culprits = append(culprits, Pj)
+		}
+	}
+	if len(culprits) > 0 {
+		return round.WrapError(firstErr, culprits...)
+	}
+
+	// 13-17. compute Xj for each Pj
+	for j, Pj := range Ps {
+		kj := Pj.KeyInt()
+		BigXj := Vc[0]
+		z := big.NewInt(1)
+		for c := 1; c <= round.Threshold(); c++ {
+			var err error
+			z = modQ.Mul(z, kj)
+			if BigXj, err = BigXj.Add(Vc[c].ScalarMult(z)); err != nil {
+				return round.WrapError(errors.New("adding Vc[c].ScalarMult(z) to BigXj resulted in a point not on the curve"), Pj)
+			}
+		}
+		round.save.BigXj[j] = BigXj
+	}
+
+	// 18. compute and SAVE the public key `y`
+	round.save.SchnorrPub = Vc[0]
+
+	// BIP-340 keys are x-only and stand for the point with an even y coordinate. If our key has an
+	// odd y coordinate, all parties negate their shares to get the key with the same x coordinate.
+	if round.save.SchnorrPub.Y().Bit(0) == 1 {
+		*round.save = round.save.Negate()
+	}
+
+	common.Logger.Debugf("%s public key: %x", round.PartyID(), round.save.SchnorrPub.X())
+
+	round.end <- *round.save
+	return nil
+}
+
+// verifyParty verifies the round 2 messages of party j and returns its polynomial commitments.
+func (round *round3) verifyParty(j int) (vss.Vs, error) {
+	r2msg2 := round.temp.kgRound2Message2s[j].Content().(*KGRound2Message2)
+	cmtDeCmt := commitments.HashCommitDecommit{C: round.temp.KGCs[j], D: r2msg2.UnmarshalDeCommitment()}
+	ok, flatPolyGs := cmtDeCmt.DeCommit()
+	if !ok || flatPolyGs == nil {
+		return nil, errors.New("de-commitment verify failed")
+	}
+
+	PjVs, err := crypto.UnFlattenECPoints(tss.EC(tss.EcdsaScheme), flatPolyGs)
+	if err != nil {
+		return nil, err
+	}
+	if len(PjVs) != round.Threshold()+1 {
+		return nil, fmt.Errorf("expected %d polynomial commitments, got %d", round.Threshold()+1, len(PjVs))
+	}
+
+	proof, err := r2msg2.UnmarshalZKProof()
+	if err != nil {
+		return nil, errors.New("failed to unmarshal zk proof")
+	}
+	if !proof.Verify(tss.EcdsaScheme, PjVs[0]) {
+		return nil, errors.New("failed to prove zk proof")
+	}
+
+	r2msg1 := round.temp.kgRound2Message1s[j].Content().(*KGRound2Message1)
+	PjShare := vss.Share{
+		Threshold: round.Threshold(),
+		ID:        round.PartyID().KeyInt(),
+		Share:     r2msg1.UnmarshalShare(),
+	}
+	if !PjShare.Verify(tss.EcdsaScheme, round.Threshold(), PjVs) {
+		return nil, errors.New("vss verify failed")
+	}
+
+	return PjVs, nil
+}
+
+func (round *round3) CanAccept(msg tss.ParsedMessage) bool {
+	// not expecting any incoming messages in this round
+	return false
+}
+
+func (round *round3) Update() (bool, *tss.Error) {
+	// not expecting any incoming messages in this round
+	return false, nil
+}
+
+func (round *round3) NextRound() tss.Round {
+	return nil // finished!
+}
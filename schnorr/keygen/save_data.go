@@ -0,0 +1,76 @@
+package keygen
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+type (
+	LocalSecrets struct {
+		// secret fields (not shared, but stored locally)
+		Xi, ShareID *big.Int // xi, kj
+	}
+
+	// Everything in LocalPartySaveData is saved locally to the database when done.
+	LocalPartySaveData struct {
+		LocalSecrets
+
+		// original indexes (ki in signing preparation phase)
+		Ks []*big.Int
+
+		// public keys (Xj = uj*G for each Pj)
+		BigXj []*crypto.ECPoint // Xj
+
+		// The secp256k1 public key. Keygen always outputs a key with an even y coordinate, so that the
+		// key is the point of its BIP-340 x-only form.
+		SchnorrPub *crypto.ECPoint // y
+	}
+)
+
+func NewLocalPartySaveData(partyCount int) (saveData LocalPartySaveData) {
+	saveData.Ks = make([]*big.Int, partyCount)
+	saveData.BigXj = make([]*crypto.ECPoint, partyCount)
+	return
+}
+
+// BuildLocalSaveDataSubset re-creates the LocalPartySaveData to contain data for only the list of
+// signing parties.
+func BuildLocalSaveDataSubset(sourceData LocalPartySaveData, sortedIDs tss.SortedPartyIDs) LocalPartySaveData {
+	keysToIndices := make(map[string]int, len(sourceData.Ks))
+	for j, kj := range sourceData.Ks {
+		keysToIndices[hex.EncodeToString(kj.Bytes())] = j
+	}
+	newData := NewLocalPartySaveData(sortedIDs.Len())
+	newData.LocalSecrets = sourceData.LocalSecrets
+	newData.SchnorrPub = sourceData.SchnorrPub
+	for j, id := range sortedIDs {
+		savedIdx, ok := keysToIndices[hex.EncodeToString(id.Key)]
+		if !ok {
+			panic(errors.New("BuildLocalSaveDataSubset: unable to find a signer party in the local save data"))
+		}
+		newData.Ks[j] = sourceData.Ks[savedIdx]
+		newData.BigXj[j] = sourceData.BigXj[savedIdx]
+	}
+	return newData
+}
+
+// Negate returns a copy of the keygen data for the negated public key. Every party negates its share
+// and the public shares of all parties.
+func (save LocalPartySaveData) Negate() LocalPartySaveData {
+	n := tss.EC(tss.EcdsaScheme).Params().N
+
+	negated := save
+	negated.Xi = new(big.Int).Sub(n, save.Xi)
+	negated.Xi.Mod(negated.Xi, n)
+	negated.BigXj = make([]*crypto.ECPoint, len(save.BigXj))
+	for j, bigXj := range save.BigXj {
+		negated.BigXj[j] = bigXj.Neg()
+	}
+	negated.SchnorrPub = save.SchnorrPub.Neg()
+
+	return negated
+}
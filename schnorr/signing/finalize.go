@@ -0,0 +1,79 @@
+package signing
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sisu-network/dheart/utils"
+	"github.com/sisu-network/tss-lib/common"
+	"github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+func (round *finalization) Start() *tss.Error {
+	if round.started {
+		return round.WrapError(errors.New("round already started"))
+	}
+	round.number = 4
+	round.started = true
+	round.resetOK()
+
+	ec := tss.EC(tss.EcdsaScheme)
+	modQ := common.ModInt(ec.Params().N)
+
+	// 1. check the share of every party: sj * G == Rj + e * Wj
+	sumS := round.temp.si
+	culprits := make([]*tss.PartyID, 0)
+	for j, Pj := range round.Parties().IDs() {
+		round.ok[j] = true
+		if j == round.PartyID().Index {
+			continue
+		}
+		r3msg := round.temp.signRound3Messages[j].Content().(*SignRound3Message)
+		sj := r3msg.UnmarshalS()
+
+		expected, err := round.temp.pointRjs[j].Add(round.temp.bigWs[j].ScalarMult(round.temp.e))
+		if err != nil || !crypto.ScalarBaseMult(ec, sj).Equals(expected) {
+			culprits = append(culprits, Pj)
+			continue
+		}
+		sumS = modQ.Add(sumS, sj)
+	}
+	if len(culprits) > 0 {
+		return round.WrapError(errors.New("signature share verify failed"), culprits...)
+	}
+
+	// 2. the signature is x(R) || s
+	r := round.temp.r.X()
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sumS.FillBytes(signature[32:])
+
+	pubKey := utils.SerializeSchnorrPubKey(round.key.SchnorrPub)
+	if err := utils.VerifySchnorrSignature(pubKey, round.temp.m, signature); err != nil {
+		return round.WrapError(fmt.Errorf("signature verification failed: %w", err))
+	}
+
+	// save the signature for final output
+	round.data.Signature = signature
+	round.data.R = r.Bytes()
+	round.data.S = sumS.Bytes()
+	round.data.M = round.temp.m
+	round.end <- round.data
+
+	return nil
+}
+
+func (round *finalization) CanAccept(msg tss.ParsedMessage) bool {
+	// not expecting any incoming messages in this round
+	return false
+}
+
+func (round *finalization) Update() (bool, *tss.Error) {
+	// not expecting any incoming messages in this round
+	return false, nil
+}
+
+func (round *finalization) NextRound() tss.Round {
+	return nil // finished!
+}
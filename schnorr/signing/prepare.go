@@ -0,0 +1,54 @@
+package signing
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/sisu-network/tss-lib/common"
+	"github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+// PrepareForSigning converts the Shamir share xi of party i into its additive share wi of the key
+// for the signing parties with indexes ks. It also returns the public additive shares of all
+// parties, which are used to check their signature shares.
+func PrepareForSigning(i, pax int, xi *big.Int, ks []*big.Int, bigXs []*crypto.ECPoint) (wi *big.Int,
+	bigWs []*crypto.ECPoint) {
+	modQ := common.ModInt(tss.EC(tss.EcdsaScheme).Params().N)
+	if len(ks) != len(bigXs) {
+		panic(fmt.Errorf("PrepareForSigning: len(ks) != len(bigXs) (%d != %d)", len(ks), len(bigXs)))
+	}
+	if len(ks) != pax {
+		panic(fmt.Errorf("PrepareForSigning: len(ks) != pax (%d != %d)", len(ks), pax))
+	}
+	if len(ks) <= i {
+		panic(fmt.Errorf("PrepareForSigning: len(ks) <= i (%d <= %d)", len(ks), i))
+	}
+
+	// lagrange returns the Lagrange coefficient of party j at 0.
+	lagrange := func(j int) *big.Int {
+		coef := big.NewInt(1)
+		for c := 0; c < pax; c++ {
+			if c == j {
+				continue
+			}
+			if ks[c].Cmp(ks[j]) == 0 {
+				panic(fmt.Errorf("index of two parties are equal"))
+			}
+			// big.Int Div is calculated as: a/b = a * modInv(b,q)
+			coef = modQ.Mul(coef, modQ.Mul(ks[c], modQ.Inverse(new(big.Int).Sub(ks[c], ks[j]))))
+		}
+		return coef
+	}
+
+	bigWs = make([]*crypto.ECPoint, len(ks))
+	for j := 0; j < pax; j++ {
+		coef := lagrange(j)
+		if j == i {
+			wi = modQ.Mul(xi, coef)
+		}
+		bigWs[j] = bigXs[j].ScalarMult(coef)
+	}
+
+	return
+}
@@ -0,0 +1,136 @@
+package signing
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/sisu-network/dheart/utils"
+	"github.com/sisu-network/tss-lib/common"
+	"github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/crypto/commitments"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+func (round *round3) Start() *tss.Error {
+	if round.started {
+		return round.WrapError(errors.New("round already started"))
+	}
+	round.number = 3
+	round.started = true
+	round.resetOK()
+
+	i := round.PartyID().Index
+	modQ := common.ModInt(tss.EC(tss.EcdsaScheme).Params().N)
+
+	// 1-5. open the nonce points Rj of all parties and compute R = sum(Rj)
+	round.temp.pointRjs[i] = round.temp.pointRi
+	R := round.temp.pointRi
+	for j, Pj := range round.Parties().IDs() {
+		if j == i {
+			continue
+		}
+
+		Rj, err := round.verifyNoncePoint(j)
+		if err != nil {
+			return round.WrapError(err, Pj)
+		}
+		if R, err = R.Add(Rj); err != nil {
+			return round.WrapError(fmt.Errorf("R.Add(Rj): %w", err), Pj)
+		}
+		round.temp.pointRjs[j] = Rj
+	}
+
+	// 6. BIP-340 signatures commit to the nonce point with an even y coordinate. If R has an odd y
+	// coordinate, every party negates its nonce so that the parties sign with -R.
+	ki := round.temp.ri
+	if R.Y().Bit(0) == 1 {
+		ki = modQ.Sub(big.NewInt(0), ki)
+		R = R.Neg()
+		for j, Rj := range round.temp.pointRjs {
+			round.temp.pointRjs[j] = Rj.Neg()
+		}
+	}
+
+	// 7. keygen outputs keys with an even y coordinate, but a derived child key may have an odd one.
+	// Its BIP-340 key is the negated key, so the parties sign with negated shares.
+	wi := round.temp.wi
+	if round.key.SchnorrPub.Y().Bit(0) == 1 {
+		wi = modQ.Sub(big.NewInt(0), wi)
+		for j, bigWj := range round.temp.bigWs {
+			round.temp.bigWs[j] = bigWj.Neg()
+		}
+	}
+
+	// 8. compute the challenge e = hash(R.x || P.x || m) and si = ki + e * wi
+	e := utils.SchnorrChallenge(R.X(), round.key.SchnorrPub.X(), round.temp.m)
+	si := modQ.Add(ki, modQ.Mul(e, wi))
+
+	// 9. store r3 message pieces
+	round.temp.r = R
+	round.temp.e = e
+	round.temp.si = si
+
+	// security: the nonce may be discarded
+	round.temp.ri = nil
+
+	// 10. broadcast si to other parties
+	r3msg := NewSignRound3Message(round.PartyID(), si)
+	round.temp.signRound3Messages[i] = r3msg
+	round.out <- r3msg
+
+	return nil
+}
+
+// verifyNoncePoint opens the commitment of party j to its nonce point and verifies the proof that
+// the party knows the discrete log of the point.
+func (round *round3) verifyNoncePoint(j int) (*crypto.ECPoint, error) {
+	r2msg := round.temp.signRound2Messages[j].Content().(*SignRound2Message)
+	cmtDeCmt := commitments.HashCommitDecommit{C: round.temp.cjs[j], D: r2msg.UnmarshalDeCommitment()}
+	ok, coordinates := cmtDeCmt.DeCommit()
+	if !ok {
+		return nil, errors.New("de-commitment verify failed")
+	}
+	if len(coordinates) != 2 {
+		return nil, errors.New("length of de-commitment should be 2")
+	}
+
+	Rj, err := crypto.NewECPoint(tss.EC(tss.EcdsaScheme), coordinates[0], coordinates[1])
+	if err != nil {
+		return nil, fmt.Errorf("NewECPoint(Rj): %w", err)
+	}
+	proof, err := r2msg.UnmarshalZKProof()
+	if err != nil {
+		return nil, errors.New("failed to unmarshal Rj proof")
+	}
+	if !proof.Verify(tss.EcdsaScheme, Rj) {
+		return nil, errors.New("failed to prove Rj")
+	}
+
+	return Rj, nil
+}
+
+func (round *round3) Update() (bool, *tss.Error) {
+	for j, msg := range round.temp.signRound3Messages {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !round.CanAccept(msg) {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+	return true, nil
+}
+
+func (round *round3) CanAccept(msg tss.ParsedMessage) bool {
+	if _, ok := msg.Content().(*SignRound3Message); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+func (round *round3) NextRound() tss.Round {
+	round.started = false
+	return &finalization{round}
+}
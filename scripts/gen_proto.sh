@@ -24,8 +24,10 @@ s=$(join_by " " "${arr[@]}")
 # Generate all proto files. These will go into folder github.com/sisu-network/dheart
 protoc $s -I. --go_out=.
 
-# Move all generated files into types folder at root
+# Move all generated files into the types, schnorr and eddsa folders at root
 cp -r github.com/sisu-network/dheart/types .
+cp -r github.com/sisu-network/dheart/schnorr .
+cp -r github.com/sisu-network/dheart/eddsa .
 
 # Delete github folder
 rm -rf github.com
\ No newline at end of file
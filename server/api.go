@@ -11,11 +11,34 @@ import (
 type Api interface {
 	Init()
 	SetPrivKey(encodedKey string, keyType string) error
-	KeyGen(keygenId string, chain string, tPubKeys []types.PubKeyWrapper) error
+	// KeyGen generates a key. keyLabel is a trailing optional param so that callers that do not
+	// know about labels keep working; a missing label generates the default key.
+	KeyGen(keygenId string, keyType string, tPubKeys []types.PubKeyWrapper, keyLabel *string) error
 	KeySign(req *types.KeysignRequest, tPubKeys []types.PubKeyWrapper) error
 	BlockEnd(blockHeight int64) error
 	SetSisuReady(isReady bool)
 	Ping(source string)
+
+	AddPeer(address string, pubKey string, pubKeyType string) error
+	RemovePeer(pubKey string) error
+	ListPeers() []*types.PeerInfo
+
+	// GetAddresses returns the addresses of a key. The network is a trailing optional param.
+	GetAddresses(keyType string, keyLabel string, bech32Prefix string, network *string) (*types.Addresses, error)
+	GetDerivedPubKey(keyType string, keyLabel string, path string) ([]byte, error)
+
+	GetPreparamsStatus() (*types.PreparamsPoolStatus, error)
+
+	// Stop shuts down the node. Works that cannot finish in time are reported as failed.
+	Stop()
+}
+
+// getOptionalString returns the value of an optional string param or "" if it is missing.
+func getOptionalString(param *string) string {
+	if param == nil {
+		return ""
+	}
+	return *param
 }
 
 func GetApi(cfg config.HeartConfig, client client.Client) Api {
@@ -1,9 +1,11 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/sisu-network/lib/log"
@@ -12,12 +14,14 @@ import (
 type Server struct {
 	handler       *rpc.Server
 	listenAddress string
+	srv           *http.Server
 }
 
 func NewServer(handler *rpc.Server, host string, port uint16) *Server {
 	return &Server{
 		handler:       handler,
 		listenAddress: fmt.Sprintf("%s:%d", host, port),
+		srv:           &http.Server{Handler: handler},
 	}
 }
 
@@ -27,7 +31,14 @@ func (s *Server) Run() {
 		panic(err)
 	}
 
-	srv := &http.Server{Handler: s.handler}
 	log.Info("Running server at", s.listenAddress)
-	srv.Serve(listener)
+	s.srv.Serve(listener)
+}
+
+// Stop stops accepting rpc calls and waits up to timeout for the running calls to return.
+func (s *Server) Stop(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return s.srv.Shutdown(ctx)
 }
@@ -2,14 +2,21 @@ package server
 
 import (
 	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"math/big"
+	"sync"
 	"time"
 
 	"github.com/decred/dcrd/dcrec/edwards/v2"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/sisu-network/dheart/client"
 	"github.com/sisu-network/dheart/types"
+	"github.com/sisu-network/dheart/utils"
 	"github.com/sisu-network/lib/log"
+	libCrypto "github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/tss"
 
 	libchain "github.com/sisu-network/lib/chain"
 )
@@ -18,20 +25,24 @@ const (
 	encodedAESKey = "Jxq9PhUzvP4RZFBQivXGfA"
 )
 
+var (
+	ErrNotSupported = errors.New("not supported in single node mode")
+)
+
 // This is a mock API to use for single localhost node. It does not have TSS signing round and
 // generates a private key instead.
 type SingleNodeApi struct {
-	keyMap map[string]interface{}
-	c      client.Client
-
-	ecPrivate *ecdsa.PrivateKey
-	edPrivate *edwards.PrivateKey
+	// map between: key type & key label -> private key
+	keyMap  map[string]interface{}
+	keyLock *sync.RWMutex
+	c       client.Client
 }
 
 func NewSingleNodeApi(c client.Client) *SingleNodeApi {
 	return &SingleNodeApi{
-		keyMap: make(map[string]interface{}),
-		c:      c,
+		keyMap:  make(map[string]interface{}),
+		keyLock: &sync.RWMutex{},
+		c:       c,
 	}
 }
 
@@ -45,6 +56,10 @@ func (api *SingleNodeApi) SetSisuReady(isReady bool) {
 	// Do nothing.
 }
 
+// Stop implements Api interface. A single node signs right away and has nothing to drain.
+func (api *SingleNodeApi) Stop() {
+}
+
 // Empty function for checking health only.
 func (api *SingleNodeApi) Ping(source string) {
 }
@@ -53,8 +68,9 @@ func (api *SingleNodeApi) Version() string {
 	return "1"
 }
 
-func (api *SingleNodeApi) KeyGen(keygenId string, keyType string, tPubKeys []types.PubKeyWrapper) error {
-	log.Info("keygen: keyType = ", keyType)
+func (api *SingleNodeApi) KeyGen(keygenId string, keyType string, tPubKeys []types.PubKeyWrapper, optKeyLabel *string) error {
+	keyLabel := getOptionalString(optKeyLabel)
+	log.Info("keygen: keyType = ", keyType, " keyLabel = ", keyLabel)
 
 	// Add some delay to mock TSS gen delay before sending back to Sisu server
 	go func() {
@@ -64,30 +80,46 @@ func (api *SingleNodeApi) KeyGen(keygenId string, keyType string, tPubKeys []typ
 		var result types.KeygenResult
 		switch keyType {
 		case libchain.KEY_TYPE_ECDSA:
-			var err error
-			api.ecPrivate, err = crypto.GenerateKey()
+			ecPrivate, err := crypto.GenerateKey()
 			if err != nil {
 				panic(err)
 			}
+			api.setPrivateKey(keyType, keyLabel, ecPrivate)
 
-			pubKey := api.ecPrivate.Public()
+			pubKey := ecPrivate.Public()
 			publicKeyECDSA, _ := pubKey.(*ecdsa.PublicKey)
 			publicKeyBytes := crypto.FromECDSAPub(publicKeyECDSA)
 
 			result = types.KeygenResult{
 				KeyType:     keyType,
+				KeyLabel:    keyLabel,
 				Outcome:     types.OutcomeSuccess,
 				PubKeyBytes: publicKeyBytes,
 			}
 		case libchain.KEY_TYPE_EDDSA:
-			api.edPrivate, _ = edwards.GeneratePrivateKey()
-			pubKeyBytes := api.edPrivate.PubKey().Serialize()
+			edPrivate, _ := edwards.GeneratePrivateKey()
+			api.setPrivateKey(keyType, keyLabel, edPrivate)
+			pubKeyBytes := edPrivate.PubKey().Serialize()
 
 			result = types.KeygenResult{
 				KeyType:     keyType,
+				KeyLabel:    keyLabel,
 				Outcome:     types.OutcomeSuccess,
 				PubKeyBytes: pubKeyBytes,
 			}
+		case types.KeyTypeSchnorr:
+			ecPrivate, err := crypto.GenerateKey()
+			if err != nil {
+				panic(err)
+			}
+			api.setPrivateKey(keyType, keyLabel, ecPrivate)
+
+			result = types.KeygenResult{
+				KeyType:     keyType,
+				KeyLabel:    keyLabel,
+				Outcome:     types.OutcomeSuccess,
+				PubKeyBytes: ecPrivate.X.FillBytes(make([]byte, 32)),
+			}
 		}
 
 		if err := api.c.PostKeygenResult(&result); err != nil {
@@ -102,16 +134,126 @@ func (api *SingleNodeApi) getKeygenKey(chain string) []byte {
 	return []byte(fmt.Sprintf("keygen_%s", chain))
 }
 
-func (api *SingleNodeApi) keySignEth(chain string, bytesToSign []byte) ([]byte, error) {
-	privateKey := api.ecPrivate
+func (api *SingleNodeApi) getKeyId(keyType, keyLabel string) string {
+	return fmt.Sprintf("%s__%s", keyType, keyLabel)
+}
+
+func (api *SingleNodeApi) setPrivateKey(keyType, keyLabel string, privateKey interface{}) {
+	api.keyLock.Lock()
+	defer api.keyLock.Unlock()
+
+	api.keyMap[api.getKeyId(keyType, keyLabel)] = privateKey
+}
+
+func (api *SingleNodeApi) getPrivateKey(keyType, keyLabel string) interface{} {
+	api.keyLock.RLock()
+	defer api.keyLock.RUnlock()
+
+	return api.keyMap[api.getKeyId(keyType, keyLabel)]
+}
+
+// getEcChildKey returns the child key at a derivation path of the secp256k1 key with a type and a
+// label. ECDSA and Schnorr keys are both secp256k1 keys.
+func (api *SingleNodeApi) getEcChildKey(keyType string, keyLabel string, path string) (*ecdsa.PrivateKey, error) {
+	privateKey, ok := api.getPrivateKey(keyType, keyLabel).(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("cannot find %s key with label %s", keyType, keyLabel)
+	}
+
+	indexes, err := utils.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	curve := tss.EC(tss.EcdsaScheme)
+	tweak, _, err := utils.DeriveEcChildKey(libCrypto.NewECPointNoCurveCheck(curve, privateKey.X, privateKey.Y), indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	d := new(big.Int).Add(privateKey.D, tweak)
+	d.Mod(d, curve.Params().N)
+
+	return crypto.ToECDSA(d.FillBytes(make([]byte, 32)))
+}
+
+// getEdChildKey returns the child key at a derivation path of the eddsa key with a label.
+func (api *SingleNodeApi) getEdChildKey(keyLabel string, path string) (*edwards.PrivateKey, error) {
+	privateKey, ok := api.getPrivateKey(libchain.KEY_TYPE_EDDSA, keyLabel).(*edwards.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("cannot find eddsa key with label %s", keyLabel)
+	}
+
+	indexes, err := utils.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	curve := tss.EC(tss.EddsaScheme)
+	x, y := privateKey.Public()
+	tweak, _, err := utils.DeriveEdChildKey(libCrypto.NewECPointNoCurveCheck(curve, x, y), indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	d := new(big.Int).Add(privateKey.GetD(), tweak)
+	d.Mod(d, curve.Params().N)
+	childKey, _, err := edwards.PrivKeyFromScalar(d.FillBytes(make([]byte, 32)))
+
+	return childKey, err
+}
+
+func (api *SingleNodeApi) keySignEth(keyLabel string, path string, bytesToSign []byte) ([]byte, error) {
+	privateKey, err := api.getEcChildKey(libchain.KEY_TYPE_ECDSA, keyLabel, path)
+	if err != nil {
+		return nil, err
+	}
 	sig, err := crypto.Sign(bytesToSign, privateKey)
 
 	return sig, err
 }
 
-func (api *SingleNodeApi) keySignEddsa(chain string, bytesToSign []byte) ([]byte, error) {
-	sig, err := api.edPrivate.Sign(bytesToSign)
-	return sig.Serialize(), err
+func (api *SingleNodeApi) keySignEddsa(keyLabel string, path string, bytesToSign []byte) ([]byte, error) {
+	privateKey, err := api.getEdChildKey(keyLabel, path)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := privateKey.Sign(bytesToSign)
+	if err != nil {
+		return nil, err
+	}
+
+	return sig.Serialize(), nil
+}
+
+func (api *SingleNodeApi) keySignSchnorr(keyLabel string, path string, tapTweak bool,
+	bytesToSign []byte) ([]byte, error) {
+	privateKey, err := api.getEcChildKey(types.KeyTypeSchnorr, keyLabel, path)
+	if err != nil {
+		return nil, err
+	}
+
+	d := privateKey.D
+	if tapTweak {
+		curve := tss.EC(tss.EcdsaScheme)
+		tweak, _, err := utils.TapTweakPubKey(libCrypto.NewECPointNoCurveCheck(curve, privateKey.X, privateKey.Y))
+		if err != nil {
+			return nil, err
+		}
+
+		// The tweak is added to the secret of the even-y internal key.
+		d = new(big.Int).Set(d)
+		if privateKey.Y.Bit(0) == 1 {
+			d.Sub(curve.Params().N, d)
+		}
+		d.Add(d, tweak)
+		d.Mod(d, curve.Params().N)
+	}
+
+	aux := make([]byte, 32)
+	if _, err := rand.Read(aux); err != nil {
+		return nil, err
+	}
+
+	return utils.SignSchnorr(d, bytesToSign, aux)
 }
 
 // Signing any transaction
@@ -126,10 +268,13 @@ func (api *SingleNodeApi) KeySign(req *types.KeysignRequest, tPubKeys []types.Pu
 
 		switch req.KeyType {
 		case libchain.KEY_TYPE_ECDSA:
-			signature, err = api.keySignEth(msg.OutChain, msg.BytesToSign)
+			signature, err = api.keySignEth(req.KeyLabel, msg.DerivationPath, msg.BytesToSign)
 
 		case libchain.KEY_TYPE_EDDSA:
-			signature, err = api.keySignEddsa(msg.OutChain, msg.BytesToSign)
+			signature, err = api.keySignEddsa(req.KeyLabel, msg.DerivationPath, msg.BytesToSign)
+
+		case types.KeyTypeSchnorr:
+			signature, err = api.keySignSchnorr(req.KeyLabel, msg.DerivationPath, req.TapTweak, msg.BytesToSign)
 		default:
 			err = fmt.Errorf("Unknown chain: %s for message at index %d", msg.OutChain, i)
 		}
@@ -175,3 +320,69 @@ func (api *SingleNodeApi) BlockEnd(blockHeight int64) error {
 	// Do nothing.
 	return nil
 }
+
+// AddPeer implements Api interface. A single node does not have peers.
+func (api *SingleNodeApi) AddPeer(address string, pubKey string, pubKeyType string) error {
+	return ErrNotSupported
+}
+
+// RemovePeer implements Api interface. A single node does not have peers.
+func (api *SingleNodeApi) RemovePeer(pubKey string) error {
+	return ErrNotSupported
+}
+
+// ListPeers implements Api interface. A single node does not have peers.
+func (api *SingleNodeApi) ListPeers() []*types.PeerInfo {
+	return []*types.PeerInfo{}
+}
+
+// GetAddresses implements Api interface.
+func (api *SingleNodeApi) GetAddresses(keyType string, keyLabel string, bech32Prefix string, network *string) (*types.Addresses, error) {
+	// The key at the empty path is the key itself, in the format of keygen results.
+	pubKey, err := api.GetDerivedPubKey(keyType, keyLabel, "")
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := utils.GetAddresses(keyType, pubKey, bech32Prefix, getOptionalString(network))
+	if err != nil {
+		return nil, err
+	}
+	addresses.KeyLabel = keyLabel
+
+	return addresses, nil
+}
+
+// GetDerivedPubKey implements Api interface.
+func (api *SingleNodeApi) GetDerivedPubKey(keyType string, keyLabel string, path string) ([]byte, error) {
+	switch keyType {
+	case libchain.KEY_TYPE_ECDSA:
+		childKey, err := api.getEcChildKey(keyType, keyLabel, path)
+		if err != nil {
+			return nil, err
+		}
+
+		return crypto.FromECDSAPub(&childKey.PublicKey), nil
+	case libchain.KEY_TYPE_EDDSA:
+		childKey, err := api.getEdChildKey(keyLabel, path)
+		if err != nil {
+			return nil, err
+		}
+
+		return childKey.PubKey().Serialize(), nil
+	case types.KeyTypeSchnorr:
+		childKey, err := api.getEcChildKey(keyType, keyLabel, path)
+		if err != nil {
+			return nil, err
+		}
+
+		return childKey.X.FillBytes(make([]byte, 32)), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", keyType)
+	}
+}
+
+// GetPreparamsStatus implements Api interface. A single node does not use preparams.
+func (api *SingleNodeApi) GetPreparamsStatus() (*types.PreparamsPoolStatus, error) {
+	return nil, ErrNotSupported
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/sisu-network/lib/log"
 
 	"github.com/sisu-network/dheart/core"
+	p2ptypes "github.com/sisu-network/dheart/p2p/types"
 	"github.com/sisu-network/dheart/types"
 )
 
@@ -29,6 +30,11 @@ func (api *TssApi) Init() {
 	}
 }
 
+// Stop shuts down the heart. Running works have core.ShutdownGracePeriod to finish.
+func (api *TssApi) Stop() {
+	api.heart.Stop(core.ShutdownGracePeriod)
+}
+
 func (api *TssApi) Version() string {
 	return "1"
 }
@@ -37,12 +43,15 @@ func (api *TssApi) Ping(source string) {
 	// Do nothing.
 }
 
-func (api *TssApi) KeyGen(keygenId string, chain string, keyWrappers []types.PubKeyWrapper) error {
+// KeyGen generates a new key for a key type. The optional keyLabel distinguishes several keys of
+// the same key type. A missing or empty label generates the default key.
+func (api *TssApi) KeyGen(keygenId string, chain string, keyWrappers []types.PubKeyWrapper, optKeyLabel *string) error {
 	if len(keyWrappers) == 0 {
 		return fmt.Errorf("invalid keys array cannot be empty")
 	}
+	keyLabel := getOptionalString(optKeyLabel)
 
-	log.Infof("keygenId = %s, keyType = %s\n", keygenId, chain)
+	log.Infof("keygenId = %s, keyType = %s, keyLabel = %s\n", keygenId, chain, keyLabel)
 
 	pubKeys := make([]ctypes.PubKey, len(keyWrappers))
 
@@ -56,7 +65,7 @@ func (api *TssApi) KeyGen(keygenId string, chain string, keyWrappers []types.Pub
 		}
 	}
 
-	return api.heart.Keygen(keygenId, chain, pubKeys)
+	return api.heart.Keygen(keygenId, chain, keyLabel, pubKeys)
 }
 
 // SetSisuReady sets the Sisu's readiness state. This informs dheart that Sisu is ready and dheart
@@ -111,3 +120,54 @@ func (api *TssApi) KeySign(req *types.KeysignRequest, keyWrappers []types.PubKey
 func (api *TssApi) BlockEnd(blockHeight int64) error {
 	return api.heart.BlockEnd(blockHeight)
 }
+
+// AddPeer adds a new peer with a hex encoded pubkey. The peer id in the address must belong to the
+// pubkey.
+func (api *TssApi) AddPeer(address string, pubKey string, pubKeyType string) error {
+	log.Info("Adding peer, address = ", address)
+
+	err := api.heart.AddPeer(&p2ptypes.Peer{
+		Address:    address,
+		PubKey:     pubKey,
+		PubKeyType: pubKeyType,
+	})
+	if err != nil {
+		log.Error("Cannot add peer, err =", err)
+	}
+
+	return err
+}
+
+// RemovePeer removes the peer with a hex encoded pubkey.
+func (api *TssApi) RemovePeer(pubKey string) error {
+	log.Info("Removing peer, pubkey = ", pubKey)
+
+	err := api.heart.RemovePeer(pubKey)
+	if err != nil {
+		log.Error("Cannot remove peer, err =", err)
+	}
+
+	return err
+}
+
+func (api *TssApi) ListPeers() []*types.PeerInfo {
+	return api.heart.ListPeers()
+}
+
+// GetAddresses returns the addresses of the TSS key with a type and a label on the supported chains.
+// Cosmos addresses use the given bech32 prefix, or "cosmos" if it is empty. The optional network is
+// "mainnet" or "testnet" and defaults to mainnet.
+func (api *TssApi) GetAddresses(keyType string, keyLabel string, bech32Prefix string, network *string) (*types.Addresses, error) {
+	return api.heart.GetAddresses(keyType, keyLabel, bech32Prefix, getOptionalString(network))
+}
+
+// GetDerivedPubKey returns the public key of the non-hardened child key at a derivation path, e.g.
+// "m/0/1", of the TSS key with a type and a label.
+func (api *TssApi) GetDerivedPubKey(keyType string, keyLabel string, path string) ([]byte, error) {
+	return api.heart.GetDerivedPubKey(keyType, keyLabel, path)
+}
+
+// GetPreparamsStatus returns the state of the pool of preparams used by ECDSA keygens.
+func (api *TssApi) GetPreparamsStatus() (*types.PreparamsPoolStatus, error) {
+	return api.heart.GetPreparamsStatus()
+}
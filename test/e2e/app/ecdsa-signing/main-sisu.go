@@ -124,7 +124,7 @@ func insertKeygenData(n, index int) {
 
 	pids := worker.GetTestPartyIds(n)
 	keygenOutput := worker.LoadEcKeygenSavedData(pids)[index]
-	err = database.SaveEcKeygen(libchain.KEY_TYPE_ECDSA, "keygen0", pids, keygenOutput)
+	err = database.SaveEcKeygen(libchain.KEY_TYPE_ECDSA, "", "keygen0", pids, keygenOutput)
 	if err != nil {
 		panic(err)
 	}
@@ -119,7 +119,7 @@ func insertKeygenData(n, index int) {
 
 	pids := worker.GetTestPartyIds(n)
 	keygenOutput := worker.LoadEdKeygenSavedData(pids)[index]
-	err = database.SaveEdKeygen(libchain.KEY_TYPE_EDDSA, "keygen0", pids, keygenOutput)
+	err = database.SaveEdKeygen(libchain.KEY_TYPE_EDDSA, "", "keygen0", pids, keygenOutput)
 	if err != nil {
 		panic(err)
 	}
@@ -105,7 +105,7 @@ func main() {
 	}
 
 	heart.SetSisuReady(true)
-	heart.Keygen("keygenId", "ecdsa", pubkeys)
+	heart.Keygen("keygenId", "ecdsa", "", pubkeys)
 
 	select {
 	case <-time.After(time.Second * 30):
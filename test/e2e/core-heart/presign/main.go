@@ -101,9 +101,10 @@ func main() {
 	}
 
 	heartConfig := config.HeartConfig{
-		Db:         dbConfig,
-		AesKey:     aesKey,
-		Connection: cfg.Connection,
+		Db:            dbConfig,
+		AesKey:        aesKey,
+		Connection:    cfg.Connection,
+		EnablePresign: true,
 	}
 	pubkeys := getPublicKeys(n)
 
@@ -121,7 +122,7 @@ func main() {
 	}
 
 	heart.SetSisuReady(true)
-	heart.Keygen("keygenId", "ecdsa", pubkeys)
+	heart.Keygen("keygenId", "ecdsa", "", pubkeys)
 	select {
 	case <-time.After(time.Second * 30):
 		panic("Time out")
@@ -35,13 +35,13 @@ func testInsertingKeygenData(database db.Database) {
 	}
 
 	// Write data
-	err := database.SaveEcKeygen(libchain.GetKeygenType(chain), workId, pids, output[0])
+	err := database.SaveEcKeygen(libchain.GetKeygenType(chain), "", workId, pids, output[0])
 	if err != nil {
 		panic(err)
 	}
 
 	// Read data and do sanity check
-	loaded, err := database.LoadEcKeygen(libchain.GetKeyTypeForChain(chain))
+	loaded, err := database.LoadEcKeygen(libchain.GetKeyTypeForChain(chain), "")
 	if err != nil {
 		panic(err)
 	}
@@ -76,13 +76,13 @@ func testInsertingPresignData(database db.Database) {
 		},
 	}
 
-	err := database.SavePresignData(workId, pids, output)
+	err := database.SavePresignData("", workId, pids, output)
 	if err != nil {
 		panic(err)
 	}
 
 	// Data data
-	presignIds, _, err := database.GetAvailablePresignShortForm()
+	presignIds, _, _, _, err := database.GetAvailablePresignShortForm()
 	if err != nil {
 		panic(err)
 	}
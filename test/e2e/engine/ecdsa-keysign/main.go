@@ -255,7 +255,7 @@ func main() {
 	// Keygen
 	keygenResult := doKeygen(pids, index, engine, keygenCh)
 
-	presignInput, err := database.LoadEcKeygen(libchain.KEY_TYPE_ECDSA)
+	presignInput, err := database.LoadEcKeygen(libchain.KEY_TYPE_ECDSA, "")
 	if err != nil {
 		panic(err)
 	}
@@ -2,7 +2,6 @@ package helper
 
 import (
 	"crypto/rand"
-	"encoding/json"
 	"math/big"
 
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -22,8 +21,8 @@ func (scm *SlowConnectionManager) Start(privKeyBytes []byte, keyType string) err
 }
 
 func (scm *SlowConnectionManager) WriteToStream(pID peer.ID, protocolId protocol.ID, msg []byte) error {
-	signedMsg := &common.SignedMessage{}
-	if err := json.Unmarshal(msg, signedMsg); err != nil {
+	signedMsg, err := common.UnmarshalSignedMessage(msg)
+	if err != nil {
 		panic(err)
 	}
 
@@ -48,6 +47,26 @@ func (scm *SlowConnectionManager) IsReady() bool {
 	return scm.cm.IsReady()
 }
 
+func (scm *SlowConnectionManager) GetPeerStatuses() []p2p.PeerStatus {
+	return scm.cm.GetPeerStatuses()
+}
+
+func (scm *SlowConnectionManager) AddPeer(peer *p2pTypes.Peer) error {
+	return scm.cm.AddPeer(peer)
+}
+
+func (scm *SlowConnectionManager) RemovePeer(pID peer.ID) error {
+	return scm.cm.RemovePeer(pID)
+}
+
+func (scm *SlowConnectionManager) IsPeerDown(pID peer.ID) bool {
+	return scm.cm.IsPeerDown(pID)
+}
+
+func (scm *SlowConnectionManager) Stop() error {
+	return scm.cm.Stop()
+}
+
 func NewSlowConnectionManager(config p2pTypes.ConnectionsConfig) p2p.ConnectionManager {
 	return &SlowConnectionManager{
 		cm: p2p.NewConnectionManager(config),
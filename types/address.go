@@ -0,0 +1,31 @@
+package types
+
+// Networks of the addresses of a key.
+const (
+	NetworkMainnet = "mainnet"
+	NetworkTestnet = "testnet"
+)
+
+// Addresses are the addresses of a TSS public key on the supported chains. The addresses of chains
+// that do not use the key type are empty.
+type Addresses struct {
+	KeyType  string
+	KeyLabel string
+	PubKey   []byte
+	Network  string
+
+	// ECDSA keys.
+	Ethereum      string
+	BitcoinP2PKH  string
+	BitcoinP2WPKH string
+	Cosmos        string
+
+	// Schnorr keys. The P2TR address pays to the BIP-86 output key of the key without a script tree.
+	BitcoinP2TR string
+
+	// EdDSA keys.
+	Solana            string
+	CardanoEnterprise string
+	// The base address uses the key as both the payment and the stake credential.
+	CardanoBase string
+}
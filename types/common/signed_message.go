@@ -0,0 +1,147 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// SignedMessageVersionProto is the version byte of signed messages encoded in binary protobuf.
+	SignedMessageVersionProto byte = 1
+
+	// Signed messages sent by old nodes are JSON objects which always start with this byte.
+	signedMessageJsonPrefix byte = '{'
+
+	// Protobuf field numbers used to read the type of a signed message without decoding it.
+	signedMessageTssMessageField protowire.Number = 2
+	tssMessageTypeField          protowire.Number = 1
+)
+
+var (
+	ErrEmptySignedMessage          = errors.New("signed message is empty")
+	ErrUnknownSignedMessageVersion = errors.New("unknown signed message version")
+)
+
+// MarshalSignedMessage serializes a signed message to send to the network. The first byte is the
+// version of the encoding and the rest is the protobuf bytes of the message.
+func MarshalSignedMessage(msg *SignedMessage) ([]byte, error) {
+	bz, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{SignedMessageVersionProto}, bz...), nil
+}
+
+// UnmarshalSignedMessage deserializes a signed message received from the network. Messages in the
+// legacy JSON encoding are still accepted so that upgraded nodes can talk to nodes that have not
+// been upgraded during a rolling upgrade.
+func UnmarshalSignedMessage(bz []byte) (*SignedMessage, error) {
+	if len(bz) == 0 {
+		return nil, ErrEmptySignedMessage
+	}
+
+	msg := &SignedMessage{}
+	switch bz[0] {
+	case SignedMessageVersionProto:
+		if err := proto.Unmarshal(bz[1:], msg); err != nil {
+			return nil, err
+		}
+	case signedMessageJsonPrefix:
+		if err := json.Unmarshal(bz, msg); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownSignedMessageVersion, bz[0])
+	}
+
+	return msg, nil
+}
+
+// GetSignBytes returns the canonical bytes of a tss message that are signed by its sender. The
+// bytes are the version byte followed by the deterministic protobuf encoding of the message.
+func (msg *TssMessage) GetSignBytes() ([]byte, error) {
+	bz, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{SignedMessageVersionProto}, bz...), nil
+}
+
+// GetLegacySignBytes returns the bytes of a tss message that are signed by nodes that send signed
+// messages in the legacy JSON encoding.
+func (msg *TssMessage) GetLegacySignBytes() ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+// IsLegacySignedMessage returns true if a serialized signed message is in the legacy JSON encoding.
+func IsLegacySignedMessage(bz []byte) bool {
+	return len(bz) > 0 && bz[0] == signedMessageJsonPrefix
+}
+
+// GetSignedMessageType returns the type of the tss message in a serialized signed message. Only
+// the type field is read so this is much cheaper than UnmarshalSignedMessage.
+func GetSignedMessageType(bz []byte) (TssMessage_Type, error) {
+	if len(bz) == 0 {
+		return 0, ErrEmptySignedMessage
+	}
+
+	switch bz[0] {
+	case SignedMessageVersionProto:
+		tssMsg, err := findProtoField(bz[1:], signedMessageTssMessageField, protowire.BytesType)
+		if err != nil || tssMsg == nil {
+			return 0, err
+		}
+
+		msgType, err := findProtoField(tssMsg, tssMessageTypeField, protowire.VarintType)
+		if err != nil || msgType == nil {
+			// A missing field has the default value.
+			return 0, err
+		}
+
+		v, _ := protowire.ConsumeVarint(msgType)
+		return TssMessage_Type(v), nil
+	case signedMessageJsonPrefix:
+		msg, err := UnmarshalSignedMessage(bz)
+		if err != nil {
+			return 0, err
+		}
+
+		return msg.GetTssMessage().GetType(), nil
+	default:
+		return 0, fmt.Errorf("%w: %d", ErrUnknownSignedMessageVersion, bz[0])
+	}
+}
+
+// findProtoField returns the value of the last occurrence of a field in protobuf bytes. The value of
+// a bytes field is returned without its length prefix. It returns nil if the field is not found.
+func findProtoField(bz []byte, field protowire.Number, wireType protowire.Type) ([]byte, error) {
+	var value []byte
+	for len(bz) > 0 {
+		num, typ, n := protowire.ConsumeTag(bz)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		bz = bz[n:]
+
+		n = protowire.ConsumeFieldValue(num, typ, bz)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+
+		if num == field && typ == wireType {
+			value = bz[:n]
+			if typ == protowire.BytesType {
+				value, _ = protowire.ConsumeBytes(value)
+			}
+		}
+		bz = bz[n:]
+	}
+
+	return value, nil
+}
@@ -0,0 +1,117 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func getTestSignedMessage() *SignedMessage {
+	return &SignedMessage{
+		From: "node0",
+		TssMessage: &TssMessage{
+			Type:   TssMessage_UPDATE_MESSAGES,
+			From:   "node0",
+			WorkId: "keygen0",
+			UpdateMessages: []*UpdateMessage{
+				{
+					Data:                     bytes.Repeat([]byte{0xab}, 4096),
+					SerializedMessageRouting: []byte(`{"From":"node0"}`),
+					Round:                    "KGRound2Message1",
+				},
+			},
+		},
+		Signature: []byte("signature"),
+	}
+}
+
+func TestSignedMessage_MarshalUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	msg := getTestSignedMessage()
+	bz, err := MarshalSignedMessage(msg)
+	require.NoError(t, err)
+	require.Equal(t, SignedMessageVersionProto, bz[0])
+	require.False(t, IsLegacySignedMessage(bz))
+
+	decoded, err := UnmarshalSignedMessage(bz)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(msg, decoded))
+
+	// The protobuf encoding is much smaller than JSON with base64 data.
+	jsonBz, err := json.Marshal(msg)
+	require.NoError(t, err)
+	require.Less(t, len(bz), len(jsonBz))
+}
+
+func TestSignedMessage_UnmarshalLegacyJson(t *testing.T) {
+	t.Parallel()
+
+	msg := getTestSignedMessage()
+	bz, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	decoded, err := UnmarshalSignedMessage(bz)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(msg, decoded))
+
+	require.True(t, IsLegacySignedMessage(bz))
+
+	// The sign bytes of the decoded message are the bytes signed by the old node.
+	signBz, err := json.Marshal(msg.TssMessage)
+	require.NoError(t, err)
+	legacySignBz, err := decoded.TssMessage.GetLegacySignBytes()
+	require.NoError(t, err)
+	require.Equal(t, signBz, legacySignBz)
+
+	_, err = UnmarshalSignedMessage(nil)
+	require.ErrorIs(t, err, ErrEmptySignedMessage)
+
+	_, err = UnmarshalSignedMessage([]byte{0xff, 0x01})
+	require.ErrorIs(t, err, ErrUnknownSignedMessageVersion)
+}
+
+func TestTssMessage_GetSignBytes(t *testing.T) {
+	t.Parallel()
+
+	bz1, err := getTestSignedMessage().TssMessage.GetSignBytes()
+	require.NoError(t, err)
+	bz2, err := getTestSignedMessage().TssMessage.GetSignBytes()
+	require.NoError(t, err)
+	require.Equal(t, bz1, bz2)
+
+	other := getTestSignedMessage().TssMessage
+	other.WorkId = "keygen1"
+	bz3, err := other.GetSignBytes()
+	require.NoError(t, err)
+	require.NotEqual(t, bz1, bz3)
+}
+
+func TestGetSignedMessageType(t *testing.T) {
+	t.Parallel()
+
+	for _, msgType := range []TssMessage_Type{TssMessage_UPDATE_MESSAGES, TssMessage_AVAILABILITY_REQUEST, TssMessage_ASK_MESSAGE_REQUEST} {
+		msg := getTestSignedMessage()
+		msg.TssMessage.Type = msgType
+
+		bz, err := MarshalSignedMessage(msg)
+		require.NoError(t, err)
+		parsed, err := GetSignedMessageType(bz)
+		require.NoError(t, err)
+		require.Equal(t, msgType, parsed)
+
+		jsonBz, err := json.Marshal(msg)
+		require.NoError(t, err)
+		parsed, err = GetSignedMessageType(jsonBz)
+		require.NoError(t, err)
+		require.Equal(t, msgType, parsed)
+	}
+
+	bz, err := MarshalSignedMessage(getTestSignedMessage())
+	require.NoError(t, err)
+	_, err = GetSignedMessageType(bz[:len(bz)/2])
+	require.Error(t, err)
+}
@@ -44,11 +44,13 @@ func NewAvailabilityRequestMessage(from, to, workId string) *TssMessage {
 	return msg
 }
 
-func NewAvailabilityResponseMessage(from, to, workId string, answer AvailabilityResponseMessage_ANSWER, maxJob int) *TssMessage {
+func NewAvailabilityResponseMessage(from, to, workId string, answer AvailabilityResponseMessage_ANSWER, maxJob int,
+	presignCount int) *TssMessage {
 	msg := baseMessage(TssMessage_AVAILABILITY_RESPONSE, from, to, workId)
 	msg.AvailabilityResponseMessage = &AvailabilityResponseMessage{
-		Answer: AvailabilityResponseMessage_YES,
-		MaxJob: int32(maxJob),
+		Answer:       answer,
+		MaxJob:       int32(maxJob),
+		PresignCount: int32(presignCount),
 	}
 
 	return msg
@@ -4,8 +4,15 @@ import (
 	"github.com/sisu-network/tss-lib/tss"
 )
 
+// KeyTypeSchnorr is the key type of BIP-340 Schnorr keys on secp256k1, which are used by Bitcoin
+// Taproot outputs. The key is stored and signs untweaked; a keysign request sets TapTweak to sign
+// with the BIP-341 output key instead. The ECDSA and EdDSA key types are defined by the chain
+// library.
+const KeyTypeSchnorr = "schnorr"
+
 type KeygenResult struct {
 	KeyType     string
+	KeyLabel    string
 	KeygenIndex int
 	PubKeyBytes []byte
 	Outcome     OutcomeType
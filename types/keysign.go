@@ -1,9 +1,45 @@
 package types
 
-import "github.com/sisu-network/tss-lib/tss"
+import (
+	"math/big"
+
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+// SignatureFormat is the encoding of a signature in a KeysignResult.
+type SignatureFormat string
+
+const (
+	// R || S || V for ECDSA signatures and the tss-lib encoding (a standard ed25519 signature) for
+	// EdDSA signatures.
+	SignatureFormatRaw SignatureFormat = ""
+	// R || S || V of an Ethereum transaction. V follows EIP-155 if the message has a chain id.
+	SignatureFormatEthereum SignatureFormat = "ethereum"
+	// DER encoding of R and S followed by the sighash type.
+	SignatureFormatBitcoin SignatureFormat = "bitcoin"
+	// 64-byte compact R || S.
+	SignatureFormatCosmos SignatureFormat = "cosmos"
+	// 64-byte ed25519 signature used by Solana and Cardano.
+	SignatureFormatEd25519 SignatureFormat = "ed25519"
+	// 64-byte BIP-340 signature of a Taproot key path spend followed by the sighash type unless it is
+	// SIGHASH_DEFAULT (0).
+	SignatureFormatTaproot SignatureFormat = "taproot"
+)
 
 type KeysignRequest struct {
-	KeyType         string
+	KeyType string
+	// KeyLabel names the key used for signing when there are several keys of the same key type.
+	// The empty label is the default key.
+	KeyLabel string
+	// Nonce is a unique number assigned to this request by the caller.
+	Nonce uint64
+	// Attempt is the number of times this request has been retried. A retried request must have a
+	// different attempt number to get a new work id.
+	Attempt int
+	// TapTweak signs with the BIP-341 output key of a Schnorr key, i.e. the key tweaked with the
+	// TapTweak hash of itself and no script tree (a BIP-86 key path spend). It is only valid for
+	// Schnorr keys. The tweak is applied after the derivation path of the messages.
+	TapTweak        bool
 	KeysignMessages []*KeysignMessage
 }
 
@@ -14,6 +50,19 @@ type KeysignMessage struct {
 	OutHash     string
 	Bytes       []byte
 	BytesToSign []byte
+
+	// SignatureFormat is the encoding of the signature of this message in the KeysignResult.
+	SignatureFormat SignatureFormat
+	// ChainId is the chain id used by the Ethereum format.
+	ChainId *big.Int
+	// SighashType is the sighash type appended by the Bitcoin and Taproot formats. The Bitcoin
+	// format uses SIGHASH_ALL if it is 0.
+	SighashType byte
+
+	// DerivationPath is the path of the non-hardened child key of the TSS key that signs this
+	// message, e.g. "m/0/1". The empty path signs with the TSS key itself. All messages of a
+	// request must use the same path.
+	DerivationPath string
 }
 
 type KeysignResult struct {
@@ -0,0 +1,17 @@
+package types
+
+// PeerInfo is a peer of this node and the state of the connection to the peer.
+type PeerInfo struct {
+	Address    string
+	PubKey     string
+	PubKeyType string
+	PeerId     string
+	State      string
+	Retries    int
+	LastError  string
+	Healthy    bool
+	// Round trip time of the last ping in milliseconds.
+	RttMs int64
+	// The number of offenses of the peer, e.g. exceeding its rate limit.
+	Offenses int
+}
@@ -0,0 +1,16 @@
+package types
+
+// PreparamsPoolStatus is the state of the pool of preparams used by ECDSA keygens.
+type PreparamsPoolStatus struct {
+	// The number of preparams that are ready for a keygen.
+	Available int
+	// The number of preparams used by past keygens.
+	Used int
+	// The generator tops up the pool to this number of available preparams.
+	Target int
+	// True if the generator is generating preparams.
+	Generating bool
+	// True if the generator waits because the cpu load is above its budget.
+	Throttled bool
+	LastError string
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/echovl/cardano-go"
+	"github.com/enigmampc/btcutil/base58"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sisu-network/dheart/types"
+	libchain "github.com/sisu-network/lib/chain"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const (
+	DefaultBech32Prefix = "cosmos"
+	// Addresses are on mainnet unless a network is given.
+	DefaultNetwork = types.NetworkMainnet
+)
+
+var (
+	ErrInvalidPubKey  = errors.New("invalid public key")
+	ErrInvalidNetwork = errors.New("invalid network")
+)
+
+// addressNetwork has the parameters of the addresses of a network.
+type addressNetwork struct {
+	bitcoinP2PKHVersion byte
+	bitcoinSegwitHrp    string
+	cardano             cardano.Network
+}
+
+var addressNetworks = map[string]addressNetwork{
+	types.NetworkMainnet: {bitcoinP2PKHVersion: 0x00, bitcoinSegwitHrp: "bc", cardano: cardano.Mainnet},
+	types.NetworkTestnet: {bitcoinP2PKHVersion: 0x6f, bitcoinSegwitHrp: "tb", cardano: cardano.Testnet},
+}
+
+// GetAddresses returns the addresses of a public key on a network for all chains that use its key
+// type. ECDSA keys are given in their compressed or uncompressed form, EdDSA keys as 32-byte ed25519
+// keys and Schnorr keys in their 32-byte x-only form, the format of keygen results. Cosmos addresses use the given bech32 prefix or
+// "cosmos" if the prefix is empty. The network is either mainnet or testnet, or DefaultNetwork if it
+// is empty.
+func GetAddresses(keyType string, pubKey []byte, bech32Prefix string, network string) (*types.Addresses, error) {
+	if network == "" {
+		network = DefaultNetwork
+	}
+	params, ok := addressNetworks[network]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidNetwork, network)
+	}
+
+	var addresses *types.Addresses
+	var err error
+	switch keyType {
+	case libchain.KEY_TYPE_ECDSA:
+		addresses, err = getEcAddresses(pubKey, bech32Prefix, params)
+	case libchain.KEY_TYPE_EDDSA:
+		addresses, err = getEdAddresses(pubKey, params)
+	case types.KeyTypeSchnorr:
+		addresses, err = getSchnorrAddresses(pubKey, params)
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", keyType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	addresses.Network = network
+
+	return addresses, nil
+}
+
+func getEcAddresses(pubKey []byte, bech32Prefix string, params addressNetwork) (*types.Addresses, error) {
+	var compressed []byte
+	switch len(pubKey) {
+	case 33:
+		if _, err := crypto.DecompressPubkey(pubKey); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPubKey, err)
+		}
+		compressed = pubKey
+	case 65:
+		key, err := crypto.UnmarshalPubkey(pubKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPubKey, err)
+		}
+		compressed = crypto.CompressPubkey(key)
+	default:
+		return nil, fmt.Errorf("%w: ECDSA key has %d bytes", ErrInvalidPubKey, len(pubKey))
+	}
+
+	key, _ := crypto.DecompressPubkey(compressed)
+	keyHash := hash160(compressed)
+
+	p2wpkh, err := encodeSegwitAddress(params.bitcoinSegwitHrp, 0, keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if bech32Prefix == "" {
+		bech32Prefix = DefaultBech32Prefix
+	}
+	cosmos, err := bech32.ConvertAndEncode(bech32Prefix, keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Addresses{
+		KeyType:       libchain.KEY_TYPE_ECDSA,
+		PubKey:        pubKey,
+		Ethereum:      crypto.PubkeyToAddress(*key).Hex(),
+		BitcoinP2PKH:  base58.CheckEncode(keyHash, params.bitcoinP2PKHVersion),
+		BitcoinP2WPKH: p2wpkh,
+		Cosmos:        cosmos,
+	}, nil
+}
+
+func getEdAddresses(pubKey []byte, params addressNetwork) (*types.Addresses, error) {
+	if len(pubKey) != 32 {
+		return nil, fmt.Errorf("%w: EdDSA key has %d bytes", ErrInvalidPubKey, len(pubKey))
+	}
+
+	enterprise, err := GetCardanoEnterpriseAddress(pubKey, params.cardano)
+	if err != nil {
+		return nil, err
+	}
+	credential := enterprise.Payment
+	base, err := cardano.NewBaseAddress(params.cardano, credential, credential)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Addresses{
+		KeyType:           libchain.KEY_TYPE_EDDSA,
+		PubKey:            pubKey,
+		Solana:            base58.Encode(pubKey),
+		CardanoEnterprise: enterprise.Bech32(),
+		CardanoBase:       base.Bech32(),
+	}, nil
+}
+
+func getSchnorrAddresses(pubKey []byte, params addressNetwork) (*types.Addresses, error) {
+	internalKey, err := ParseSchnorrPubKey(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	_, outputKey, err := TapTweakPubKey(internalKey)
+	if err != nil {
+		return nil, err
+	}
+
+	p2tr, err := encodeSegwitAddress(params.bitcoinSegwitHrp, 1, SerializeSchnorrPubKey(outputKey))
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Addresses{
+		KeyType:     types.KeyTypeSchnorr,
+		PubKey:      pubKey,
+		BitcoinP2TR: p2tr,
+	}, nil
+}
+
+// hash160 returns RIPEMD160(SHA256(data)), the key hash of Bitcoin and Cosmos addresses.
+func hash160(data []byte) []byte {
+	sha := sha256.Sum256(data)
+	hasher := ripemd160.New()
+	hasher.Write(sha[:])
+
+	return hasher.Sum(nil)
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/echovl/cardano-go"
+	"github.com/ethereum/go-ethereum/crypto"
+	libchain "github.com/sisu-network/lib/chain"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sisu-network/dheart/types"
+)
+
+func TestGetAddresses_Ecdsa(t *testing.T) {
+	t.Parallel()
+
+	// The key with private key 1.
+	key, err := crypto.HexToECDSA("0000000000000000000000000000000000000000000000000000000000000001")
+	require.NoError(t, err)
+
+	for _, pubKey := range [][]byte{crypto.FromECDSAPub(&key.PublicKey), crypto.CompressPubkey(&key.PublicKey)} {
+		addresses, err := GetAddresses(libchain.KEY_TYPE_ECDSA, pubKey, "", types.NetworkMainnet)
+		require.NoError(t, err)
+		require.Equal(t, types.NetworkMainnet, addresses.Network)
+
+		require.Equal(t, "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf", addresses.Ethereum)
+		require.Equal(t, "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH", addresses.BitcoinP2PKH)
+		require.Equal(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", addresses.BitcoinP2WPKH)
+		require.Empty(t, addresses.Solana)
+		require.Empty(t, addresses.CardanoEnterprise)
+
+		hrp, keyHash, err := bech32.DecodeAndConvert(addresses.Cosmos)
+		require.NoError(t, err)
+		require.Equal(t, DefaultBech32Prefix, hrp)
+		require.Equal(t, "751e76e8199196d454941c45d1b3a323f1433bd6", hex.EncodeToString(keyHash))
+	}
+
+	addresses, err := GetAddresses(libchain.KEY_TYPE_ECDSA, crypto.FromECDSAPub(&key.PublicKey), "sisu", "")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(addresses.Cosmos, "sisu1"))
+
+	// Mainnet addresses are the default.
+	require.Equal(t, types.NetworkMainnet, addresses.Network)
+	require.Equal(t, "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH", addresses.BitcoinP2PKH)
+
+	addresses, err = GetAddresses(libchain.KEY_TYPE_ECDSA, crypto.FromECDSAPub(&key.PublicKey), "", types.NetworkTestnet)
+	require.NoError(t, err)
+	require.Equal(t, "mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r", addresses.BitcoinP2PKH)
+	require.Equal(t, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", addresses.BitcoinP2WPKH)
+
+	_, err = GetAddresses(libchain.KEY_TYPE_ECDSA, []byte{1, 2, 3}, "", "")
+	require.True(t, errors.Is(err, ErrInvalidPubKey))
+
+	_, err = GetAddresses(libchain.KEY_TYPE_ECDSA, crypto.FromECDSAPub(&key.PublicKey), "", "regtest")
+	require.True(t, errors.Is(err, ErrInvalidNetwork))
+}
+
+func TestGetAddresses_Eddsa(t *testing.T) {
+	t.Parallel()
+
+	addresses, err := GetAddresses(libchain.KEY_TYPE_EDDSA, make([]byte, 32), "", types.NetworkMainnet)
+	require.NoError(t, err)
+	require.Equal(t, "11111111111111111111111111111111", addresses.Solana)
+	require.Empty(t, addresses.Ethereum)
+
+	keyHash, err := cardano.Blake224Hash(make([]byte, 32))
+	require.NoError(t, err)
+
+	enterprise, err := cardano.NewAddress(addresses.CardanoEnterprise)
+	require.NoError(t, err)
+	require.Equal(t, cardano.Enterprise, enterprise.Type)
+	require.Equal(t, cardano.Mainnet, enterprise.Network)
+	require.Equal(t, cardano.Hash28(keyHash), enterprise.Payment.KeyHash)
+
+	base, err := cardano.NewAddress(addresses.CardanoBase)
+	require.NoError(t, err)
+	require.Equal(t, cardano.Base, base.Type)
+	require.Equal(t, cardano.Hash28(keyHash), base.Payment.KeyHash)
+	require.Equal(t, cardano.Hash28(keyHash), base.Stake.KeyHash)
+
+	_, err = GetAddresses(libchain.KEY_TYPE_EDDSA, make([]byte, 33), "", "")
+	require.True(t, errors.Is(err, ErrInvalidPubKey))
+}
+
+func TestGetAddresses_CardanoMatchesExistingHelper(t *testing.T) {
+	t.Parallel()
+
+	pubKey := make([]byte, 32)
+	pubKey[0] = 1
+
+	// Testnet gives the same enterprise address as GetAddressFromCardanoPubkey.
+	expected, err := GetAddressFromCardanoPubkey(pubKey)
+	require.NoError(t, err)
+	addresses, err := GetAddresses(libchain.KEY_TYPE_EDDSA, pubKey, "", types.NetworkTestnet)
+	require.NoError(t, err)
+	require.Equal(t, expected.Bech32(), addresses.CardanoEnterprise)
+
+	base, err := cardano.NewAddress(addresses.CardanoBase)
+	require.NoError(t, err)
+	require.Equal(t, cardano.Testnet, base.Network)
+
+	expected, err = GetCardanoEnterpriseAddress(pubKey, cardano.Mainnet)
+	require.NoError(t, err)
+	addresses, err = GetAddresses(libchain.KEY_TYPE_EDDSA, pubKey, "", "")
+	require.NoError(t, err)
+	require.Equal(t, expected.Bech32(), addresses.CardanoEnterprise)
+}
+
+func TestGetAddresses_Schnorr(t *testing.T) {
+	t.Parallel()
+
+	// The internal key and address of the first receiving address of the BIP-86 test vectors.
+	pubKey, err := hex.DecodeString("cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115")
+	require.NoError(t, err)
+
+	addresses, err := GetAddresses(types.KeyTypeSchnorr, pubKey, "", "")
+	require.NoError(t, err)
+	require.Equal(t, types.KeyTypeSchnorr, addresses.KeyType)
+	require.Equal(t, types.NetworkMainnet, addresses.Network)
+	require.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", addresses.BitcoinP2TR)
+	require.Empty(t, addresses.BitcoinP2WPKH)
+
+	addresses, err = GetAddresses(types.KeyTypeSchnorr, pubKey, "", types.NetworkTestnet)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(addresses.BitcoinP2TR, "tb1p"))
+
+	// Schnorr keys are x-only.
+	key, err := crypto.HexToECDSA("0000000000000000000000000000000000000000000000000000000000000001")
+	require.NoError(t, err)
+	_, err = GetAddresses(types.KeyTypeSchnorr, crypto.CompressPubkey(&key.PublicKey), "", "")
+	require.True(t, errors.Is(err, ErrInvalidPubKey))
+}
+
+func TestEncodeSegwitAddress(t *testing.T) {
+	t.Parallel()
+
+	// Test vectors of BIP-173 and BIP-350.
+	program, err := hex.DecodeString("751e76e8199196d454941c45d1b3a323f1433bd6")
+	require.NoError(t, err)
+	address, err := encodeSegwitAddress("bc", 0, program)
+	require.NoError(t, err)
+	require.Equal(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", address)
+
+	address, err = encodeSegwitAddress("bc", 1, append(program, program...))
+	require.NoError(t, err)
+	require.Equal(t, "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", address)
+
+	program, err = hex.DecodeString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	require.NoError(t, err)
+	address, err = encodeSegwitAddress("tb", 1, program)
+	require.NoError(t, err)
+	require.Equal(t, "tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq47zagq", address)
+}
@@ -1,18 +1,125 @@
 package utils
 
-import "github.com/echovl/cardano-go"
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/echovl/cardano-go"
+	"github.com/sisu-network/dheart/types"
+	libchain "github.com/sisu-network/lib/chain"
+)
+
+const (
+	SighashAll = 0x01
+)
+
+var (
+	ErrUnsupportedSignatureFormat = errors.New("unsupported signature format")
+)
 
 func GetAddressFromCardanoPubkey(pubkey []byte) (cardano.Address, error) {
+	return GetCardanoEnterpriseAddress(pubkey, cardano.Testnet)
+}
+
+// GetCardanoEnterpriseAddress returns the enterprise address of a pubkey on a Cardano network.
+func GetCardanoEnterpriseAddress(pubkey []byte, network cardano.Network) (cardano.Address, error) {
 	keyHash, err := cardano.Blake224Hash(pubkey)
 	if err != nil {
 		return cardano.Address{}, err
 	}
 
 	payment := cardano.StakeCredential{Type: cardano.KeyCredential, KeyHash: keyHash}
-	enterpriseAddr, err := cardano.NewEnterpriseAddress(0, payment)
+	enterpriseAddr, err := cardano.NewEnterpriseAddress(network, payment)
 	if err != nil {
 		return cardano.Address{}, err
 	}
 
 	return enterpriseAddr, nil
 }
+
+// EncodeSignature encodes a signature of a message in the format asked by the message. ECDSA
+// signatures are given as R || S || V with a low S, EdDSA signatures as 64-byte ed25519 signatures
+// and Schnorr signatures as 64-byte BIP-340 signatures.
+func EncodeSignature(keyType string, signature []byte, msg *types.KeysignMessage) ([]byte, error) {
+	switch msg.SignatureFormat {
+	case types.SignatureFormatRaw:
+		return signature, nil
+
+	case types.SignatureFormatEthereum, types.SignatureFormatBitcoin, types.SignatureFormatCosmos:
+		if keyType != libchain.KEY_TYPE_ECDSA || len(signature) != 65 {
+			return nil, fmt.Errorf("%w: %s needs an ECDSA signature", ErrUnsupportedSignatureFormat,
+				msg.SignatureFormat)
+		}
+
+	case types.SignatureFormatEd25519:
+		if keyType != libchain.KEY_TYPE_EDDSA || len(signature) != 64 {
+			return nil, fmt.Errorf("%w: %s needs an EdDSA signature", ErrUnsupportedSignatureFormat,
+				msg.SignatureFormat)
+		}
+
+		return signature, nil
+
+	case types.SignatureFormatTaproot:
+		if keyType != types.KeyTypeSchnorr || len(signature) != 64 {
+			return nil, fmt.Errorf("%w: %s needs a Schnorr signature", ErrUnsupportedSignatureFormat,
+				msg.SignatureFormat)
+		}
+
+		// SIGHASH_DEFAULT is implied by a 64-byte signature.
+		if msg.SighashType == 0 {
+			return signature, nil
+		}
+
+		return append(append([]byte{}, signature...), msg.SighashType), nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedSignatureFormat, msg.SignatureFormat)
+	}
+
+	r, s, recovery := signature[:32], signature[32:64], signature[64]
+	switch msg.SignatureFormat {
+	case types.SignatureFormatEthereum:
+		return encodeEthereumSignature(r, s, recovery, msg.ChainId), nil
+
+	case types.SignatureFormatBitcoin:
+		return encodeBitcoinSignature(r, s, msg.SighashType)
+
+	default:
+		return append(append([]byte{}, r...), s...), nil
+	}
+}
+
+// encodeEthereumSignature returns R || S || V. V is chainId * 2 + 35 + recovery (EIP-155) or
+// 27 + recovery for a signature without chain id. V is encoded in big endian with as few bytes as
+// possible.
+func encodeEthereumSignature(r, s []byte, recovery byte, chainId *big.Int) []byte {
+	v := big.NewInt(27 + int64(recovery))
+	if chainId != nil && chainId.Sign() > 0 {
+		v = new(big.Int).Mul(chainId, big.NewInt(2))
+		v.Add(v, big.NewInt(35+int64(recovery)))
+	}
+
+	encoded := append(append([]byte{}, r...), s...)
+	return append(encoded, v.Bytes()...)
+}
+
+// encodeBitcoinSignature returns the DER encoding of R and S followed by the sighash type.
+func encodeBitcoinSignature(r, s []byte, sighashType byte) ([]byte, error) {
+	der, err := asn1.Marshal(struct {
+		R, S *big.Int
+	}{
+		R: new(big.Int).SetBytes(r),
+		S: new(big.Int).SetBytes(s),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if sighashType == 0 {
+		sighashType = SighashAll
+	}
+
+	return append(der, sighashType), nil
+}
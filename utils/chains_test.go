@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sisu-network/dheart/types"
+	libchain "github.com/sisu-network/lib/chain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeSignature_Ecdsa(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	hash := crypto.Keccak256([]byte("message"))
+	signature, err := crypto.Sign(hash, key)
+	require.NoError(t, err)
+	pubkey := crypto.FromECDSAPub(&key.PublicKey)
+
+	encode := func(msg *types.KeysignMessage) []byte {
+		encoded, err := EncodeSignature(libchain.KEY_TYPE_ECDSA, signature, msg)
+		require.NoError(t, err)
+		return encoded
+	}
+
+	require.Equal(t, signature, encode(&types.KeysignMessage{}))
+
+	// Ethereum
+	encoded := encode(&types.KeysignMessage{
+		SignatureFormat: types.SignatureFormatEthereum,
+		ChainId:         big.NewInt(56),
+	})
+	require.Equal(t, signature[:64], encoded[:64])
+	require.Equal(t, []byte{56*2 + 35 + signature[64]}, encoded[64:])
+
+	encoded = encode(&types.KeysignMessage{
+		SignatureFormat: types.SignatureFormatEthereum,
+		ChainId:         big.NewInt(1337),
+	})
+	require.Equal(t, big.NewInt(1337*2+35+int64(signature[64])).Bytes(), encoded[64:])
+
+	encoded = encode(&types.KeysignMessage{SignatureFormat: types.SignatureFormatEthereum})
+	require.Equal(t, []byte{27 + signature[64]}, encoded[64:])
+
+	// Bitcoin
+	encoded = encode(&types.KeysignMessage{SignatureFormat: types.SignatureFormatBitcoin})
+	require.Equal(t, byte(SighashAll), encoded[len(encoded)-1])
+	var der struct {
+		R, S *big.Int
+	}
+	rest, err := asn1.Unmarshal(encoded[:len(encoded)-1], &der)
+	require.NoError(t, err)
+	require.Empty(t, rest)
+	require.Equal(t, new(big.Int).SetBytes(signature[:32]), der.R)
+	require.Equal(t, new(big.Int).SetBytes(signature[32:64]), der.S)
+
+	encoded = encode(&types.KeysignMessage{SignatureFormat: types.SignatureFormatBitcoin, SighashType: 0x41})
+	require.Equal(t, byte(0x41), encoded[len(encoded)-1])
+
+	// Cosmos
+	encoded = encode(&types.KeysignMessage{SignatureFormat: types.SignatureFormatCosmos})
+	require.Len(t, encoded, 64)
+	require.True(t, crypto.VerifySignature(pubkey, hash, encoded))
+
+	// An ECDSA signature cannot be encoded as an ed25519 signature.
+	_, err = EncodeSignature(libchain.KEY_TYPE_ECDSA, signature,
+		&types.KeysignMessage{SignatureFormat: types.SignatureFormatEd25519})
+	require.True(t, errors.Is(err, ErrUnsupportedSignatureFormat))
+
+	_, err = EncodeSignature(libchain.KEY_TYPE_ECDSA, signature, &types.KeysignMessage{SignatureFormat: "unknown"})
+	require.True(t, errors.Is(err, ErrUnsupportedSignatureFormat))
+}
+
+func TestEncodeSignature_Eddsa(t *testing.T) {
+	t.Parallel()
+
+	pubkey, privKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	message := []byte("message")
+	signature := ed25519.Sign(privKey, message)
+
+	encoded, err := EncodeSignature(libchain.KEY_TYPE_EDDSA, signature,
+		&types.KeysignMessage{SignatureFormat: types.SignatureFormatEd25519})
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(pubkey, message, encoded))
+
+	_, err = EncodeSignature(libchain.KEY_TYPE_EDDSA, signature,
+		&types.KeysignMessage{SignatureFormat: types.SignatureFormatEthereum})
+	require.True(t, errors.Is(err, ErrUnsupportedSignatureFormat))
+}
+
+func TestEncodeSignature_Taproot(t *testing.T) {
+	t.Parallel()
+
+	signature := make([]byte, 64)
+	signature[0] = 1
+
+	encoded, err := EncodeSignature(types.KeyTypeSchnorr, signature,
+		&types.KeysignMessage{SignatureFormat: types.SignatureFormatTaproot})
+	require.NoError(t, err)
+	require.Equal(t, signature, encoded)
+
+	encoded, err = EncodeSignature(types.KeyTypeSchnorr, signature,
+		&types.KeysignMessage{SignatureFormat: types.SignatureFormatTaproot, SighashType: 0x83})
+	require.NoError(t, err)
+	require.Equal(t, append(append([]byte{}, signature...), 0x83), encoded)
+
+	_, err = EncodeSignature(libchain.KEY_TYPE_EDDSA, signature,
+		&types.KeysignMessage{SignatureFormat: types.SignatureFormatTaproot})
+	require.True(t, errors.Is(err, ErrUnsupportedSignatureFormat))
+}
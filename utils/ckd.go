@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/edwards/v2"
+	schnorrkeygen "github.com/sisu-network/dheart/schnorr/keygen"
+	libCrypto "github.com/sisu-network/tss-lib/crypto"
+	eckeygen "github.com/sisu-network/tss-lib/ecdsa/keygen"
+	edkeygen "github.com/sisu-network/tss-lib/eddsa/keygen"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+const (
+	// HardenedKeyStart is the index of the first hardened child key. Hardened derivation needs the
+	// private key, which no party of a TSS key has.
+	HardenedKeyStart = uint32(0x80000000)
+)
+
+var (
+	ErrInvalidDerivationPath = errors.New("invalid derivation path")
+	ErrInvalidChildKey       = errors.New("invalid child key")
+)
+
+// ParseDerivationPath parses a path of non-hardened child indexes such as "m/0/1". The empty path
+// and "m" are the path of the TSS key itself.
+func ParseDerivationPath(path string) ([]uint32, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "m"), "/")
+	if path == "" {
+		return []uint32{}, nil
+	}
+
+	parts := strings.Split(path, "/")
+	indexes := make([]uint32, len(parts))
+	for i, part := range parts {
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidDerivationPath, path)
+		}
+		if uint32(index) >= HardenedKeyStart {
+			return nil, fmt.Errorf("%w: hardened index %d in %s", ErrInvalidDerivationPath, index, path)
+		}
+		indexes[i] = uint32(index)
+	}
+
+	return indexes, nil
+}
+
+// DeriveEcChildKey derives the child public key of an ECDSA public key at a path with BIP32
+// non-hardened derivation. It returns the child key and the tweak to add to the private key. A TSS
+// key has no chain code, the chain code of the TSS key is the SHA-256 hash of its compressed public
+// key.
+func DeriveEcChildKey(pubKey *libCrypto.ECPoint, path []uint32) (*big.Int, *libCrypto.ECPoint, error) {
+	return deriveChildKey(tss.EC(tss.EcdsaScheme), pubKey, path, serializeEcPubKey, false)
+}
+
+// DeriveEdChildKey is the EdDSA equivalent of DeriveEcChildKey. Public keys are serialized in their
+// 32-byte ed25519 encoding and the tweaks are reduced modulo the group order.
+func DeriveEdChildKey(pubKey *libCrypto.ECPoint, path []uint32) (*big.Int, *libCrypto.ECPoint, error) {
+	return deriveChildKey(tss.EC(tss.EddsaScheme), pubKey, path, serializeEdPubKey, true)
+}
+
+func deriveChildKey(curve elliptic.Curve, pubKey *libCrypto.ECPoint, path []uint32,
+	serialize func(*libCrypto.ECPoint) []byte, reduce bool) (*big.Int, *libCrypto.ECPoint, error) {
+	n := curve.Params().N
+	tweak := big.NewInt(0)
+	chainCode := sha256.Sum256(serialize(pubKey))
+	key := pubKey
+
+	for _, index := range path {
+		if index >= HardenedKeyStart {
+			return nil, nil, fmt.Errorf("%w: hardened index %d", ErrInvalidDerivationPath, index)
+		}
+
+		var indexBytes [4]byte
+		binary.BigEndian.PutUint32(indexBytes[:], index)
+		data := append(serialize(key), indexBytes[:]...)
+
+		mac := hmac.New(sha512.New, chainCode[:])
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		delta := new(big.Int).SetBytes(sum[:32])
+		if reduce {
+			delta.Mod(delta, n)
+		}
+		if delta.Sign() == 0 || delta.Cmp(n) >= 0 {
+			return nil, nil, fmt.Errorf("%w: index %d", ErrInvalidChildKey, index)
+		}
+
+		child, err := key.Add(libCrypto.ScalarBaseMult(curve, delta))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: index %d: %v", ErrInvalidChildKey, index, err)
+		}
+
+		key = child
+		tweak.Add(tweak, delta)
+		tweak.Mod(tweak, n)
+		copy(chainCode[:], sum[32:])
+	}
+
+	return tweak, key, nil
+}
+
+// DeriveEcKeygen returns a copy of the keygen data of a party for the child key at a path. Every
+// party adds the same tweak to its share. Since the Lagrange coefficients of the signers sum to one,
+// the shares of the signers still combine into the child private key.
+func DeriveEcKeygen(data *eckeygen.LocalPartySaveData, path []uint32) (*eckeygen.LocalPartySaveData, error) {
+	if len(path) == 0 {
+		return data, nil
+	}
+
+	tweak, pubKey, err := DeriveEcChildKey(data.ECDSAPub, path)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := tss.EC(tss.EcdsaScheme)
+	bigXj, err := tweakPoints(curve, data.BigXj, tweak)
+	if err != nil {
+		return nil, err
+	}
+
+	derived := *data
+	derived.Xi = tweakShare(curve, data.Xi, tweak)
+	derived.BigXj = bigXj
+	derived.ECDSAPub = pubKey
+
+	return &derived, nil
+}
+
+// DeriveEdKeygen is the EdDSA equivalent of DeriveEcKeygen.
+func DeriveEdKeygen(data *edkeygen.LocalPartySaveData, path []uint32) (*edkeygen.LocalPartySaveData, error) {
+	if len(path) == 0 {
+		return data, nil
+	}
+
+	tweak, pubKey, err := DeriveEdChildKey(data.EDDSAPub, path)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := tss.EC(tss.EddsaScheme)
+	bigXj, err := tweakPoints(curve, data.BigXj, tweak)
+	if err != nil {
+		return nil, err
+	}
+
+	derived := *data
+	derived.Xi = tweakShare(curve, data.Xi, tweak)
+	derived.BigXj = bigXj
+	derived.EDDSAPub = pubKey
+
+	return &derived, nil
+}
+
+// DeriveSchnorrKeygen is the Schnorr equivalent of DeriveEcKeygen. The child key is derived from the
+// full secp256k1 key as in BIP32, so it may have an odd y coordinate. Signing negates the shares of
+// such a key to sign for its x-only form.
+func DeriveSchnorrKeygen(data *schnorrkeygen.LocalPartySaveData, path []uint32) (*schnorrkeygen.LocalPartySaveData, error) {
+	if len(path) == 0 {
+		return data, nil
+	}
+
+	tweak, pubKey, err := DeriveEcChildKey(data.SchnorrPub, path)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := tss.EC(tss.EcdsaScheme)
+	bigXj, err := tweakPoints(curve, data.BigXj, tweak)
+	if err != nil {
+		return nil, err
+	}
+
+	derived := *data
+	derived.Xi = tweakShare(curve, data.Xi, tweak)
+	derived.BigXj = bigXj
+	derived.SchnorrPub = pubKey
+
+	return &derived, nil
+}
+
+func tweakShare(curve elliptic.Curve, share *big.Int, tweak *big.Int) *big.Int {
+	tweaked := new(big.Int).Add(share, tweak)
+	return tweaked.Mod(tweaked, curve.Params().N)
+}
+
+func tweakPoints(curve elliptic.Curve, points []*libCrypto.ECPoint, tweak *big.Int) ([]*libCrypto.ECPoint, error) {
+	tweakPoint := libCrypto.ScalarBaseMult(curve, tweak)
+	tweaked := make([]*libCrypto.ECPoint, len(points))
+	for i, point := range points {
+		var err error
+		if tweaked[i], err = point.Add(tweakPoint); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidChildKey, err)
+		}
+	}
+
+	return tweaked, nil
+}
+
+// serializeEcPubKey returns the 33-byte compressed form of an ECDSA public key.
+func serializeEcPubKey(pubKey *libCrypto.ECPoint) []byte {
+	serialized := make([]byte, 33)
+	serialized[0] = 0x02 + byte(pubKey.Y().Bit(0))
+	pubKey.X().FillBytes(serialized[1:])
+
+	return serialized
+}
+
+func serializeEdPubKey(pubKey *libCrypto.ECPoint) []byte {
+	return edwards.NewPublicKey(pubKey.X(), pubKey.Y()).Serialize()
+}
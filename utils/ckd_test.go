@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/enigmampc/btcutil/hdkeychain"
+	libCrypto "github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/crypto/vss"
+	eckeygen "github.com/sisu-network/tss-lib/ecdsa/keygen"
+	edkeygen "github.com/sisu-network/tss-lib/eddsa/keygen"
+	"github.com/sisu-network/tss-lib/tss"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDerivationPath(t *testing.T) {
+	t.Parallel()
+
+	for path, expected := range map[string][]uint32{
+		"":          {},
+		"m":         {},
+		"m/0":       {0},
+		"m/44/60/7": {44, 60, 7},
+		"1/2":       {1, 2},
+	} {
+		indexes, err := ParseDerivationPath(path)
+		require.NoError(t, err, path)
+		require.Equal(t, expected, indexes, path)
+	}
+
+	for _, path := range []string{"m/0'", "m/2147483648", "m//1", "m/x", "m/-1"} {
+		_, err := ParseDerivationPath(path)
+		require.True(t, errors.Is(err, ErrInvalidDerivationPath), path)
+	}
+}
+
+func TestDeriveEcChildKey_Bip32(t *testing.T) {
+	t.Parallel()
+
+	curve := tss.EC(tss.EcdsaScheme)
+	pubKey := libCrypto.ScalarBaseMult(curve, big.NewInt(12345))
+	path := []uint32{44, 60, 0, 7}
+
+	// The child key is the BIP32 child key of the TSS key with its chain code.
+	chainCode := sha256.Sum256(serializeEcPubKey(pubKey))
+	extended := hdkeychain.NewExtendedKey([]byte{0x04, 0x88, 0xb2, 0x1e}, serializeEcPubKey(pubKey),
+		chainCode[:], []byte{0, 0, 0, 0}, 0, 0, false)
+	for _, index := range path {
+		var err error
+		extended, err = extended.Child(index)
+		require.NoError(t, err)
+	}
+	expected, err := extended.ECPubKey()
+	require.NoError(t, err)
+
+	tweak, child, err := DeriveEcChildKey(pubKey, path)
+	require.NoError(t, err)
+	require.Equal(t, expected.SerializeCompressed(), serializeEcPubKey(child))
+	require.True(t, child.Equals(libCrypto.ScalarBaseMult(curve, new(big.Int).Add(big.NewInt(12345), tweak))))
+
+	_, _, err = DeriveEcChildKey(pubKey, []uint32{HardenedKeyStart})
+	require.True(t, errors.Is(err, ErrInvalidDerivationPath))
+}
+
+// newTestShares splits a secret among 4 parties with threshold 1.
+func newTestShares(t *testing.T, scheme string, secret *big.Int) (vss.Shares, []*libCrypto.ECPoint) {
+	curve := tss.EC(scheme)
+	ids := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+	_, shares, err := vss.Create(scheme, 1, secret, ids)
+	require.NoError(t, err)
+
+	bigXj := make([]*libCrypto.ECPoint, len(shares))
+	for j, share := range shares {
+		bigXj[j] = libCrypto.ScalarBaseMult(curve, share.Share)
+	}
+
+	return shares, bigXj
+}
+
+// requireDerivedShares checks that the derived shares of any two parties combine into the derived
+// private key and match the derived public shares.
+func requireDerivedShares(t *testing.T, scheme string, shares vss.Shares, xis []*big.Int,
+	bigXj []*libCrypto.ECPoint, pubKey *libCrypto.ECPoint) {
+	curve := tss.EC(scheme)
+	derived := make(vss.Shares, len(shares))
+	for j, share := range shares {
+		derived[j] = &vss.Share{Threshold: share.Threshold, ID: share.ID, Share: xis[j]}
+		require.True(t, bigXj[j].Equals(libCrypto.ScalarBaseMult(curve, xis[j])))
+	}
+
+	for _, signers := range []vss.Shares{derived[:2], derived[1:3], {derived[0], derived[3]}} {
+		secret, err := signers.ReConstruct(scheme)
+		require.NoError(t, err)
+		require.True(t, pubKey.Equals(libCrypto.ScalarBaseMult(curve, secret)))
+	}
+}
+
+func TestDeriveEcKeygen(t *testing.T) {
+	t.Parallel()
+
+	curve := tss.EC(tss.EcdsaScheme)
+	secret := big.NewInt(987654321)
+	shares, bigXj := newTestShares(t, tss.EcdsaScheme, secret)
+	path := []uint32{1, 2}
+
+	xis := make([]*big.Int, len(shares))
+	var pubKey *libCrypto.ECPoint
+	var derivedBigXj []*libCrypto.ECPoint
+	for i, share := range shares {
+		data := &eckeygen.LocalPartySaveData{
+			LocalSecrets: eckeygen.LocalSecrets{Xi: share.Share},
+			BigXj:        bigXj,
+			ECDSAPub:     libCrypto.ScalarBaseMult(curve, secret),
+		}
+		derived, err := DeriveEcKeygen(data, path)
+		require.NoError(t, err)
+
+		// The keygen data of the party is not modified.
+		require.Equal(t, share.Share, data.Xi)
+
+		xis[i], pubKey, derivedBigXj = derived.Xi, derived.ECDSAPub, derived.BigXj
+	}
+
+	_, expected, err := DeriveEcChildKey(libCrypto.ScalarBaseMult(curve, secret), path)
+	require.NoError(t, err)
+	require.True(t, expected.Equals(pubKey))
+	requireDerivedShares(t, tss.EcdsaScheme, shares, xis, derivedBigXj, pubKey)
+}
+
+func TestDeriveEdKeygen(t *testing.T) {
+	t.Parallel()
+
+	curve := tss.EC(tss.EddsaScheme)
+	secret := big.NewInt(123456789)
+	shares, bigXj := newTestShares(t, tss.EddsaScheme, secret)
+	path := []uint32{3, 4, 5}
+
+	xis := make([]*big.Int, len(shares))
+	var pubKey *libCrypto.ECPoint
+	var derivedBigXj []*libCrypto.ECPoint
+	for i, share := range shares {
+		derived, err := DeriveEdKeygen(&edkeygen.LocalPartySaveData{
+			LocalSecrets: edkeygen.LocalSecrets{Xi: share.Share},
+			BigXj:        bigXj,
+			EDDSAPub:     libCrypto.ScalarBaseMult(curve, secret),
+		}, path)
+		require.NoError(t, err)
+
+		xis[i], pubKey, derivedBigXj = derived.Xi, derived.EDDSAPub, derived.BigXj
+	}
+
+	requireDerivedShares(t, tss.EddsaScheme, shares, xis, derivedBigXj, pubKey)
+
+	// The empty path is the key itself.
+	data := &edkeygen.LocalPartySaveData{LocalSecrets: edkeygen.LocalSecrets{Xi: shares[0].Share}, BigXj: bigXj}
+	derived, err := DeriveEdKeygen(data, []uint32{})
+	require.NoError(t, err)
+	require.Equal(t, data, derived)
+}
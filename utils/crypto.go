@@ -3,9 +3,13 @@ package utils
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
 
 	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
@@ -84,6 +88,72 @@ func GetCosmosPubKey(keyType string, keyBytes []byte) (ctypes.PubKey, error) {
 	return pubKey, nil
 }
 
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// HashToInt converts a hash value to an integer the same way as crypto/ecdsa does. The hash is
+// truncated to the bit length of the curve order.
+func HashToInt(hash []byte, c elliptic.Curve) *big.Int {
+	orderBits := c.Params().N.BitLen()
+	orderBytes := (orderBits + 7) / 8
+	if len(hash) > orderBytes {
+		hash = hash[:orderBytes]
+	}
+
+	ret := new(big.Int).SetBytes(hash)
+	excess := len(hash)*8 - orderBits
+	if excess > 0 {
+		ret.Rsh(ret, uint(excess))
+	}
+	return ret
+}
+
+// VerifyEcSignature verifies an ECDSA signature of a hash. It returns the S value normalized to
+// the lower half of the curve order and the recovery id of the normalized signature.
+func VerifyEcSignature(pub *ecdsa.PublicKey, hash []byte, r, s *big.Int) (*big.Int, byte, error) {
+	curve := pub.Curve
+	n := curve.Params().N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return nil, 0, fmt.Errorf("%w: r or s is out of range", ErrInvalidSignature)
+	}
+
+	// Compute the point R = (e * G + r * Q) / s. The signature is valid if the x coordinate of R is
+	// r. The recovery id tells the parity of the y coordinate and whether x overflows the order.
+	e := HashToInt(hash, curve)
+	w := new(big.Int).ModInverse(s, n)
+	u1 := new(big.Int).Mul(e, w)
+	u1.Mod(u1, n)
+	u2 := new(big.Int).Mul(r, w)
+	u2.Mod(u2, n)
+
+	x1, y1 := curve.ScalarBaseMult(u1.Bytes())
+	x2, y2 := curve.ScalarMult(pub.X, pub.Y, u2.Bytes())
+	x, y := curve.Add(x1, y1, x2, y2)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, 0, fmt.Errorf("%w: R is the point at infinity", ErrInvalidSignature)
+	}
+
+	if new(big.Int).Mod(x, n).Cmp(r) != 0 {
+		return nil, 0, ErrInvalidSignature
+	}
+
+	recovery := byte(y.Bit(0))
+	if x.Cmp(n) >= 0 {
+		recovery |= 2
+	}
+
+	// Replacing S with N - S gives another valid signature whose R point is negated, i.e. the parity
+	// of its y coordinate is flipped.
+	halfOrder := new(big.Int).Rsh(n, 1)
+	if s.Cmp(halfOrder) > 0 {
+		s = new(big.Int).Sub(n, s)
+		recovery ^= 1
+	}
+
+	return s, recovery, nil
+}
+
 func PadToLengthBytesForSignature(src []byte, length int) []byte {
 	oriLen := len(src)
 	if oriLen < length {
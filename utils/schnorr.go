@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	schnorrkeygen "github.com/sisu-network/dheart/schnorr/keygen"
+	libCrypto "github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/tss"
+)
+
+const (
+	TagBip340Aux       = "BIP0340/aux"
+	TagBip340Nonce     = "BIP0340/nonce"
+	TagBip340Challenge = "BIP0340/challenge"
+	TagTapTweak        = "TapTweak"
+)
+
+// TaggedHash returns the BIP-340 hash SHA256(SHA256(tag) || SHA256(tag) || msg).
+func TaggedHash(tag string, msgs ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, msg := range msgs {
+		h.Write(msg)
+	}
+
+	return h.Sum(nil)
+}
+
+// SchnorrChallenge returns the BIP-340 challenge of a signature with nonce point R for a message.
+// Both points are given by their x coordinates.
+func SchnorrChallenge(rX *big.Int, pubKeyX *big.Int, msg []byte) *big.Int {
+	hash := TaggedHash(TagBip340Challenge, rX.FillBytes(make([]byte, 32)), pubKeyX.FillBytes(make([]byte, 32)), msg)
+
+	e := new(big.Int).SetBytes(hash)
+	return e.Mod(e, tss.EC(tss.EcdsaScheme).Params().N)
+}
+
+// SerializeSchnorrPubKey returns the 32-byte x-only form of a secp256k1 public key. A public key and
+// its negation have the same x-only form.
+func SerializeSchnorrPubKey(pubKey *libCrypto.ECPoint) []byte {
+	return pubKey.X().FillBytes(make([]byte, 32))
+}
+
+// ParseSchnorrPubKey returns the point with an even y coordinate of an x-only public key.
+func ParseSchnorrPubKey(pubKey []byte) (*libCrypto.ECPoint, error) {
+	if len(pubKey) != 32 {
+		return nil, fmt.Errorf("%w: x-only key has length %d", ErrInvalidPubKey, len(pubKey))
+	}
+
+	x := new(big.Int).SetBytes(pubKey)
+	y, err := liftX(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return libCrypto.NewECPoint(tss.EC(tss.EcdsaScheme), x, y)
+}
+
+// TapTweakPubKey returns the BIP-341 tweak and output key of a Taproot internal key without a
+// script tree, as used by BIP-86 key path spends. The output key may have an odd y coordinate.
+func TapTweakPubKey(internalKey *libCrypto.ECPoint) (*big.Int, *libCrypto.ECPoint, error) {
+	curve := tss.EC(tss.EcdsaScheme)
+	tweak := new(big.Int).SetBytes(TaggedHash(TagTapTweak, SerializeSchnorrPubKey(internalKey)))
+	if tweak.Cmp(curve.Params().N) >= 0 {
+		return nil, nil, fmt.Errorf("%w: tweak is out of range", ErrInvalidChildKey)
+	}
+
+	// The internal key is the point of its x-only form, which has an even y coordinate.
+	evenKey, err := ParseSchnorrPubKey(SerializeSchnorrPubKey(internalKey))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	outputKey, err := evenKey.Add(libCrypto.ScalarBaseMult(curve, tweak))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidChildKey, err)
+	}
+
+	return tweak, outputKey, nil
+}
+
+// TapTweakSchnorrKeygen returns the keygen data of the BIP-341 output key of a Schnorr key without
+// a script tree. The shares of a key with an odd y coordinate are negated first so that they are
+// the shares of its x-only form.
+func TapTweakSchnorrKeygen(data *schnorrkeygen.LocalPartySaveData) (*schnorrkeygen.LocalPartySaveData, error) {
+	tweak, outputKey, err := TapTweakPubKey(data.SchnorrPub)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := tss.EC(tss.EcdsaScheme)
+	xi, bigXj := data.Xi, data.BigXj
+	if data.SchnorrPub.Y().Bit(0) == 1 {
+		xi = new(big.Int).Sub(curve.Params().N, xi)
+		bigXj = make([]*libCrypto.ECPoint, len(data.BigXj))
+		for j, point := range data.BigXj {
+			negY := new(big.Int).Sub(curve.Params().P, point.Y())
+			if bigXj[j], err = libCrypto.NewECPoint(curve, point.X(), negY); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidChildKey, err)
+			}
+		}
+	}
+
+	if bigXj, err = tweakPoints(curve, bigXj, tweak); err != nil {
+		return nil, err
+	}
+
+	tweaked := *data
+	tweaked.Xi = tweakShare(curve, xi, tweak)
+	tweaked.BigXj = bigXj
+	tweaked.SchnorrPub = outputKey
+
+	return &tweaked, nil
+}
+
+// liftX returns the even y coordinate of the secp256k1 point with x coordinate x.
+func liftX(x *big.Int) (*big.Int, error) {
+	p := tss.EC(tss.EcdsaScheme).Params().P
+	if x.Cmp(p) >= 0 {
+		return nil, fmt.Errorf("%w: x is not a field element", ErrInvalidPubKey)
+	}
+
+	// y^2 = x^3 + 7. Since p = 3 mod 4, the square root of c is c^((p + 1) / 4).
+	c := new(big.Int).Exp(x, big.NewInt(3), p)
+	c.Add(c, big.NewInt(7))
+	c.Mod(c, p)
+
+	exp := new(big.Int).Add(p, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y := new(big.Int).Exp(c, exp, p)
+	if new(big.Int).Exp(y, big.NewInt(2), p).Cmp(c) != 0 {
+		return nil, fmt.Errorf("%w: x is not on the curve", ErrInvalidPubKey)
+	}
+
+	if y.Bit(0) == 1 {
+		y.Sub(p, y)
+	}
+
+	return y, nil
+}
+
+// SignSchnorr signs a message with a private key with the BIP-340 signing algorithm. aux is 32
+// bytes of auxiliary random data.
+func SignSchnorr(privateKey *big.Int, msg []byte, aux []byte) ([]byte, error) {
+	curve := tss.EC(tss.EcdsaScheme)
+	n := curve.Params().N
+	if privateKey.Sign() <= 0 || privateKey.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("private key is out of range")
+	}
+
+	// The private key of the x-only public key is the one whose public key has an even y.
+	d := new(big.Int).Set(privateKey)
+	pubKey := libCrypto.ScalarBaseMult(curve, d)
+	if pubKey.Y().Bit(0) == 1 {
+		d.Sub(n, d)
+	}
+	pubKeyBytes := SerializeSchnorrPubKey(pubKey)
+
+	auxHash := TaggedHash(TagBip340Aux, aux)
+	t := d.FillBytes(make([]byte, 32))
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+
+	k := new(big.Int).SetBytes(TaggedHash(TagBip340Nonce, t, pubKeyBytes, msg))
+	k.Mod(k, n)
+	if k.Sign() == 0 {
+		return nil, fmt.Errorf("nonce is zero")
+	}
+
+	r := libCrypto.ScalarBaseMult(curve, k)
+	if r.Y().Bit(0) == 1 {
+		k.Sub(n, k)
+	}
+
+	e := SchnorrChallenge(r.X(), pubKey.X(), msg)
+	s := new(big.Int).Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, n)
+
+	signature := append(r.X().FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	if err := VerifySchnorrSignature(pubKeyBytes, msg, signature); err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// VerifySchnorrSignature verifies a 64-byte BIP-340 signature of a message for an x-only public key.
+func VerifySchnorrSignature(pubKey []byte, msg []byte, signature []byte) error {
+	if len(signature) != 64 {
+		return fmt.Errorf("%w: signature has length %d", ErrInvalidSignature, len(signature))
+	}
+
+	point, err := ParseSchnorrPubKey(pubKey)
+	if err != nil {
+		return err
+	}
+
+	curve := tss.EC(tss.EcdsaScheme)
+	params := curve.Params()
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if r.Cmp(params.P) >= 0 || s.Cmp(params.N) >= 0 {
+		return fmt.Errorf("%w: r or s is out of range", ErrInvalidSignature)
+	}
+
+	// R = s * G - e * P must have an even y coordinate and r as its x coordinate.
+	e := SchnorrChallenge(r, point.X(), msg)
+	negE := new(big.Int).Sub(params.N, e)
+	negE.Mod(negE, params.N)
+
+	x1, y1 := curve.ScalarBaseMult(s.Bytes())
+	x2, y2 := curve.ScalarMult(point.X(), point.Y(), negE.Bytes())
+	x, y := curve.Add(x1, y1, x2, y2)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return fmt.Errorf("%w: R is the point at infinity", ErrInvalidSignature)
+	}
+
+	if y.Bit(0) == 1 || x.Cmp(r) != 0 {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	schnorrkeygen "github.com/sisu-network/dheart/schnorr/keygen"
+	libCrypto "github.com/sisu-network/tss-lib/crypto"
+	"github.com/sisu-network/tss-lib/tss"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignSchnorr_Bip340Vectors(t *testing.T) {
+	t.Parallel()
+
+	// Test vectors 0 to 2 of BIP-340.
+	for _, v := range []struct{ privateKey, pubKey, aux, msg, signature string }{
+		{
+			"0000000000000000000000000000000000000000000000000000000000000003",
+			"F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0",
+		},
+		{
+			"B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF",
+			"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+		},
+		{
+			"C90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B14E5C9",
+			"DD308AFEC5777E13121FA72B9CC1B7CC0139715309B086C960E18FD969774EB8",
+			"C87AA53824B4D7AE2EB035A2B5BBBCCC080E76CDC6D1692C4B0B62D798E6D906",
+			"7E2D58D8B3BCDF1ABADEC7829054F90DDA9805AAB56C77333024B9D0A508B75C",
+			"5831AAEED7B44BB74E5EAB94BA9D4294C49BCF2A60728D8B4C200F50DD313C1BAB745879A5AD954A72C45A91C3A51D3C7ADEA98D82F8481E0E1E03674A6F3FB7",
+		},
+	} {
+		privateKey, _ := new(big.Int).SetString(v.privateKey, 16)
+		pubKey, _ := hex.DecodeString(v.pubKey)
+		aux, _ := hex.DecodeString(v.aux)
+		msg, _ := hex.DecodeString(v.msg)
+		expected, _ := hex.DecodeString(v.signature)
+
+		signature, err := SignSchnorr(privateKey, msg, aux)
+		require.NoError(t, err)
+		require.Equal(t, expected, signature)
+		require.NoError(t, VerifySchnorrSignature(pubKey, msg, signature))
+
+		point, err := ParseSchnorrPubKey(pubKey)
+		require.NoError(t, err)
+		require.Equal(t, pubKey, SerializeSchnorrPubKey(point))
+		require.Equal(t, uint(0), point.Y().Bit(0))
+	}
+}
+
+func TestVerifySchnorrSignature_Invalid(t *testing.T) {
+	t.Parallel()
+
+	pubKey, _ := hex.DecodeString("DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659")
+	msg, _ := hex.DecodeString("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89")
+	signature, _ := hex.DecodeString("6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A")
+	require.NoError(t, VerifySchnorrSignature(pubKey, msg, signature))
+
+	otherMsg := append([]byte{}, msg...)
+	otherMsg[0] ^= 1
+	require.True(t, errors.Is(VerifySchnorrSignature(pubKey, otherMsg, signature), ErrInvalidSignature))
+
+	otherSignature := append([]byte{}, signature...)
+	otherSignature[63] ^= 1
+	require.True(t, errors.Is(VerifySchnorrSignature(pubKey, msg, otherSignature), ErrInvalidSignature))
+
+	// s is not smaller than the group order.
+	otherSignature = append(append([]byte{}, signature[:32]...), secp256k1Order()...)
+	require.True(t, errors.Is(VerifySchnorrSignature(pubKey, msg, otherSignature), ErrInvalidSignature))
+
+	require.True(t, errors.Is(VerifySchnorrSignature(pubKey, msg, signature[:63]), ErrInvalidSignature))
+	require.True(t, errors.Is(VerifySchnorrSignature(pubKey[:31], msg, signature), ErrInvalidPubKey))
+
+	// Public key of BIP-340 test vector 5, which is not on the curve.
+	notOnCurve, _ := hex.DecodeString("EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34")
+	require.True(t, errors.Is(VerifySchnorrSignature(notOnCurve, msg, signature), ErrInvalidPubKey))
+}
+
+func TestTapTweakPubKey_Bip86Vector(t *testing.T) {
+	t.Parallel()
+
+	// Internal and output keys of m/86'/0'/0'/0/0 in the test vectors of BIP-86.
+	internalKey, _ := hex.DecodeString("cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115")
+	point, err := ParseSchnorrPubKey(internalKey)
+	require.NoError(t, err)
+
+	_, outputKey, err := TapTweakPubKey(point)
+	require.NoError(t, err)
+	require.Equal(t, "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+		hex.EncodeToString(SerializeSchnorrPubKey(outputKey)))
+}
+
+func TestTapTweakSchnorrKeygen(t *testing.T) {
+	t.Parallel()
+
+	curve := tss.EC(tss.EcdsaScheme)
+	// Test a key with an even y coordinate and a key with an odd one.
+	for _, odd := range []uint{0, 1} {
+		secret := big.NewInt(1)
+		for libCrypto.ScalarBaseMult(curve, secret).Y().Bit(0) != odd {
+			secret.Add(secret, big.NewInt(1))
+		}
+		pubKey := libCrypto.ScalarBaseMult(curve, secret)
+
+		shares, bigXj := newTestShares(t, tss.EcdsaScheme, secret)
+		xis := make([]*big.Int, len(shares))
+		var tweaked *schnorrkeygen.LocalPartySaveData
+		for i, share := range shares {
+			data := &schnorrkeygen.LocalPartySaveData{
+				LocalSecrets: schnorrkeygen.LocalSecrets{Xi: share.Share},
+				BigXj:        bigXj,
+				SchnorrPub:   pubKey,
+			}
+
+			var err error
+			tweaked, err = TapTweakSchnorrKeygen(data)
+			require.NoError(t, err)
+			require.Equal(t, share.Share, data.Xi)
+			xis[i] = tweaked.Xi
+		}
+
+		_, outputKey, err := TapTweakPubKey(pubKey)
+		require.NoError(t, err)
+		require.True(t, outputKey.Equals(tweaked.SchnorrPub))
+		requireDerivedShares(t, tss.EcdsaScheme, shares, xis, tweaked.BigXj, tweaked.SchnorrPub)
+	}
+}
+
+func secp256k1Order() []byte {
+	n, _ := hex.DecodeString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141")
+	return n
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"strings"
+
+	btcbech32 "github.com/enigmampc/btcutil/bech32"
+)
+
+const (
+	bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	// bech32mConst is the checksum constant of BIP-350.
+	bech32mConst = 0x2bc830a3
+)
+
+// encodeSegwitAddress returns the address of a witness program with a version. Version 0 addresses
+// are encoded with bech32 and later versions with bech32m (BIP-350).
+func encodeSegwitAddress(hrp string, version byte, program []byte) (string, error) {
+	data, err := btcbech32.ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	data = append([]byte{version}, data...)
+
+	if version == 0 {
+		return btcbech32.Encode(hrp, data)
+	}
+
+	return encodeBech32m(hrp, data), nil
+}
+
+// encodeBech32m returns the bech32m string of 5-bit data with a human readable part.
+func encodeBech32m(hrp string, data []byte) string {
+	values := append(bech32HrpExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ bech32mConst
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, b := range data {
+		sb.WriteByte(bech32Charset[b])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+
+	return sb.String()
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	values := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+
+	return values
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+
+	return chk
+}
@@ -8,8 +8,9 @@ import (
 )
 
 type partyInfo struct {
-	partyId *tss.PartyID
-	maxJob  int
+	partyId      *tss.PartyID
+	maxJob       int
+	presignCount int
 }
 
 type AvailableParties struct {
@@ -24,13 +25,14 @@ func NewAvailableParties() *AvailableParties {
 	}
 }
 
-func (ap *AvailableParties) add(p *tss.PartyID, computingPower int) {
+func (ap *AvailableParties) add(p *tss.PartyID, computingPower int, presignCount int) {
 	ap.lock.Lock()
 	defer ap.lock.Unlock()
 
 	ap.parties[p.Id] = &partyInfo{
-		partyId: p,
-		maxJob:  computingPower,
+		partyId:      p,
+		maxJob:       computingPower,
+		presignCount: presignCount,
 	}
 }
 
@@ -92,14 +94,32 @@ func (ap *AvailableParties) getAllPartiesMap() map[string]*tss.PartyID {
 	return ret
 }
 
-// getTopParties returns a list of parties with highest computing power.
+// getPresignPartiesMap returns the parties that hold at least minCount presigns of the work's key.
+func (ap *AvailableParties) getPresignPartiesMap(minCount int) map[string]*tss.PartyID {
+	ap.lock.RLock()
+	defer ap.lock.RUnlock()
+
+	ret := make(map[string]*tss.PartyID)
+	for pid, info := range ap.parties {
+		if info.presignCount >= minCount {
+			ret[pid] = info.partyId
+		}
+	}
+
+	return ret
+}
+
+// getTopParties returns a list of at most count parties with highest computing power and the
+// lowest computing power in this list. Parties that cannot take any job are skipped.
 func (ap *AvailableParties) getTopParties(count int) ([]*tss.PartyID, int) {
 	ap.lock.RLock()
 	defer ap.lock.RUnlock()
 
 	arr := make([]*partyInfo, 0, len(ap.parties))
 	for _, partyInfo := range ap.parties {
-		arr = append(arr, partyInfo)
+		if partyInfo.maxJob > 0 {
+			arr = append(arr, partyInfo)
+		}
 	}
 
 	// Sort all parties descendingly by their max job.
@@ -112,5 +132,9 @@ func (ap *AvailableParties) getTopParties(count int) ([]*tss.PartyID, int) {
 		parties = append(parties, arr[i].partyId)
 	}
 
+	if len(parties) == 0 {
+		return parties, 0
+	}
+
 	return parties, arr[len(parties)-1].maxJob
 }
@@ -38,9 +38,9 @@ type DefaultMessageMonitor struct {
 	allMessages      []string // all messages that this node should receive from each peer
 }
 
-func NewMessageMonitor(mypid *tss.PartyID, jobType wTypes.WorkType, callback MessageMonitorCallback,
-	pIDsMap map[string]*tss.PartyID, timeout time.Duration) MessageMonitor {
-	allMessages := message.GetMessagesByWorkType(jobType)
+func NewMessageMonitor(mypid *tss.PartyID, jobType wTypes.WorkType, hasPresignData bool,
+	callback MessageMonitorCallback, pIDsMap map[string]*tss.PartyID, timeout time.Duration) MessageMonitor {
+	allMessages := message.GetMessagesByWorkType(jobType, hasPresignData)
 	receivedMessages := make(map[string][]bool)
 	for pid := range pIDsMap {
 		receivedMessages[pid] = make([]bool, len(allMessages))
@@ -55,7 +55,7 @@ func NewMessageMonitor(mypid *tss.PartyID, jobType wTypes.WorkType, callback Mes
 		callback:         callback,
 		pIDsMap:          pIDsMap,
 		receivedMessages: receivedMessages,
-		allMessages:      message.GetMessagesByWorkType(jobType),
+		allMessages:      allMessages,
 	}
 }
 
@@ -11,8 +11,10 @@ import (
 	corecomponents "github.com/sisu-network/dheart/core/components"
 	"github.com/sisu-network/lib/log"
 
+	"github.com/sisu-network/dheart/blame"
 	"github.com/sisu-network/dheart/core/config"
 	"github.com/sisu-network/dheart/db"
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
 	"github.com/sisu-network/dheart/worker/interfaces"
 	"github.com/sisu-network/dheart/worker/types"
 	ecsigning "github.com/sisu-network/tss-lib/ecdsa/signing"
@@ -42,6 +44,7 @@ type DefaultWorker struct {
 	maxJob          int
 	dispatcher      interfaces.MessageDispatcher
 	presignsManager corecomponents.AvailablePresigns
+	blameMgr        *blame.Manager
 	cfg             config.TimeoutConfig
 
 	// PreExecution
@@ -130,6 +133,7 @@ func baseWorker(
 		jobs:              make([]*Job, request.BatchSize),
 		lock:              &sync.RWMutex{},
 		preExecutionCache: preExecutionCache,
+		blameMgr:          blame.NewManager(),
 		cfg:               cfg,
 		maxJob:            maxJob,
 		isStopped:         atomic.NewBool(false),
@@ -146,7 +150,7 @@ func (w *DefaultWorker) Start(preworkCache []*commonTypes.TssMessage) error {
 
 	// Start the selection result.
 	w.preworkSelection = NewPreworkSelection(w.request, w.allParties, w.myPid, w.db,
-		w.preExecutionCache, w.dispatcher, w.presignsManager, w.cfg, w.onSelectionResult)
+		w.preExecutionCache, w.dispatcher, w.presignsManager, w.callback, w.callback, w.cfg, w.onSelectionResult)
 	w.preworkSelection.Init()
 
 	cacheMsgs := w.preExecutionCache.PopAllMessages(w.workId, commonTypes.GetPreworkSelectionMsgType())
@@ -199,26 +203,42 @@ func (w *DefaultWorker) startEcExecution(result SelectionResult) {
 func (w *DefaultWorker) startEdExecution(result SelectionResult) {
 	sortedPids := tss.SortPartyIDs(result.SelectedPids)
 
+	// We need to load the set of presigns data
+	var edSigningPresign []*edpresign.PresignData
+	if w.request.IsEddsa() && w.request.IsSigning() && len(result.PresignIds) > 0 {
+		edSigningPresign = w.callback.GetEdPresignOutputs(result.PresignIds)
+		if len(edSigningPresign) != w.request.BatchSize {
+			log.Errorf("Work %s: cannot load presigns %v", w.workId, result.PresignIds)
+			w.callback.OnWorkFailed(w.request)
+			return
+		}
+	}
+
 	w.lock.Lock()
 	defer w.lock.Unlock()
 
 	w.curWorkType = w.request.WorkType
-	w.executor = w.getEdExecutor(sortedPids)
+	w.executor = w.getEdExecutor(sortedPids, edSigningPresign)
 	w.runExecutor(w.executor)
 }
 
 func (w *DefaultWorker) getEcExecutor(selectedPids []*tss.PartyID, ecSigningPresign []*ecsigning.SignatureData_OneRoundData) *WorkerExecutor {
 	return NewWorkerExecutor(w.request, w.curWorkType, w.myPid, selectedPids, w.dispatcher,
-		w.db, ecSigningPresign, w.onJobExecutionResult, w.cfg)
+		w.db, ecSigningPresign, nil, w.blameMgr, w.onJobExecutionResult, w.cfg)
 }
 
-func (w *DefaultWorker) getEdExecutor(selectedPids []*tss.PartyID) *WorkerExecutor {
+func (w *DefaultWorker) getEdExecutor(selectedPids []*tss.PartyID, edSigningPresign []*edpresign.PresignData) *WorkerExecutor {
 	return NewWorkerExecutor(w.request, w.curWorkType, w.myPid, selectedPids, w.dispatcher,
-		w.db, nil, w.onJobExecutionResult, w.cfg)
+		w.db, nil, edSigningPresign, w.blameMgr, w.onJobExecutionResult, w.cfg)
 }
 
 func (w *DefaultWorker) runExecutor(executor *WorkerExecutor) {
-	executor.Init()
+	if err := executor.Init(); err != nil {
+		log.Errorf("Work %s: cannot init the executor, err = %v", w.workId, err)
+		// Failing the work stops this worker which needs the lock held by the caller.
+		go w.callback.OnWorkFailed(w.request)
+		return
+	}
 
 	cacheMsgs := w.preExecutionCache.PopAllMessages(w.workId, commonTypes.GetUpdateMessageType())
 	go executor.Run(cacheMsgs)
@@ -302,7 +322,7 @@ func (w *DefaultWorker) ProcessNewMessage(msg *commonTypes.TssMessage) error {
 // Callback from worker executor
 func (w *DefaultWorker) onJobExecutionResult(executor *WorkerExecutor, result ExecutionResult) {
 	if result.Success {
-		ok := w.saveJobResultData(result)
+		ok := w.saveJobResultData(executor, result)
 		if !ok {
 			w.callback.OnWorkFailed(w.request)
 			return
@@ -317,17 +337,23 @@ func (w *DefaultWorker) onJobExecutionResult(executor *WorkerExecutor, result Ex
 	}
 }
 
-func (w *DefaultWorker) saveJobResultData(result ExecutionResult) bool {
+func (w *DefaultWorker) saveJobResultData(executor *WorkerExecutor, result ExecutionResult) bool {
 	if w.request.IsKeygen() {
 		if w.request.IsEcdsa() {
 			// Save to database
-			if err := w.db.SaveEcKeygen(w.request.KeygenType, w.request.WorkId, w.request.AllParties,
+			if err := w.db.SaveEcKeygen(w.request.KeygenType, w.request.KeyLabel, w.request.WorkId, w.request.AllParties,
 				GetEcKeygenOutputs(result.JobResults)[0]); err != nil {
 				log.Error("error when saving keygen data", err)
 				return false
 			}
+		} else if w.request.IsSchnorr() {
+			if err := w.db.SaveSchnorrKeygen(w.request.KeygenType, w.request.KeyLabel, w.request.WorkId, w.request.AllParties,
+				GetSchnorrKeygenOutputs(result.JobResults)[0]); err != nil {
+				log.Error("error when saving keygen data", err)
+				return false
+			}
 		} else {
-			if err := w.db.SaveEdKeygen(w.request.KeygenType, w.request.WorkId, w.request.AllParties,
+			if err := w.db.SaveEdKeygen(w.request.KeygenType, w.request.KeyLabel, w.request.WorkId, w.request.AllParties,
 				GetEdKeygenOutputs(result.JobResults)[0]); err != nil {
 				log.Error("error when saving keygen data", err)
 				return false
@@ -335,6 +361,10 @@ func (w *DefaultWorker) saveJobResultData(result ExecutionResult) bool {
 		}
 	} else if w.request.IsEcPresign() {
 		// TODO: Save presign data here.
+	} else if w.request.IsEdPresign() {
+		// The presigns can only be used by the parties that made them.
+		w.presignsManager.AddEdPresign(w.request.KeyLabel, w.request.WorkId, executor.pIDs,
+			GetEdPresignOutputs(result.JobResults))
 	}
 
 	return true
@@ -345,7 +375,10 @@ func (w *DefaultWorker) Stop() {
 	defer w.lock.Unlock()
 
 	if !w.isStopped.Load() {
-		go w.preworkSelection.Stop()
+		// The prework selection is created when the worker starts.
+		if w.preworkSelection != nil {
+			go w.preworkSelection.Stop()
+		}
 		if w.executor != nil {
 			go w.executor.Stop()
 		}
@@ -353,8 +386,12 @@ func (w *DefaultWorker) Stop() {
 }
 
 func (w *DefaultWorker) GetCulprits() []*tss.PartyID {
-	// TODO: Reimplement blame manager.
-	return make([]*tss.PartyID, 0)
+	allParties := make(map[string]*tss.PartyID, len(w.allParties))
+	for _, p := range w.allParties {
+		allParties[p.Id] = p
+	}
+
+	return w.blameMgr.GetCulprits(w.workId, allParties)
 }
 
 // Implements GetPartyId() of Worker interface.
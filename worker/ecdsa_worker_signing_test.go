@@ -1,6 +1,7 @@
 package worker
 
 import (
+	libchain "github.com/sisu-network/lib/chain"
 	"bytes"
 
 	"crypto/ecdsa"
@@ -45,8 +46,12 @@ func mockDbForSigning(pids []*tss.PartyID, WorkId string, batchSize int) db.Data
 	}
 
 	return &db.MockDatabase{
-		GetAvailablePresignShortFormFunc: func() ([]string, []string, error) {
-			return presignIds, pidStrings, nil
+		GetAvailablePresignShortFormFunc: func() ([]string, []string, []string, []string, error) {
+			keyTypes := make([]string, len(presignIds))
+			for i := range keyTypes {
+				keyTypes[i] = libchain.KEY_TYPE_ECDSA
+			}
+			return presignIds, pidStrings, keyTypes, make([]string, len(presignIds)), nil
 		},
 
 		LoadPresignFunc: func(presignIds []string) ([]*ecsigning.SignatureData_OneRoundData, error) {
@@ -125,7 +130,10 @@ func TestEcWorkerSigning_EndToEnd(t *testing.T) {
 			config.NewDefaultTimeoutConfig(),
 			1,
 			&components.MockAvailablePresigns{
-				GetAvailablePresignsFunc: func(batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
+				GetPresignCountFunc: func(keyType string, keyLabel string) int {
+					return 1
+				},
+				GetAvailablePresignsFunc: func(keyType string, keyLabel string, batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
 					return make([]string, batchSize), flattenPidMaps(allPids)
 				},
 			},
@@ -200,7 +208,7 @@ func TestEcWorkerSigning_PresignAndSign(t *testing.T) {
 			cfg,
 			1,
 			&components.MockAvailablePresigns{
-				GetAvailablePresignsFunc: func(batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
+				GetAvailablePresignsFunc: func(keyType string, keyLabel string, batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
 					return nil, nil
 				},
 			},
@@ -315,7 +323,10 @@ func TestEcWorkerSigning_ExecutionTimeout(t *testing.T) {
 			cfg,
 			1,
 			&components.MockAvailablePresigns{
-				GetAvailablePresignsFunc: func(batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
+				GetPresignCountFunc: func(keyType string, keyLabel string) int {
+					return 1
+				},
+				GetAvailablePresignsFunc: func(keyType string, keyLabel string, batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
 					return make([]string, batchSize), flattenPidMaps(allPids)
 				},
 			},
@@ -454,7 +465,10 @@ func doTestThreshold(t *testing.T) {
 			config.NewDefaultTimeoutConfig(),
 			1,
 			&components.MockAvailablePresigns{
-				GetAvailablePresignsFunc: func(batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
+				GetPresignCountFunc: func(keyType string, keyLabel string) int {
+					return 1
+				},
+				GetAvailablePresignsFunc: func(keyType string, keyLabel string, batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
 					if len(allPids) < len(wrapper.Outputs) {
 						return []string{}, []*tss.PartyID{}
 					}
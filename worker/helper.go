@@ -1,6 +1,8 @@
 package worker
 
 import (
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
+	schnorrkeygen "github.com/sisu-network/dheart/schnorr/keygen"
 	libCommon "github.com/sisu-network/tss-lib/common"
 	"github.com/sisu-network/tss-lib/ecdsa/keygen"
 	ecsigning "github.com/sisu-network/tss-lib/ecdsa/signing"
@@ -44,6 +46,15 @@ func GetEdKeygenOutputs(results []*JobResult) []*edkeygen.LocalPartySaveData {
 	return outputs
 }
 
+func GetEdPresignOutputs(results []*JobResult) []*edpresign.PresignData {
+	outputs := make([]*edpresign.PresignData, len(results))
+	for i := range results {
+		outputs[i] = results[i].EdPresign
+	}
+
+	return outputs
+}
+
 func GetEdSigningOutputs(results []*JobResult) []*edsigning.SignatureData {
 	outputs := make([]*edsigning.SignatureData, len(results))
 	for i := range results {
@@ -52,3 +63,21 @@ func GetEdSigningOutputs(results []*JobResult) []*edsigning.SignatureData {
 
 	return outputs
 }
+
+func GetSchnorrKeygenOutputs(results []*JobResult) []*schnorrkeygen.LocalPartySaveData {
+	outputs := make([]*schnorrkeygen.LocalPartySaveData, len(results))
+	for i := range results {
+		outputs[i] = results[i].SchnorrKeygen
+	}
+
+	return outputs
+}
+
+func GetSchnorrSigningOutputs(results []*JobResult) []*libCommon.ECSignature {
+	outputs := make([]*libCommon.ECSignature, len(results))
+	for i := range results {
+		outputs[i] = results[i].SchnorrSigning
+	}
+
+	return outputs
+}
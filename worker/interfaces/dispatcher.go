@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"github.com/sisu-network/dheart/types/common"
+	"github.com/sisu-network/dheart/worker/types"
 	"github.com/sisu-network/tss-lib/tss"
 )
 
@@ -13,3 +14,18 @@ type MessageDispatcher interface {
 	// Send a message to a single destination.
 	UnicastMessage(dest *tss.PartyID, tssMessage *common.TssMessage)
 }
+
+// PeerHealth reports the liveness of the peers in the network.
+type PeerHealth interface {
+	// IsPeerDown returns true if the peer with this party id is known to be unreachable or is
+	// misbehaving. Such peers are not selected for new works.
+	IsPeerDown(pid string) bool
+}
+
+// Availability reports whether this node can take part in a new tss work.
+type Availability interface {
+	// GetAvailability returns the answer of this node to the leader's availability request for a
+	// work and the number of works (including this one) that the node can still take. A node that
+	// cannot do the work at all, e.g. because it does not hold the key, returns 0.
+	GetAvailability(request *types.WorkRequest) (common.AvailabilityResponseMessage_ANSWER, int)
+}
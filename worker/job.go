@@ -1,15 +1,19 @@
 package worker
 
 import (
-	"crypto/elliptic"
 	"fmt"
 	"math/big"
 	"sync"
 	"time"
 
 	"github.com/sisu-network/dheart/core/message"
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
+	schnorrkeygen "github.com/sisu-network/dheart/schnorr/keygen"
+	schnorrsigning "github.com/sisu-network/dheart/schnorr/signing"
+	"github.com/sisu-network/dheart/utils"
 	"github.com/sisu-network/dheart/worker/helper"
 	"github.com/sisu-network/lib/log"
+	libCommon "github.com/sisu-network/tss-lib/common"
 	eckeygen "github.com/sisu-network/tss-lib/ecdsa/keygen"
 	ecsigning "github.com/sisu-network/tss-lib/ecdsa/signing"
 	edkeygen "github.com/sisu-network/tss-lib/eddsa/keygen"
@@ -43,7 +47,11 @@ type JobResult struct {
 	EcSigning *ecsigning.SignatureData
 
 	EdKeygen  *edkeygen.LocalPartySaveData
+	EdPresign *edpresign.PresignData
 	EdSigning *edsigning.SignatureData
+
+	SchnorrKeygen  *schnorrkeygen.LocalPartySaveData
+	SchnorrSigning *libCommon.ECSignature
 }
 
 type Job struct {
@@ -64,8 +72,13 @@ type Job struct {
 
 	// Eddsa
 	edEndKeygenCh  chan edkeygen.LocalPartySaveData
+	edEndPresignCh chan *edpresign.PresignData
 	edEndSigningCh chan *edsigning.SignatureData
 
+	// Schnorr
+	schnorrEndKeygenCh  chan schnorrkeygen.LocalPartySaveData
+	schnorrEndSigningCh chan *libCommon.ECSignature
+
 	// Mutable data
 	finishedMsgs map[string]bool
 	finishLock   *sync.RWMutex
@@ -112,7 +125,7 @@ func NewEcSigningJob(
 	if msg == nil {
 		msgInt = nil
 	} else {
-		msgInt = hashToInt(msg, tss.EC(tss.EcdsaScheme))
+		msgInt = utils.HashToInt(msg, tss.EC(tss.EcdsaScheme))
 	}
 	party := ecsigning.NewLocalParty(msgInt, params, keygenData, signingInput, outCh, endCh)
 
@@ -142,6 +155,26 @@ func NewEdKeygenJob(
 	return job
 }
 
+func NewEdPresignJob(
+	workId string,
+	index int,
+	pIDs tss.SortedPartyIDs,
+	params *tss.Parameters,
+	callback JobCallback,
+	timeOut time.Duration,
+) *Job {
+	outCh := make(chan tss.Message, len(pIDs))
+	endCh := make(chan *edpresign.PresignData, len(pIDs))
+	party := edpresign.NewLocalParty(params, outCh, endCh)
+
+	job := baseJob(workId, index, party, wTypes.EdPresign, callback, outCh, timeOut)
+	job.edEndPresignCh = endCh
+
+	return job
+}
+
+// NewEdSigningJob creates an EdDSA signing job. If presignData is not nil, the job signs in a single
+// round with the presign.
 func NewEdSigningJob(
 	workId string,
 	index int,
@@ -149,16 +182,62 @@ func NewEdSigningJob(
 	params *tss.Parameters,
 	msg []byte,
 	signingInput edkeygen.LocalPartySaveData,
+	presignData *edpresign.PresignData,
 	callback JobCallback,
 	timeOut time.Duration,
 ) *Job {
 	outCh := make(chan tss.Message, len(pIDs))
 	endCh := make(chan *edsigning.SignatureData, len(pIDs))
 
-	party := edsigning.NewLocalParty(new(big.Int).SetBytes(msg), params, signingInput, outCh, endCh)
+	var party tss.Party
+	if presignData == nil {
+		party = edsigning.NewLocalParty(new(big.Int).SetBytes(msg), params, signingInput, outCh, endCh)
+	} else {
+		party = edpresign.NewSigningParty(msg, params, signingInput, presignData, outCh, endCh)
+	}
 
-	job := baseJob(workId, index, party, wTypes.EdKeygen, callback, outCh, timeOut)
+	job := baseJob(workId, index, party, wTypes.EdSigning, callback, outCh, timeOut)
 	job.edEndSigningCh = endCh
+	job.hasPresignData = presignData != nil
+
+	return job
+}
+
+func NewSchnorrKeygenJob(
+	workId string,
+	index int,
+	pIDs tss.SortedPartyIDs,
+	params *tss.Parameters,
+	callback JobCallback,
+	timeOut time.Duration,
+) *Job {
+	outCh := make(chan tss.Message, len(pIDs))
+	endCh := make(chan schnorrkeygen.LocalPartySaveData, len(pIDs))
+	party := schnorrkeygen.NewLocalParty(params, outCh, endCh)
+
+	job := baseJob(workId, index, party, wTypes.SchnorrKeygen, callback, outCh, timeOut)
+	job.schnorrEndKeygenCh = endCh
+
+	return job
+}
+
+func NewSchnorrSigningJob(
+	workId string,
+	index int,
+	pIDs tss.SortedPartyIDs,
+	params *tss.Parameters,
+	msg []byte,
+	signingInput schnorrkeygen.LocalPartySaveData,
+	callback JobCallback,
+	timeOut time.Duration,
+) *Job {
+	outCh := make(chan tss.Message, len(pIDs))
+	endCh := make(chan *libCommon.ECSignature, len(pIDs))
+
+	party := schnorrsigning.NewLocalParty(msg, params, signingInput, outCh, endCh)
+
+	job := baseJob(workId, index, party, wTypes.SchnorrSigning, callback, outCh, timeOut)
+	job.schnorrEndSigningCh = endCh
 
 	return job
 }
@@ -187,21 +266,6 @@ func baseJob(
 	}
 }
 
-func hashToInt(hash []byte, c elliptic.Curve) *big.Int {
-	orderBits := c.Params().N.BitLen()
-	orderBytes := (orderBits + 7) / 8
-	if len(hash) > orderBytes {
-		hash = hash[:orderBytes]
-	}
-
-	ret := new(big.Int).SetBytes(hash)
-	excess := len(hash)*8 - orderBits
-	if excess > 0 {
-		ret.Rsh(ret, uint(excess))
-	}
-	return ret
-}
-
 func (job *Job) Start() error {
 	if err := job.party.Start(); err != nil {
 		return fmt.Errorf("error when starting party %w", err)
@@ -272,6 +336,17 @@ func (job *Job) startListening() {
 				return
 			}
 
+		case data := <-job.edEndPresignCh:
+			job.doneEndCh.Store(true)
+			job.callback.OnJobResult(job, JobResult{
+				Success:   true,
+				EdPresign: data,
+			})
+
+			if job.isDone() {
+				return
+			}
+
 		case data := <-job.edEndSigningCh:
 			job.doneEndCh.Store(true)
 			job.callback.OnJobResult(job, JobResult{
@@ -279,6 +354,29 @@ func (job *Job) startListening() {
 				EdSigning: data,
 			})
 
+			if job.isDone() {
+				return
+			}
+
+		// Schnorr
+		case data := <-job.schnorrEndKeygenCh:
+			job.doneEndCh.Store(true)
+			job.callback.OnJobResult(job, JobResult{
+				Success:       true,
+				SchnorrKeygen: &data,
+			})
+
+			if job.isDone() {
+				return
+			}
+
+		case data := <-job.schnorrEndSigningCh:
+			job.doneEndCh.Store(true)
+			job.callback.OnJobResult(job, JobResult{
+				Success:        true,
+				SchnorrSigning: data,
+			})
+
 			if job.isDone() {
 				return
 			}
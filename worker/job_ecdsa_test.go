@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sisu-network/dheart/utils"
 	"github.com/sisu-network/tss-lib/crypto"
 	eckeygen "github.com/sisu-network/tss-lib/ecdsa/keygen"
 	ecsigning "github.com/sisu-network/tss-lib/ecdsa/signing"
@@ -169,3 +170,43 @@ func verifyEcSignature(t *testing.T, msg string, results []JobResult, pubkey *cr
 	)
 	assert.True(t, ok, "ecdsa verify must pass")
 }
+
+func TestEcJob_Signing_ChildKey(t *testing.T) {
+	n := 4
+	threshold := 1
+
+	jobs := make([]*Job, n)
+	cbs := make([]*MockJobCallback, n)
+	results := make([]JobResult, n)
+
+	for i := 0; i < n; i++ {
+		index := i
+		cbs[index] = &MockJobCallback{}
+		cbs[index].OnJobResultFunc = func(job *Job, result JobResult) {
+			results[index] = result
+		}
+	}
+
+	// Every party derives its keygen data for the same path and the parties sign with the child key.
+	pIDs := GetTestPartyIds(n)
+	keygenOutputs := LoadEcKeygenSavedData(pIDs)
+	childOutputs := make([]*eckeygen.LocalPartySaveData, n)
+	for i := range keygenOutputs {
+		var err error
+		childOutputs[i], err = utils.DeriveEcKeygen(keygenOutputs[i], []uint32{7, 3})
+		require.NoError(t, err)
+	}
+	require.False(t, childOutputs[0].ECDSAPub.Equals(keygenOutputs[0].ECDSAPub))
+	msg := "Test message"
+
+	for i := 0; i < n; i++ {
+		p2pCtx := tss.NewPeerContext(pIDs)
+		params := tss.NewParameters(p2pCtx, pIDs[i], len(pIDs), threshold)
+		jobs[i] = NewEcSigningJob("Signinng0", i, pIDs, params, []byte(msg), *childOutputs[i],
+			nil, cbs[i], time.Second*15, "eth")
+	}
+
+	runJobs(t, jobs, cbs, true)
+
+	verifyEcSignature(t, msg, results, childOutputs[0].ECDSAPub)
+}
@@ -1,10 +1,14 @@
 package worker
 
 import (
+	"crypto/ed25519"
+	"math/big"
 	"testing"
 	"time"
 
 	"github.com/decred/dcrd/dcrec/edwards/v2"
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
+	"github.com/sisu-network/dheart/utils"
 	edkeygen "github.com/sisu-network/tss-lib/eddsa/keygen"
 	"github.com/sisu-network/tss-lib/tss"
 	"github.com/stretchr/testify/assert"
@@ -68,7 +72,7 @@ func TestEdJob_Signing(t *testing.T) {
 	for i := 0; i < n; i++ {
 		p2pCtx := tss.NewPeerContext(pIDs)
 		params := tss.NewParameters(p2pCtx, pIDs[i], len(pIDs), threshold)
-		jobs[i] = NewEdSigningJob("EdSign0", i, pIDs, params, msgBytes, *keygenOutputs[i], cbs[i], time.Second*10)
+		jobs[i] = NewEdSigningJob("EdSign0", i, pIDs, params, msgBytes, *keygenOutputs[i], nil, cbs[i], time.Second*10)
 	}
 
 	runJobs(t, jobs, cbs, true)
@@ -94,3 +98,105 @@ func TestEdJob_Signing(t *testing.T) {
 	ok := edwards.Verify(&pk, msgBytes, newSig.R, newSig.S)
 	assert.True(t, ok, "eddsa verify must pass")
 }
+
+func TestEdJob_Signing_ChildKey(t *testing.T) {
+	n := 4
+	threshold := 1
+	jobs := make([]*Job, n)
+	cbs := make([]*MockJobCallback, n)
+	results := make([]JobResult, n)
+
+	for i := 0; i < n; i++ {
+		index := i
+		cbs[index] = &MockJobCallback{}
+		cbs[index].OnJobResultFunc = func(job *Job, result JobResult) {
+			results[index] = result
+		}
+	}
+
+	// Every party derives its keygen data for the same path and the parties sign with the child key.
+	pIDs := GetTestPartyIds(n)
+	keygenOutputs := LoadEdKeygenSavedData(pIDs)
+	childOutputs := make([]*edkeygen.LocalPartySaveData, n)
+	for i := range keygenOutputs {
+		var err error
+		childOutputs[i], err = utils.DeriveEdKeygen(keygenOutputs[i], []uint32{7, 3})
+		require.NoError(t, err)
+	}
+	msgBytes := []byte("Test")
+
+	for i := 0; i < n; i++ {
+		p2pCtx := tss.NewPeerContext(pIDs)
+		params := tss.NewParameters(p2pCtx, pIDs[i], len(pIDs), threshold)
+		jobs[i] = NewEdSigningJob("EdSign0", i, pIDs, params, msgBytes, *childOutputs[i], nil, cbs[i], time.Second*10)
+	}
+
+	runJobs(t, jobs, cbs, true)
+
+	for _, result := range results {
+		require.Equal(t, result.EdSigning.Signature.Signature, results[0].EdSigning.Signature.Signature)
+	}
+
+	// The signature is a standard ed25519 signature of the child key.
+	pubKey := edwards.NewPublicKey(childOutputs[0].EDDSAPub.X(), childOutputs[0].EDDSAPub.Y())
+	require.True(t, ed25519.Verify(pubKey.Serialize(), msgBytes, results[0].EdSigning.Signature.Signature))
+}
+
+func TestEdJob_PresignAndSigning(t *testing.T) {
+	n := 4
+	threshold := 1
+	pIDs := GetTestPartyIds(n)
+	keygenOutputs := LoadEdKeygenSavedData(pIDs)
+
+	// Run the presign.
+	jobs := make([]*Job, n)
+	cbs := make([]*MockJobCallback, n)
+	presigns := make([]*edpresign.PresignData, n)
+	for i := 0; i < n; i++ {
+		index := i
+		cbs[index] = &MockJobCallback{}
+		cbs[index].OnJobResultFunc = func(job *Job, result JobResult) {
+			presigns[index] = result.EdPresign
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		p2pCtx := tss.NewPeerContext(pIDs)
+		params := tss.NewParameters(p2pCtx, pIDs[i], len(pIDs), threshold)
+		jobs[i] = NewEdPresignJob("EdPresign0", i, pIDs, params, cbs[i], time.Second*10)
+	}
+
+	runJobs(t, jobs, cbs, true)
+
+	// Sign with the presigns.
+	results := make([]JobResult, n)
+	for i := 0; i < n; i++ {
+		index := i
+		cbs[index] = &MockJobCallback{}
+		cbs[index].OnJobResultFunc = func(job *Job, result JobResult) {
+			results[index] = result
+		}
+	}
+	msgBytes := []byte("Test")
+
+	for i := 0; i < n; i++ {
+		require.NotNil(t, presigns[i])
+		p2pCtx := tss.NewPeerContext(pIDs)
+		params := tss.NewParameters(p2pCtx, pIDs[i], len(pIDs), threshold)
+		jobs[i] = NewEdSigningJob("EdSign0", i, pIDs, params, msgBytes, *keygenOutputs[i], presigns[i], cbs[i], time.Second*10)
+	}
+
+	runJobs(t, jobs, cbs, true)
+
+	for _, result := range results {
+		require.Equal(t, result.EdSigning.Signature.Signature, results[0].EdSigning.Signature.Signature)
+	}
+
+	// The signature is a standard ed25519 signature and its R and S can be verified like the signatures
+	// of the signing without presign.
+	pubKey := edwards.NewPublicKey(keygenOutputs[0].EDDSAPub.X(), keygenOutputs[0].EDDSAPub.Y())
+	signature := results[0].EdSigning.Signature
+	require.True(t, ed25519.Verify(pubKey.Serialize(), msgBytes, signature.Signature))
+	require.True(t, edwards.Verify(pubKey, msgBytes, new(big.Int).SetBytes(signature.R),
+		new(big.Int).SetBytes(signature.S)))
+}
@@ -0,0 +1,163 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	schnorrkeygen "github.com/sisu-network/dheart/schnorr/keygen"
+	"github.com/sisu-network/dheart/utils"
+	"github.com/sisu-network/tss-lib/tss"
+	"github.com/stretchr/testify/require"
+)
+
+func runSchnorrKeygen(t *testing.T, pIDs tss.SortedPartyIDs, threshold int) []*schnorrkeygen.LocalPartySaveData {
+	n := len(pIDs)
+	jobs := make([]*Job, n)
+	cbs := make([]*MockJobCallback, n)
+	outputs := make([]*schnorrkeygen.LocalPartySaveData, n)
+
+	for i := 0; i < n; i++ {
+		index := i
+		cbs[index] = &MockJobCallback{}
+		cbs[index].OnJobResultFunc = func(job *Job, result JobResult) {
+			outputs[index] = result.SchnorrKeygen
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		p2pCtx := tss.NewPeerContext(pIDs)
+		params := tss.NewParameters(p2pCtx, pIDs[i], len(pIDs), threshold)
+		jobs[i] = NewSchnorrKeygenJob("SchnorrKeygen0", i, pIDs, params, cbs[i], time.Second*60)
+	}
+
+	runJobs(t, jobs, cbs, true)
+
+	return outputs
+}
+
+// runSchnorrSigning signs a message with the keygen outputs of the parties at the signer indexes.
+func runSchnorrSigning(t *testing.T, pIDs tss.SortedPartyIDs, signers []int, threshold int, msg []byte,
+	keygenOutputs []*schnorrkeygen.LocalPartySaveData) []JobResult {
+	signerIds := make([]*tss.PartyID, len(signers))
+	for i, signer := range signers {
+		signerIds[i] = tss.NewPartyID(pIDs[signer].Id, pIDs[signer].Moniker, pIDs[signer].KeyInt())
+	}
+	sorted := tss.SortPartyIDs(signerIds)
+
+	n := len(sorted)
+	jobs := make([]*Job, n)
+	cbs := make([]*MockJobCallback, n)
+	results := make([]JobResult, n)
+	for i := 0; i < n; i++ {
+		index := i
+		cbs[index] = &MockJobCallback{}
+		cbs[index].OnJobResultFunc = func(job *Job, result JobResult) {
+			results[index] = result
+		}
+	}
+
+	for i, pid := range sorted {
+		var keygenOutput *schnorrkeygen.LocalPartySaveData
+		for j := range pIDs {
+			if pIDs[j].Id == pid.Id {
+				keygenOutput = keygenOutputs[j]
+			}
+		}
+
+		p2pCtx := tss.NewPeerContext(sorted)
+		params := tss.NewParameters(p2pCtx, pid, n, threshold)
+		jobs[i] = NewSchnorrSigningJob("SchnorrSign0", i, sorted, params, msg, *keygenOutput, cbs[i], time.Second*10)
+	}
+
+	runJobs(t, jobs, cbs, true)
+
+	return results
+}
+
+func TestSchnorrJob_KeygenAndSigning(t *testing.T) {
+	t.Parallel()
+
+	n := 4
+	threshold := 1
+	pIDs := GetTestPartyIds(n)
+
+	keygenOutputs := runSchnorrKeygen(t, pIDs, threshold)
+	pubKey := keygenOutputs[0].SchnorrPub
+	// Keygen outputs the key with an even y coordinate.
+	require.Equal(t, uint(0), pubKey.Y().Bit(0))
+	for _, output := range keygenOutputs {
+		require.True(t, output.SchnorrPub.Equals(pubKey))
+	}
+
+	// A message with a leading zero byte is signed as is.
+	msg := append([]byte{0}, []byte("Test message to sign")...)
+	results := runSchnorrSigning(t, pIDs, []int{3, 1}, threshold, msg, keygenOutputs)
+
+	for _, result := range results {
+		require.Equal(t, results[0].SchnorrSigning.Signature, result.SchnorrSigning.Signature)
+	}
+	require.NoError(t, utils.VerifySchnorrSignature(utils.SerializeSchnorrPubKey(pubKey), msg,
+		results[0].SchnorrSigning.Signature))
+}
+
+func TestSchnorrJob_Signing_ChildKey(t *testing.T) {
+	t.Parallel()
+
+	n := 3
+	threshold := 1
+	pIDs := GetTestPartyIds(n)
+	keygenOutputs := runSchnorrKeygen(t, pIDs, threshold)
+
+	// Find a child key with an odd y coordinate. Its shares are negated during signing.
+	var childOutputs []*schnorrkeygen.LocalPartySaveData
+	for index := uint32(0); childOutputs == nil; index++ {
+		outputs := make([]*schnorrkeygen.LocalPartySaveData, n)
+		for i := range keygenOutputs {
+			var err error
+			outputs[i], err = utils.DeriveSchnorrKeygen(keygenOutputs[i], []uint32{index})
+			require.NoError(t, err)
+		}
+
+		if outputs[0].SchnorrPub.Y().Bit(0) == 1 {
+			childOutputs = outputs
+		}
+	}
+
+	msg := []byte("Test")
+	results := runSchnorrSigning(t, pIDs, []int{0, 1, 2}, threshold, msg, childOutputs)
+
+	for _, result := range results {
+		require.Equal(t, results[0].SchnorrSigning.Signature, result.SchnorrSigning.Signature)
+	}
+	require.NoError(t, utils.VerifySchnorrSignature(utils.SerializeSchnorrPubKey(childOutputs[0].SchnorrPub), msg,
+		results[0].SchnorrSigning.Signature))
+}
+
+func TestSchnorrJob_Signing_TapTweak(t *testing.T) {
+	t.Parallel()
+
+	n := 3
+	threshold := 1
+	pIDs := GetTestPartyIds(n)
+	keygenOutputs := runSchnorrKeygen(t, pIDs, threshold)
+
+	tweakedOutputs := make([]*schnorrkeygen.LocalPartySaveData, n)
+	for i := range keygenOutputs {
+		var err error
+		tweakedOutputs[i], err = utils.TapTweakSchnorrKeygen(keygenOutputs[i])
+		require.NoError(t, err)
+	}
+
+	_, outputKey, err := utils.TapTweakPubKey(keygenOutputs[0].SchnorrPub)
+	require.NoError(t, err)
+	require.True(t, outputKey.Equals(tweakedOutputs[0].SchnorrPub))
+
+	msg := []byte("Test")
+	results := runSchnorrSigning(t, pIDs, []int{0, 1, 2}, threshold, msg, tweakedOutputs)
+
+	for _, result := range results {
+		require.Equal(t, results[0].SchnorrSigning.Signature, result.SchnorrSigning.Signature)
+	}
+	require.NoError(t, utils.VerifySchnorrSignature(utils.SerializeSchnorrPubKey(outputKey), msg,
+		results[0].SchnorrSigning.Signature))
+}
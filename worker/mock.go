@@ -17,6 +17,7 @@ import (
 
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
 	"github.com/sisu-network/dheart/types/common"
 	"github.com/sisu-network/dheart/worker/types"
 	libCommon "github.com/sisu-network/tss-lib/common"
@@ -67,8 +68,11 @@ type MockWorkerCallback struct {
 
 	OnNodeNotSelectedFunc    func(request *types.WorkRequest)
 	OnWorkFailedFunc         func(request *types.WorkRequest)
-	GetAvailablePresignsFunc func(count int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID)
+	GetAvailablePresignsFunc func(keyType string, keyLabel string, count int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID)
 	GetPresignOutputsFunc    func(presignIds []string) []*ecsigning.SignatureData_OneRoundData
+	GetEdPresignOutputsFunc  func(presignIds []string) []*edpresign.PresignData
+	IsPeerDownFunc           func(pid string) bool
+	GetAvailabilityFunc      func(request *types.WorkRequest) (common.AvailabilityResponseMessage_ANSWER, int)
 
 	workerIndex     int
 	keygenCallback  func(workerIndex int, request *types.WorkRequest, data []*eckeygen.LocalPartySaveData)
@@ -94,9 +98,9 @@ func (cb *MockWorkerCallback) OnWorkFailed(request *types.WorkRequest) {
 	}
 }
 
-func (cb *MockWorkerCallback) GetAvailablePresigns(count int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
+func (cb *MockWorkerCallback) GetAvailablePresigns(keyType string, keyLabel string, count int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
 	if cb.GetAvailablePresignsFunc != nil {
-		return cb.GetAvailablePresignsFunc(count, n, allPids)
+		return cb.GetAvailablePresignsFunc(keyType, keyLabel, count, n, allPids)
 	}
 
 	return nil, nil
@@ -110,6 +114,30 @@ func (cb *MockWorkerCallback) GetPresignOutputs(presignIds []string) []*ecsignin
 	return nil
 }
 
+func (cb *MockWorkerCallback) GetEdPresignOutputs(presignIds []string) []*edpresign.PresignData {
+	if cb.GetEdPresignOutputsFunc != nil {
+		return cb.GetEdPresignOutputsFunc(presignIds)
+	}
+
+	return nil
+}
+
+func (cb *MockWorkerCallback) IsPeerDown(pid string) bool {
+	if cb.IsPeerDownFunc != nil {
+		return cb.IsPeerDownFunc(pid)
+	}
+
+	return false
+}
+
+func (cb *MockWorkerCallback) GetAvailability(request *types.WorkRequest) (common.AvailabilityResponseMessage_ANSWER, int) {
+	if cb.GetAvailabilityFunc != nil {
+		return cb.GetAvailabilityFunc(request)
+	}
+
+	return common.AvailabilityResponseMessage_YES, 1
+}
+
 //---/
 
 type PresignDataWrapper struct {
@@ -18,6 +18,7 @@ import (
 	"github.com/sisu-network/dheart/worker/helper"
 	"github.com/sisu-network/dheart/worker/interfaces"
 	"github.com/sisu-network/dheart/worker/types"
+	libchain "github.com/sisu-network/lib/chain"
 	"github.com/sisu-network/lib/log"
 	"github.com/sisu-network/tss-lib/tss"
 	"go.uber.org/atomic"
@@ -57,8 +58,13 @@ type PreworkSelection struct {
 	///////////////////////
 	leader          *tss.PartyID
 	presignsManager corecomponents.AvailablePresigns
+	peerHealth      interfaces.PeerHealth
+	availability    interfaces.Availability
 	// List of parties who indicate that they are available for current tss work.
 	availableParties *AvailableParties
+	// List of parties who indicate that they are busy. They are only selected for mandatory works
+	// when there are not enough available parties.
+	busyParties *AvailableParties
 
 	// Cache all tss update messages when some parties start executing while this node has not.
 	stopped *atomic.Bool
@@ -66,7 +72,8 @@ type PreworkSelection struct {
 
 func NewPreworkSelection(request *types.WorkRequest, allParties []*tss.PartyID, myPid *tss.PartyID,
 	db db.Database, preExecutionCache *enginecache.MessageCache, dispatcher interfaces.MessageDispatcher,
-	presignsManager corecomponents.AvailablePresigns, cfg config.TimeoutConfig, callback func(SelectionResult)) *PreworkSelection {
+	presignsManager corecomponents.AvailablePresigns, peerHealth interfaces.PeerHealth,
+	availability interfaces.Availability, cfg config.TimeoutConfig, callback func(SelectionResult)) *PreworkSelection {
 
 	leader := ChooseLeader(request.WorkId, request.AllParties)
 	return &PreworkSelection{
@@ -74,11 +81,14 @@ func NewPreworkSelection(request *types.WorkRequest, allParties []*tss.PartyID,
 		allParties:       allParties,
 		db:               db,
 		availableParties: NewAvailableParties(),
+		busyParties:      NewAvailableParties(),
 		myPid:            myPid,
 		leader:           leader,
 		dispatcher:       dispatcher,
 		preExecMsgCh:     make(chan *commonTypes.PreExecOutputMessage, 1),
 		presignsManager:  presignsManager,
+		peerHealth:       peerHealth,
+		availability:     availability,
 		memberResponseCh: make(chan *commonTypes.TssMessage, len(allParties)),
 		callback:         callback,
 		stopped:          atomic.NewBool(false),
@@ -104,7 +114,7 @@ func ChooseLeader(workId string, parties []*tss.PartyID) *tss.PartyID {
 }
 
 func (s *PreworkSelection) Init() {
-	s.availableParties.add(s.myPid, 1)
+	s.availableParties.add(s.myPid, 1, 0)
 }
 
 func (s *PreworkSelection) Run(cachedMsgs []*commonTypes.TssMessage) {
@@ -126,14 +136,24 @@ func (s *PreworkSelection) Stop() {
 ////////////////////////////////////////////////////////////////////////////
 
 func (s *PreworkSelection) doPreExecutionAsLeader(cachedMsgs []*commonTypes.TssMessage) {
+	// The leader takes part in the work. It checks its own load like the members do.
+	answer, maxJob := s.availability.GetAvailability(s.request)
+	if maxJob == 0 || (answer == common.AvailabilityResponseMessage_NO && !s.request.IsMandatory()) {
+		log.Warnf("Leader: this node cannot take workId = %s, answer = %s, maxJob = %d", s.request.WorkId,
+			answer, maxJob)
+		s.leaderFinalized(false, nil, nil)
+		return
+	}
+	s.availableParties.add(s.myPid, maxJob, s.getPresignCount())
+
 	// Update availability from cache first.
 	for _, tssMsg := range cachedMsgs {
-		if tssMsg.Type == common.TssMessage_AVAILABILITY_RESPONSE && tssMsg.AvailabilityResponseMessage.Answer == common.AvailabilityResponseMessage_YES {
+		if tssMsg.Type == common.TssMessage_AVAILABILITY_RESPONSE {
 			// update the availability.
 			for _, p := range s.allParties {
 				if p.Id == tssMsg.From {
 					log.Verbose("Leader: Pid", p.Id, "has responded to us from a message in the cache for workId = ", s.request.WorkId)
-					s.availableParties.add(p, 1)
+					s.addMemberResponse(p, tssMsg.AvailabilityResponseMessage)
 					break
 				}
 			}
@@ -146,13 +166,22 @@ func (s *PreworkSelection) doPreExecutionAsLeader(cachedMsgs []*commonTypes.TssM
 		return
 	}
 
+	// Parties that are known to be down cannot respond. Fail early instead of waiting for the timeout
+	// if there are not enough parties left.
+	if s.getReachableCount() < s.request.GetMinPartyCount() {
+		log.Warnf("Leader: not enough reachable parties for workId = %s, reachable = %d, required = %d",
+			s.request.WorkId, s.getReachableCount(), s.request.GetMinPartyCount())
+		s.leaderFinalized(false, nil, nil)
+		return
+	}
+
 	for _, p := range s.allParties {
-		if p.Id == s.myPid.Id {
+		if p.Id == s.myPid.Id || s.peerHealth.IsPeerDown(p.Id) {
 			continue
 		}
 
 		// Only send request message to parties that has not sent a message to us.
-		if s.availableParties.getParty(p.Id) == nil {
+		if !s.hasResponded(p.Id) {
 			tssMsg := common.NewAvailabilityRequestMessage(s.myPid.Id, p.Id, s.request.WorkId)
 			log.Verbosef("Leader: sending query to %s for workId = %s", p.Id, s.request.WorkId)
 			go s.dispatcher.UnicastMessage(p, tssMsg)
@@ -191,6 +220,11 @@ func (s *PreworkSelection) waitForMemberResponse() ([]string, []*tss.PartyID, er
 				return nil, selectedPids, nil
 			}
 
+			if ok, selectedPids := s.forceSelection(); ok {
+				log.Info("Leader: timeout, selecting busy parties for this mandatory work")
+				return nil, selectedPids, nil
+			}
+
 			log.Info("LEADER: timeout")
 
 			return nil, nil, errors.New("timeout: cannot find enough members for this work")
@@ -212,17 +246,26 @@ func (s *PreworkSelection) waitForMemberResponse() ([]string, []*tss.PartyID, er
 				continue
 			}
 
-			if tssMsg.AvailabilityResponseMessage.Answer == commonTypes.AvailabilityResponseMessage_YES {
-				s.availableParties.add(party, 1)
-				// TODO: Check if this is a new member to save one call for checkEnoughParticipants
-				if ok, presignIds, selectedPids := s.checkEnoughParticipants(); ok {
-					s := ""
-					for _, pid := range selectedPids {
-						s += pid.Id
-					}
-					log.Infof("Leader: selectedPids found, selectedPids = %s", s)
-					return presignIds, selectedPids, nil
+			s.addMemberResponse(party, tssMsg.AvailabilityResponseMessage)
+			// TODO: Check if this is a new member to save one call for checkEnoughParticipants
+			if ok, presignIds, selectedPids := s.checkEnoughParticipants(); ok {
+				s := ""
+				for _, pid := range selectedPids {
+					s += pid.Id
 				}
+				log.Infof("Leader: selectedPids found, selectedPids = %s", s)
+				return presignIds, selectedPids, nil
+			}
+
+			// Everyone has answered but not enough parties are available. There is no point to wait
+			// any longer.
+			if s.allReachablePartiesResponded() {
+				if ok, selectedPids := s.forceSelection(); ok {
+					log.Info("Leader: selecting busy parties for this mandatory work")
+					return nil, selectedPids, nil
+				}
+
+				return nil, nil, errors.New("not enough parties are available for this work")
 			}
 		}
 	}
@@ -237,23 +280,27 @@ func (s *PreworkSelection) checkEnoughParticipants() (bool, []string, []*tss.Par
 		return false, nil, make([]*tss.PartyID, 0)
 	}
 
-	if s.request.IsEddsa() {
+	// Schnorr works have no presigns.
+	if s.request.IsSchnorr() {
 		parties := s.availableParties.getPartyList(s.request.GetMinPartyCount()-1, s.myPid)
 		parties = append(parties, s.myPid)
 
 		return true, nil, parties
 	}
 
-	if s.request.IsSigning() && s.request.Messages != nil {
+	// Signing with a child key cannot use presigns.
+	if s.request.IsSigning() && s.request.Messages != nil && s.request.DerivationPath == "" {
 		batchSize := len(s.request.Messages)
 
-		// Check if we can find a presign list that match this of nodes.
-		presignIds, selectedPids := s.presignsManager.GetAvailablePresigns(batchSize, s.request.N, s.availableParties.getAllPartiesMap())
+		// Check if we can find a presign list that match this of nodes. Only the parties that hold
+		// enough presigns of the key can sign with a presign.
+		presignIds, selectedPids := s.presignsManager.GetAvailablePresigns(s.presignKeyType(), s.request.KeyLabel, batchSize,
+			s.request.N, s.availableParties.getPresignPartiesMap(batchSize))
 		if len(presignIds) == batchSize {
 			log.Info("We found a presign set: presignIds = ", presignIds, " batchSize = ", batchSize, " selectedPids = ", selectedPids)
 			// Announce this as success and return
 			return true, presignIds, selectedPids
-		} else if s.availableParties.Length() == len(s.allParties) {
+		} else if s.allReachablePartiesResponded() {
 			selected := s.availableParties.getPartyList(s.request.Threshold, s.myPid)
 			selected = append(selected, s.myPid) // include this leader in the list of final selection
 			return true, nil, selected
@@ -269,6 +316,91 @@ func (s *PreworkSelection) checkEnoughParticipants() (bool, []string, []*tss.Par
 	}
 }
 
+// getPresignCount returns the number of presigns of the work's key that this node holds. Works
+// that cannot use presigns have none.
+func (s *PreworkSelection) getPresignCount() int {
+	if s.request.IsSchnorr() || !s.request.IsSigning() || s.request.Messages == nil ||
+		s.request.DerivationPath != "" {
+		return 0
+	}
+
+	return s.presignsManager.GetPresignCount(s.presignKeyType(), s.request.KeyLabel)
+}
+
+// presignKeyType returns the key type of the presigns used by this work.
+func (s *PreworkSelection) presignKeyType() string {
+	if s.request.IsEcdsa() {
+		return libchain.KEY_TYPE_ECDSA
+	}
+
+	return libchain.KEY_TYPE_EDDSA
+}
+
+// addMemberResponse records the answer of a member to the availability request.
+func (s *PreworkSelection) addMemberResponse(party *tss.PartyID, response *commonTypes.AvailabilityResponseMessage) {
+	if response == nil {
+		log.Error("Leader: empty availability response from ", party.Id)
+		return
+	}
+
+	if response.Answer == commonTypes.AvailabilityResponseMessage_YES {
+		s.availableParties.add(party, int(response.MaxJob), int(response.PresignCount))
+	} else {
+		log.Verbosef("Leader: %s is busy, workId = %s, maxJob = %d", party.Id, s.request.WorkId, response.MaxJob)
+		s.busyParties.add(party, int(response.MaxJob), int(response.PresignCount))
+	}
+}
+
+// hasResponded returns true if the party has answered the availability request.
+func (s *PreworkSelection) hasResponded(pid string) bool {
+	return s.availableParties.hasPartyId(pid) || s.busyParties.hasPartyId(pid)
+}
+
+// forceSelection fills up the committee of a mandatory work with busy parties when there are not
+// enough available parties. Busy parties with more free worker slots are selected first. Parties
+// that cannot do the work at all are never selected.
+func (s *PreworkSelection) forceSelection() (bool, []*tss.PartyID) {
+	if !s.request.IsMandatory() {
+		return false, nil
+	}
+
+	missing := s.request.GetMinPartyCount() - s.availableParties.Length()
+	forced, _ := s.busyParties.getTopParties(missing)
+	if len(forced) < missing {
+		return false, nil
+	}
+
+	selected := s.availableParties.getPartyList(s.availableParties.Length(), s.myPid)
+	selected = append(selected, forced...)
+	selected = append(selected, s.myPid)
+
+	return true, selected
+}
+
+// getReachableCount returns the number of parties that are available or not known to be down.
+func (s *PreworkSelection) getReachableCount() int {
+	count := 0
+	for _, p := range s.allParties {
+		if p.Id == s.myPid.Id || s.hasResponded(p.Id) || !s.peerHealth.IsPeerDown(p.Id) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// allReachablePartiesResponded returns true if every party that is not known to be down has
+// responded to the leader.
+func (s *PreworkSelection) allReachablePartiesResponded() bool {
+	for _, p := range s.allParties {
+		if !s.hasResponded(p.Id) && !s.peerHealth.IsPeerDown(p.Id) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Finalize work as a leader and start execution.
 func (s *PreworkSelection) leaderFinalized(success bool, presignIds []string, selectedPids []*tss.PartyID) {
 	log.Verbosef("%s leader finalized, success = %s", s.myPid.Id, success)
@@ -311,7 +443,7 @@ func (s *PreworkSelection) doPreExecutionAsMember(leader *tss.PartyID, cachedMsg
 	}
 
 	// Send a message to the leader.
-	tssMsg := common.NewAvailabilityResponseMessage(s.myPid.Id, leader.Id, s.request.WorkId, common.AvailabilityResponseMessage_YES, 1)
+	tssMsg := s.newAvailabilityResponse(leader.Id)
 	log.Verbose("Member: Sending response message to the leader, workId = ", s.request.WorkId)
 	go s.dispatcher.UnicastMessage(leader, tssMsg)
 
@@ -368,20 +500,30 @@ func (s *PreworkSelection) memberFinalized(msg *common.PreExecOutputMessage) {
 
 			if s.request.IsSigning() && len(msg.PresignIds) > 0 {
 				// Check if the leader is giving us a valid presign id set.
-				signingInput, err := s.db.LoadPresign(msg.PresignIds)
-				if err != nil || len(signingInput) == 0 {
-					log.Error("Cannot load presign, err =", err, " len(signingInput) = ", len(signingInput))
+				count, err := s.loadPresignCount(msg.PresignIds)
+				if err != nil || count == 0 {
+					log.Error("Cannot load presign, err =", err, " len(signingInput) = ", count)
 					s.broadcastResult(SelectionResult{
 						Success: false,
 					})
 					return
-				} else {
+				}
+
+				// Claim the presigns before sharing anything computed from them. Another leader might
+				// have picked the same presigns and signing twice with a presign leaks the key share.
+				if err := s.presignsManager.ClaimPresigns(s.presignKeyType(), s.request.KeyLabel, msg.PresignIds); err != nil {
+					log.Error("Cannot claim presigns, err =", err)
 					s.broadcastResult(SelectionResult{
-						Success:      true,
-						SelectedPids: pIDs,
-						PresignIds:   msg.PresignIds,
+						Success: false,
 					})
+					return
 				}
+
+				s.broadcastResult(SelectionResult{
+					Success:      true,
+					SelectedPids: pIDs,
+					PresignIds:   msg.PresignIds,
+				})
 			}
 		} else {
 			// We are not in the participant list. Terminate this work. Nothing else to do.
@@ -399,6 +541,17 @@ func (s *PreworkSelection) memberFinalized(msg *common.PreExecOutputMessage) {
 	}
 }
 
+// loadPresignCount returns the number of presigns with the given ids that this node holds.
+func (s *PreworkSelection) loadPresignCount(presignIds []string) (int, error) {
+	if s.request.IsEcdsa() {
+		signingInput, err := s.db.LoadPresign(presignIds)
+		return len(signingInput), err
+	}
+
+	signingInput, err := s.db.LoadEdPresign(presignIds)
+	return len(signingInput), err
+}
+
 // TODO: Add tests for this function
 func (s *PreworkSelection) validateLeaderSelection(msg *common.PreExecOutputMessage) bool {
 	if s.request.IsKeygen() && len(msg.Pids) != len(s.allParties) {
@@ -410,6 +563,21 @@ func (s *PreworkSelection) validateLeaderSelection(msg *common.PreExecOutputMess
 		return false
 	}
 
+	if s.request.DerivationPath != "" && len(msg.PresignIds) > 0 {
+		// Presigns of the TSS key cannot sign with a child key.
+		return false
+	}
+
+	if (s.request.IsSchnorr() || s.request.IsPresign()) && len(msg.PresignIds) > 0 {
+		// Only ECDSA and EdDSA signing use presigns.
+		return false
+	}
+
+	if s.request.IsEddsa() && len(msg.PresignIds) > 0 && len(msg.PresignIds) != s.request.BatchSize {
+		// Every message of an EdDSA signing needs its own presign.
+		return false
+	}
+
 	for _, pid := range msg.Pids {
 		partyId := helper.GetPidFromString(pid, s.allParties)
 		if partyId == nil {
@@ -453,11 +621,9 @@ func (s *PreworkSelection) onPreExecutionRequest(tssMsg *commonTypes.TssMessage)
 
 	if sender != nil {
 		// TODO: Check that the sender is indeed the leader.
-		// We receive a message from a leader to check our availability. Reply "Yes".
-		log.Info("Member: Responding YES to leader's request")
-		responseMsg := common.NewAvailabilityResponseMessage(s.myPid.Id, tssMsg.From, s.request.WorkId,
-			common.AvailabilityResponseMessage_YES, 1)
-		responseMsg.AvailabilityResponseMessage.MaxJob = int32(1)
+		// We receive a message from a leader to check our availability.
+		responseMsg := s.newAvailabilityResponse(tssMsg.From)
+		log.Info("Member: Responding ", responseMsg.AvailabilityResponseMessage.Answer, " to leader's request")
 
 		go s.dispatcher.UnicastMessage(sender, responseMsg)
 	} else {
@@ -467,6 +633,14 @@ func (s *PreworkSelection) onPreExecutionRequest(tssMsg *commonTypes.TssMessage)
 	return nil
 }
 
+// newAvailabilityResponse creates the answer of this node to the leader's availability request
+// from the current load of this node.
+func (s *PreworkSelection) newAvailabilityResponse(leader string) *commonTypes.TssMessage {
+	answer, maxJob := s.availability.GetAvailability(s.request)
+	return common.NewAvailabilityResponseMessage(s.myPid.Id, leader, s.request.WorkId, answer, maxJob,
+		s.getPresignCount())
+}
+
 func (s *PreworkSelection) onPreExecutionResponse(tssMsg *commonTypes.TssMessage) error {
 	// TODO: Check if we have finished selection process. Otherwise, this could create a blocking
 	// operation.
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/sisu-network/dheart/core/cache"
 	"github.com/sisu-network/dheart/core/components"
@@ -11,6 +12,8 @@ import (
 	"github.com/sisu-network/dheart/db"
 	"github.com/sisu-network/dheart/types/common"
 	"github.com/sisu-network/dheart/worker/types"
+	libchain "github.com/sisu-network/lib/chain"
+	ecsigning "github.com/sisu-network/tss-lib/ecdsa/signing"
 	"github.com/sisu-network/tss-lib/tss"
 	"github.com/stretchr/testify/require"
 )
@@ -70,6 +73,8 @@ func TestPreworkSelection_SuccessfulSeleciton(t *testing.T) {
 			cache.NewMessageCache(),
 			dispatcher,
 			components.NewAvailPresignManager(dbInstance),
+			&MockWorkerCallback{},
+			&MockWorkerCallback{},
 			config.NewDefaultTimeoutConfig(),
 			cb,
 		)
@@ -84,3 +89,332 @@ func TestPreworkSelection_SuccessfulSeleciton(t *testing.T) {
 
 	done.Wait()
 }
+
+func TestPreworkSelection_PeerDown(t *testing.T) {
+	n := 4
+	pIDs := GetTestPartyIds(n)
+
+	workId := "eddsaKeygen"
+	leader := ChooseLeader(workId, pIDs)
+	var downPid *tss.PartyID
+	for _, pid := range pIDs {
+		if pid.Id != leader.Id {
+			downPid = pid
+			break
+		}
+	}
+
+	selections := make(map[string]*PreworkSelection)
+	lock := &sync.RWMutex{}
+	deliver := func(dest *tss.PartyID, tssMessage *common.TssMessage) {
+		lock.RLock()
+		selection := selections[dest.Id]
+		lock.RUnlock()
+
+		if selection != nil {
+			selection.ProcessNewMessage(tssMessage)
+		}
+	}
+	dispatcher := &MockMessageDispatcher{
+		BroadcastMessageFunc: func(pIDs []*tss.PartyID, tssMessage *common.TssMessage) {
+			for _, pid := range pIDs {
+				if pid.Id != tssMessage.From {
+					deliver(pid, tssMessage)
+				}
+			}
+		},
+		UnicastMessageFunc: deliver,
+	}
+	peerHealth := &MockWorkerCallback{
+		IsPeerDownFunc: func(pid string) bool {
+			return pid == downPid.Id
+		},
+	}
+
+	// Keygen requires all parties. The leader knows that one party is down and fails the selection
+	// without waiting for the timeout.
+	cfg := config.NewDefaultTimeoutConfig()
+	done := &sync.WaitGroup{}
+	done.Add(n - 1)
+	lock.Lock()
+	for i := 0; i < n; i++ {
+		if pIDs[i].Id == downPid.Id {
+			continue
+		}
+
+		dbInstance := getDb(i)
+		selections[pIDs[i].Id] = NewPreworkSelection(
+			types.NewEdKeygenRequest(workId, pIDs, 2),
+			pIDs,
+			pIDs[i],
+			dbInstance,
+			cache.NewMessageCache(),
+			dispatcher,
+			components.NewAvailPresignManager(dbInstance),
+			peerHealth,
+			peerHealth,
+			cfg,
+			func(result SelectionResult) {
+				require.False(t, result.Success)
+				done.Done()
+			},
+		)
+		selections[pIDs[i].Id].Init()
+	}
+	lock.Unlock()
+
+	start := time.Now()
+	for _, selection := range selections {
+		go selection.Run(make([]*common.TssMessage, 0))
+	}
+
+	done.Wait()
+	require.Less(t, time.Since(start), cfg.SelectionLeaderTimeout)
+}
+
+// runSelections runs the selection of a work on all parties with the given callbacks and returns
+// the selection results of all parties.
+func runSelections(t *testing.T, request *types.WorkRequest, pIDs tss.SortedPartyIDs,
+	callbacks []*MockWorkerCallback, cfg config.TimeoutConfig) []SelectionResult {
+	n := len(pIDs)
+	selections := make([]*PreworkSelection, n)
+	lock := &sync.RWMutex{}
+	deliver := func(dest *tss.PartyID, tssMessage *common.TssMessage) {
+		lock.RLock()
+		defer lock.RUnlock()
+
+		for _, selection := range selections {
+			if selection.myPid.Id == dest.Id {
+				selection.ProcessNewMessage(tssMessage)
+				break
+			}
+		}
+	}
+	dispatcher := &MockMessageDispatcher{
+		BroadcastMessageFunc: func(pIDs []*tss.PartyID, tssMessage *common.TssMessage) {
+			for _, pid := range pIDs {
+				if pid.Id != tssMessage.From {
+					deliver(pid, tssMessage)
+				}
+			}
+		},
+		UnicastMessageFunc: deliver,
+	}
+
+	results := make([]SelectionResult, n)
+	done := &sync.WaitGroup{}
+	done.Add(n)
+	lock.Lock()
+	for i := 0; i < n; i++ {
+		i := i
+		dbInstance := getDb(i)
+		selections[i] = NewPreworkSelection(request, pIDs, pIDs[i], dbInstance, cache.NewMessageCache(),
+			dispatcher, components.NewAvailPresignManager(dbInstance), callbacks[i], callbacks[i], cfg,
+			func(result SelectionResult) {
+				results[i] = result
+				done.Done()
+			},
+		)
+		selections[i].Init()
+	}
+	lock.Unlock()
+
+	for _, selection := range selections {
+		go selection.Run(make([]*common.TssMessage, 0))
+	}
+	done.Wait()
+
+	return results
+}
+
+func TestPreworkSelection_BusyMembers(t *testing.T) {
+	t.Parallel()
+
+	n := 4
+	pIDs := GetTestPartyIds(n)
+	workId := "eddsaSigning"
+	request := types.NewEdSigningRequest(workId, pIDs, 1, [][]byte{[]byte("message")}, []string{"eth"}, nil)
+	leader := ChooseLeader(workId, request.AllParties)
+
+	// All members are busy. One of them does not hold the key and another one has more free worker
+	// slots than the others.
+	callbacks := make([]*MockWorkerCallback, n)
+	var capable, incapable *tss.PartyID
+	for i, pid := range pIDs {
+		maxJob := 1
+		if pid.Id != leader.Id {
+			if incapable == nil {
+				incapable, maxJob = pid, 0
+			} else if capable == nil {
+				capable, maxJob = pid, 2
+			}
+		}
+
+		callbacks[i] = &MockWorkerCallback{
+			GetAvailabilityFunc: func(request *types.WorkRequest) (common.AvailabilityResponseMessage_ANSWER, int) {
+				return common.AvailabilityResponseMessage_NO, maxJob
+			},
+		}
+	}
+
+	// Signing is mandatory. The leader selects the busy member with the most free slots once all
+	// members have answered.
+	cfg := config.NewDefaultTimeoutConfig()
+	start := time.Now()
+	results := runSelections(t, request, pIDs, callbacks, cfg)
+	require.Less(t, time.Since(start), cfg.SelectionLeaderTimeout)
+
+	for i, result := range results {
+		require.True(t, result.Success)
+		require.Len(t, result.SelectedPids, 2)
+		selected := []string{result.SelectedPids[0].Id, result.SelectedPids[1].Id}
+		require.ElementsMatch(t, []string{leader.Id, capable.Id}, selected)
+		require.Equal(t, pIDs[i].Id != leader.Id && pIDs[i].Id != capable.Id, result.IsNodeExcluded)
+	}
+
+	// A presign is optional. The leader does not force busy members to do it.
+	request = types.NewEcSigningRequest("presign", pIDs, 1, nil, nil, nil)
+	start = time.Now()
+	results = runSelections(t, request, pIDs, callbacks, cfg)
+	require.Less(t, time.Since(start), cfg.SelectionLeaderTimeout)
+
+	for _, result := range results {
+		require.False(t, result.Success)
+	}
+}
+
+func TestPreworkSelection_PresignUsedOnce(t *testing.T) {
+	t.Parallel()
+
+	n := 4
+	pIDs := GetTestPartyIds(n)
+	dbInstance := getDb(0)
+	require.Nil(t, dbInstance.Init())
+
+	presignsManager := components.NewAvailPresignManager(dbInstance)
+	presignData := []*ecsigning.SignatureData_OneRoundData{{PartyId: pIDs[0].Id}}
+	presignsManager.AddPresign("", "presign", pIDs, presignData)
+	presignIds := []string{"presign-0"}
+
+	// Two leaders pick the same presign for different signings. The member only joins the first one.
+	for i, workId := range []string{"ecdsaSigning0", "ecdsaSigning1"} {
+		request := types.NewEcSigningRequest(workId, pIDs, 1, [][]byte{[]byte("message")}, []string{"eth"}, nil)
+		leader := ChooseLeader(workId, request.AllParties)
+
+		var result SelectionResult
+		selection := NewPreworkSelection(request, pIDs, pIDs[0], dbInstance, cache.NewMessageCache(),
+			&MockMessageDispatcher{}, presignsManager, &MockWorkerCallback{}, &MockWorkerCallback{},
+			config.NewDefaultTimeoutConfig(), func(r SelectionResult) {
+				result = r
+			},
+		)
+		selection.Init()
+
+		msg := common.NewPreExecOutputMessage(leader.Id, "", workId, true, presignIds, pIDs)
+		selection.memberFinalized(msg.PreExecOutputMessage)
+
+		if i == 0 {
+			require.True(t, result.Success)
+			require.Equal(t, presignIds, result.PresignIds)
+		} else {
+			require.False(t, result.Success)
+		}
+	}
+
+	// The presign is not offered to this node as a leader either.
+	allPids := make(map[string]*tss.PartyID)
+	for _, pid := range pIDs {
+		allPids[pid.Id] = pid
+	}
+	ids, _ := presignsManager.GetAvailablePresigns(libchain.KEY_TYPE_ECDSA, "", 1, n, allPids)
+	require.Empty(t, ids)
+}
+
+func TestPreworkSelection_BusyLeader(t *testing.T) {
+	t.Parallel()
+
+	n := 4
+	pIDs := GetTestPartyIds(n)
+	request := types.NewEcSigningRequest("presign", pIDs, 1, nil, nil, nil)
+	leader := ChooseLeader(request.WorkId, request.AllParties)
+
+	dispatcher := &MockMessageDispatcher{
+		UnicastMessageFunc: func(dest *tss.PartyID, tssMessage *common.TssMessage) {
+			require.Fail(t, "a busy leader must not ask for the availability of the members")
+		},
+	}
+	callback := &MockWorkerCallback{
+		GetAvailabilityFunc: func(request *types.WorkRequest) (common.AvailabilityResponseMessage_ANSWER, int) {
+			return common.AvailabilityResponseMessage_NO, 1
+		},
+	}
+
+	done := make(chan SelectionResult, 1)
+	dbInstance := getDb(0)
+	selection := NewPreworkSelection(request, pIDs, leader, dbInstance, cache.NewMessageCache(), dispatcher,
+		components.NewAvailPresignManager(dbInstance), callback, callback, config.NewDefaultTimeoutConfig(),
+		func(result SelectionResult) {
+			done <- result
+		},
+	)
+	selection.Init()
+	go selection.Run(make([]*common.TssMessage, 0))
+
+	// A presign is optional. The leader does not start it when it is busy itself.
+	select {
+	case result := <-done:
+		require.False(t, result.Success)
+	case <-time.After(time.Second):
+		require.Fail(t, "the selection of a busy leader must fail immediately")
+	}
+}
+
+func TestPreworkSelection_PresignCount(t *testing.T) {
+	t.Parallel()
+
+	n := 3
+	pIDs := GetTestPartyIds(n)
+	workId := "ecdsaSigning"
+	request := types.NewEcSigningRequest(workId, pIDs, 1, [][]byte{[]byte("message")}, []string{"eth"}, nil)
+	leader := ChooseLeader(workId, request.AllParties)
+
+	var candidates map[string]*tss.PartyID
+	presignsManager := &components.MockAvailablePresigns{
+		GetPresignCountFunc: func(keyType string, keyLabel string) int {
+			return 1
+		},
+		GetAvailablePresignsFunc: func(keyType string, keyLabel string, batchSize int, n int,
+			allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID) {
+			candidates = allPids
+			return []string{}, []*tss.PartyID{}
+		},
+	}
+	selection := NewPreworkSelection(request, pIDs, leader, nil, cache.NewMessageCache(),
+		&MockMessageDispatcher{}, presignsManager, &MockWorkerCallback{}, &MockWorkerCallback{},
+		config.NewDefaultTimeoutConfig(), func(result SelectionResult) {},
+	)
+	selection.Init()
+
+	// Members tell the leader how many presigns of the key they hold.
+	response := selection.newAvailabilityResponse(leader.Id)
+	require.Equal(t, int32(1), response.AvailabilityResponseMessage.PresignCount)
+
+	// The leader only looks for presigns held by all the parties of the set.
+	selection.availableParties.add(leader, 1, selection.getPresignCount())
+	members := make([]*tss.PartyID, 0, n-1)
+	for _, pid := range pIDs {
+		if pid.Id != leader.Id {
+			members = append(members, pid)
+		}
+	}
+	selection.addMemberResponse(members[0], &common.AvailabilityResponseMessage{MaxJob: 1, PresignCount: 1})
+	selection.addMemberResponse(members[1], &common.AvailabilityResponseMessage{MaxJob: 1})
+	selection.checkEnoughParticipants()
+	require.Len(t, candidates, 2)
+	require.Contains(t, candidates, leader.Id)
+	require.Contains(t, candidates, members[0].Id)
+
+	// A child key cannot be signed with presigns.
+	request.DerivationPath = "m/0"
+	require.Zero(t, selection.getPresignCount())
+}
@@ -2,7 +2,10 @@ package types
 
 import (
 	"errors"
+	"time"
 
+	schnorrkeygen "github.com/sisu-network/dheart/schnorr/keygen"
+	htypes "github.com/sisu-network/dheart/types"
 	"github.com/sisu-network/lib/log"
 	"github.com/sisu-network/tss-lib/ecdsa/keygen"
 	eckeygen "github.com/sisu-network/tss-lib/ecdsa/keygen"
@@ -10,6 +13,15 @@ import (
 	"github.com/sisu-network/tss-lib/tss"
 )
 
+// Priority classes of works in the request queue. Works with higher priority are executed first.
+// Works in the same class are executed in the order they were added.
+const (
+	PriorityKeygen        = 100
+	PriorityForcedPresign = 90
+	PrioritySigning       = 80
+	PriorityPresign       = 60
+)
+
 type WorkRequest struct {
 	WorkType      WorkType
 	AllParties    []*tss.PartyID
@@ -18,10 +30,17 @@ type WorkRequest struct {
 	ForcedPresign bool
 	BatchSize     int
 
+	// The time after which this work is considered stale and should not be started anymore. A zero
+	// value means the work never expires.
+	Deadline time.Time
+
 	// Used only for keygen, presign & signing
 	KeygenType  string
 	KeygenIndex int
 	Threshold   int
+	// Label of the key generated or used by this work. Several keys of the same key type are
+	// distinguished by their labels. The empty label is the default key.
+	KeyLabel string
 
 	// Used for ecdsa
 	EcKeygenInput  *eckeygen.LocalPreParams
@@ -30,6 +49,14 @@ type WorkRequest struct {
 	// Eddsa
 	EdSigningInput *edkeygen.LocalPartySaveData
 
+	// Schnorr
+	SchnorrSigningInput *schnorrkeygen.LocalPartySaveData
+
+	// The derivation path of the child key used by a signing work. The signing input is already
+	// derived for this path. Presigns are made for the TSS key itself and cannot be used with a child
+	// key.
+	DerivationPath string
+
 	// Used for signing
 	Messages [][]byte // TODO: Make this a byte array
 	Chains   []string
@@ -73,6 +100,33 @@ func NewEdSigningRequest(workId string, pIds tss.SortedPartyIDs, threshold int,
 	return request
 }
 
+// NewEdPresignRequest creates a work that makes batchSize presigns of an EdDSA key. The presigns
+// can later be used by EdDSA signing works of the same parties.
+func NewEdPresignRequest(workId string, pIds tss.SortedPartyIDs, threshold int, batchSize int,
+	inputs *edkeygen.LocalPartySaveData) *WorkRequest {
+	request := baseRequest(EdPresign, workId, len(pIds), threshold, pIds, batchSize)
+	request.EdSigningInput = inputs
+
+	return request
+}
+
+func NewSchnorrKeygenRequest(workId string, pIds tss.SortedPartyIDs, threshold int) *WorkRequest {
+	request := baseRequest(SchnorrKeygen, workId, len(pIds), threshold, pIds, 1)
+	request.KeygenType = htypes.KeyTypeSchnorr
+
+	return request
+}
+
+func NewSchnorrSigningRequest(workId string, pIds tss.SortedPartyIDs, threshold int, messages [][]byte,
+	chains []string, inputs *schnorrkeygen.LocalPartySaveData) *WorkRequest {
+	request := baseRequest(SchnorrSigning, workId, len(pIds), threshold, pIds, len(messages))
+	request.SchnorrSigningInput = inputs
+	request.Messages = messages
+	request.Chains = chains
+
+	return request
+}
+
 func baseRequest(workType WorkType, workdId string, n int, threshold int, pIDs tss.SortedPartyIDs, batchSize int) *WorkRequest {
 	// Make a copy of pids so that when we sort pids, it does not change the indexes in the original pids.
 	copy := make([]*tss.PartyID, len(pIDs))
@@ -97,6 +151,9 @@ func (request *WorkRequest) Validate() error {
 	case EcSigning:
 	case EdKeygen:
 	case EdSigning:
+	case EdPresign:
+	case SchnorrKeygen:
+	case SchnorrSigning:
 	default:
 		return errors.New("Invalid request type")
 	}
@@ -114,13 +171,22 @@ func (request *WorkRequest) GetMinPartyCount() int {
 
 func (request *WorkRequest) GetPriority() int {
 	// Keygen
-	if request.WorkType == EcKeygen || request.WorkType == EdKeygen {
-		return 100
+	if request.IsKeygen() {
+		return PriorityKeygen
+	}
+
+	// Presign
+	if request.IsPresign() {
+		if request.ForcedPresign {
+			return PriorityForcedPresign
+		}
+
+		return PriorityPresign
 	}
 
 	// Signing
-	if request.WorkType == EcSigning || request.WorkType == EdSigning {
-		return 80
+	if request.IsSigning() {
+		return PrioritySigning
 	}
 
 	log.Critical("Unknown work type", request.WorkType)
@@ -128,16 +194,36 @@ func (request *WorkRequest) GetPriority() int {
 	return -1
 }
 
+// IsExpired returns true if the request has a deadline and the deadline has passed.
+func (request *WorkRequest) IsExpired(now time.Time) bool {
+	return !request.Deadline.IsZero() && now.After(request.Deadline)
+}
+
+// IsMandatory returns true if this work must be done even when there are not enough parties that
+// are free to do it. Only non-forced presigns are optional.
+func (request *WorkRequest) IsMandatory() bool {
+	return !request.IsPresign() || request.ForcedPresign
+}
+
 func (request *WorkRequest) IsKeygen() bool {
-	return request.WorkType == EcKeygen || request.WorkType == EdKeygen
+	return request.WorkType.IsKeygen()
 }
 
 func (request *WorkRequest) IsSigning() bool {
-	return request.WorkType == EcSigning || request.WorkType == EdSigning
+	return request.WorkType.IsSigning()
 }
 
 func (request *WorkRequest) IsEcPresign() bool {
-	return request.WorkType == EcSigning && request.Messages[0] == nil
+	return request.WorkType == EcSigning && (len(request.Messages) == 0 || request.Messages[0] == nil)
+}
+
+func (request *WorkRequest) IsEdPresign() bool {
+	return request.WorkType == EdPresign
+}
+
+// IsPresign returns true if this work makes presigns instead of signatures.
+func (request *WorkRequest) IsPresign() bool {
+	return request.IsEcPresign() || request.IsEdPresign()
 }
 
 func (request *WorkRequest) IsEcdsa() bool {
@@ -145,5 +231,9 @@ func (request *WorkRequest) IsEcdsa() bool {
 }
 
 func (request *WorkRequest) IsEddsa() bool {
-	return request.WorkType == EdKeygen || request.WorkType == EdSigning
+	return request.WorkType == EdKeygen || request.WorkType == EdSigning || request.WorkType == EdPresign
+}
+
+func (request *WorkRequest) IsSchnorr() bool {
+	return request.WorkType == SchnorrKeygen || request.WorkType == SchnorrSigning
 }
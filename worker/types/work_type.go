@@ -8,6 +8,10 @@ const (
 
 	EdKeygen
 	EdSigning
+	EdPresign
+
+	SchnorrKeygen
+	SchnorrSigning
 )
 
 var (
@@ -17,6 +21,10 @@ var (
 
 		EdKeygen:  "EDDSA_KEYGEN",
 		EdSigning: "EDDSA_SIGNING",
+		EdPresign: "EDDSA_PRESIGN",
+
+		SchnorrKeygen:  "SCHNORR_KEYGEN",
+		SchnorrSigning: "SCHNORR_SIGNING",
 	}
 )
 
@@ -25,9 +33,10 @@ func (w WorkType) String() string {
 }
 
 func (w WorkType) IsKeygen() bool {
-	return w == EcKeygen || w == EdKeygen
+	return w == EcKeygen || w == EdKeygen || w == SchnorrKeygen
 }
 
+// IsSigning returns true for signing works and for presign works that prepare signing works.
 func (w WorkType) IsSigning() bool {
-	return w == EcSigning || w == EdSigning
+	return w == EcSigning || w == EdSigning || w == EdPresign || w == SchnorrSigning
 }
@@ -1,7 +1,9 @@
 package worker
 
 import (
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
 	commonTypes "github.com/sisu-network/dheart/types/common"
+	"github.com/sisu-network/dheart/worker/interfaces"
 	"github.com/sisu-network/dheart/worker/types"
 	ecsigning "github.com/sisu-network/tss-lib/ecdsa/signing"
 	"github.com/sisu-network/tss-lib/tss"
@@ -28,12 +30,17 @@ type Worker interface {
 // A callback for the caller to receive updates from this worker. We use callback instead of Go
 // channel to avoid creating too many channels.
 type WorkerCallback interface {
-	// GetAvailablePresigns returns a list of presign output that will be used for signing. The presign's
-	// party ids should match the pids params passed into the function.
-	GetAvailablePresigns(batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID)
+	interfaces.PeerHealth
+	interfaces.Availability
+
+	// GetAvailablePresigns returns a list of presign output of a key that will be used for signing.
+	// The presign's party ids should match the pids params passed into the function.
+	GetAvailablePresigns(keyType string, keyLabel string, batchSize int, n int, allPids map[string]*tss.PartyID) ([]string, []*tss.PartyID)
 
 	GetPresignOutputs(presignIds []string) []*ecsigning.SignatureData_OneRoundData
 
+	GetEdPresignOutputs(presignIds []string) []*edpresign.PresignData
+
 	OnNodeNotSelected(request *types.WorkRequest)
 
 	OnWorkFailed(request *types.WorkRequest)
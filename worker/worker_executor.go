@@ -6,13 +6,14 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/sisu-network/dheart/blame"
 	"github.com/sisu-network/dheart/core/config"
 	"github.com/sisu-network/dheart/core/message"
 	"github.com/sisu-network/dheart/db"
+	edpresign "github.com/sisu-network/dheart/eddsa/presign"
 	"github.com/sisu-network/dheart/types/common"
 	commonTypes "github.com/sisu-network/dheart/types/common"
 	"github.com/sisu-network/dheart/worker/components"
-	"github.com/sisu-network/dheart/worker/helper"
 	"github.com/sisu-network/dheart/worker/interfaces"
 	"github.com/sisu-network/dheart/worker/types"
 	wTypes "github.com/sisu-network/dheart/worker/types"
@@ -23,6 +24,10 @@ import (
 	"go.uber.org/atomic"
 )
 
+var (
+	ErrInvalidUpdateMessage = errors.New("invalid update message")
+)
+
 type ExecutionResult struct {
 	Success bool
 	Request *types.WorkRequest
@@ -42,12 +47,16 @@ type WorkerExecutor struct {
 	pIDsMap    map[string]*tss.PartyID
 	dispatcher interfaces.MessageDispatcher
 	db         db.Database
+	blameMgr   *blame.Manager
 	cfg        config.TimeoutConfig
 
 	// ECDSA Input
 	ecKeygenInput   *eckeygen.LocalPreParams
 	ecPresignOutput []*ecsigning.SignatureData_OneRoundData
 
+	// EDDSA Input
+	edPresignOutput []*edpresign.PresignData
+
 	callback func(*WorkerExecutor, ExecutionResult)
 
 	///////////////////////
@@ -67,6 +76,10 @@ type WorkerExecutor struct {
 	jobs           []*Job
 	jobsLock       *sync.RWMutex
 	messageMonitor components.MessageMonitor
+
+	// The messages that were missing when the message monitor last checked, by sender.
+	missingMessages map[string][]string
+	missingLock     *sync.RWMutex
 }
 
 func NewWorkerExecutor(
@@ -77,6 +90,8 @@ func NewWorkerExecutor(
 	dispatcher interfaces.MessageDispatcher,
 	db db.Database,
 	ecPresignOutput []*ecsigning.SignatureData_OneRoundData,
+	edPresignOutput []*edpresign.PresignData,
+	blameMgr *blame.Manager,
 	callback func(*WorkerExecutor, ExecutionResult),
 	cfg config.TimeoutConfig,
 ) *WorkerExecutor {
@@ -106,11 +121,14 @@ func NewWorkerExecutor(
 		pIDsMap:         pIDsMap,
 		dispatcher:      dispatcher,
 		db:              db,
+		blameMgr:        blameMgr,
 		callback:        callback,
 		ecPresignOutput: ecPresignOutput,
+		edPresignOutput: edPresignOutput,
 		jobsLock:        &sync.RWMutex{},
 		jobOutputLock:   &sync.RWMutex{},
 		finalOutputLock: &sync.RWMutex{},
+		missingLock:     &sync.RWMutex{},
 		jobResults:      make([]*JobResult, request.BatchSize),
 		jobOutput:       make(map[string][]tss.Message),
 		isStopped:       *atomic.NewBool(false),
@@ -121,13 +139,16 @@ func NewWorkerExecutor(
 func (w *WorkerExecutor) Init() (err error) {
 	if w.workType == wTypes.EcKeygen {
 		if w.request.EcKeygenInput == nil {
-			err = w.loadPreparams()
+			if err = w.loadPreparams(); err != nil {
+				return err
+			}
 		} else {
 			w.ecKeygenInput = w.request.EcKeygenInput
 		}
 	}
 
-	w.messageMonitor = components.NewMessageMonitor(w.myPid, w.workType, w, w.pIDsMap, w.cfg.MonitorMessageTimeout)
+	w.messageMonitor = components.NewMessageMonitor(w.myPid, w.workType, w.hasPresignData(), w, w.pIDsMap,
+		w.cfg.MonitorMessageTimeout)
 	go w.messageMonitor.Start()
 
 	p2pCtx := tss.NewPeerContext(w.pIDs)
@@ -159,7 +180,23 @@ func (w *WorkerExecutor) Init() (err error) {
 			jobs[i] = NewEdKeygenJob(workId, i, w.pIDs, params, w, w.cfg.KeygenJobTimeout)
 
 		case wTypes.EdSigning:
-			jobs[i] = NewEdSigningJob(workId, i, w.pIDs, params, []byte(w.request.Messages[i]), *w.request.EdSigningInput, w, w.cfg.SigningJobTimeout)
+			var presignOutput *edpresign.PresignData
+			if w.hasPresignData() {
+				presignOutput = w.edPresignOutput[i]
+			}
+			jobs[i] = NewEdSigningJob(workId, i, w.pIDs, params, []byte(w.request.Messages[i]), *w.request.EdSigningInput,
+				presignOutput, w, w.cfg.SigningJobTimeout)
+
+		case wTypes.EdPresign:
+			jobs[i] = NewEdPresignJob(workId, i, w.pIDs, params, w, w.cfg.SigningJobTimeout)
+
+		// Schnorr
+		case wTypes.SchnorrKeygen:
+			jobs[i] = NewSchnorrKeygenJob(workId, i, w.pIDs, params, w, w.cfg.KeygenJobTimeout)
+
+		case wTypes.SchnorrSigning:
+			jobs[i] = NewSchnorrSigningJob(workId, i, w.pIDs, params, w.request.Messages[i], *w.request.SchnorrSigningInput,
+				w, w.cfg.SigningJobTimeout)
 
 		default:
 			// If job type is not correct, kill the whole worker.
@@ -208,18 +245,30 @@ func (w *WorkerExecutor) Run(cachedMsgs []*commonTypes.TssMessage) {
 	}
 }
 
+// hasPresignData returns true if this is a signing work that signs with presigns.
+func (w *WorkerExecutor) hasPresignData() bool {
+	switch w.workType {
+	case wTypes.EcSigning:
+		return len(w.ecPresignOutput) > 0 && w.ecPresignOutput[0] != nil
+	case wTypes.EdSigning:
+		return len(w.edPresignOutput) > 0
+	default:
+		return false
+	}
+}
+
+// loadPreparams takes fresh preparams from the preparams pool for this keygen. Preparams are never
+// shared between keys.
 func (w *WorkerExecutor) loadPreparams() error {
-	// Check if we have generated preparams
-	var err error
-	preparams, err := w.db.LoadPreparams()
-	if err == nil {
-		log.Info("Preparams found")
-		w.ecKeygenInput = preparams
-	} else {
+	preparams, err := w.db.ConsumePreparams(w.request.WorkId)
+	if err != nil {
 		log.Error("Failed to get preparams, err =", err)
 		return err
 	}
 
+	log.Info("Preparams found")
+	w.ecKeygenInput = preparams
+
 	return nil
 }
 
@@ -277,17 +326,23 @@ func (w *WorkerExecutor) OnJobResult(job *Job, result JobResult) {
 	w.jobResults[job.index] = &result
 	count := 0
 	hasFailure := false
+	hasTimeout := false
 	for _, result := range w.jobResults {
 		if result != nil {
 			count++
 			if !result.Success {
 				hasFailure = true
+				hasTimeout = hasTimeout || result.Failure == JobFailureTimeout
 			}
 		}
 	}
 	w.finalOutputLock.Unlock()
 
 	if count == w.request.BatchSize {
+		if hasTimeout {
+			w.blameMissingSenders()
+		}
+
 		if hasFailure {
 			// If any of the job fails, this is considered to be a failure.
 			w.broadcastResult(ExecutionResult{
@@ -308,6 +363,10 @@ func (w *WorkerExecutor) OnMissingMesssageDetected(m map[string][]string) {
 		return
 	}
 
+	w.missingLock.Lock()
+	w.missingMessages = m
+	w.missingLock.Unlock()
+
 	workId := w.request.WorkId
 	// We have found missing messages
 	for pid, msgTypes := range m {
@@ -332,35 +391,32 @@ func (w *WorkerExecutor) ProcessUpdateMessage(tssMsg *commonTypes.TssMessage) er
 		return nil
 	}
 
-	// Do all message validation first before processing.
-	// TODO: Add more validation here.
-	msgs := make([]tss.ParsedMessage, w.request.BatchSize)
-	// Now update all messages
-	w.jobsLock.RLock()
-	jobs := w.jobs
-	w.jobsLock.RUnlock()
-
-	fromString := tssMsg.From
-	from := helper.GetPidFromString(fromString, w.pIDs)
+	from := w.pIDsMap[tssMsg.From]
 	if from == nil {
-		return errors.New("sender is nil")
+		return fmt.Errorf("%w: sender %s is not selected for this work", ErrInvalidUpdateMessage, tssMsg.From)
 	}
 
-	for i := range jobs {
-		updateMessage := tssMsg.UpdateMessages[i]
-		msg, err := tss.ParseWireMessage(updateMessage.Data, from, tssMsg.IsBroadcast())
-		if err != nil {
-			return fmt.Errorf("error when parsing wire message %w", err)
-		}
-
-		msgRouting := tss.MessageRouting{}
-		if err := json.Unmarshal(updateMessage.SerializedMessageRouting, &msgRouting); err != nil {
-			return fmt.Errorf("error when unmarshal message routing %w", err)
+	// Do all message validation first before processing. A party that sends an invalid message is
+	// blamed and the work fails since the message cannot be fixed by asking for it again.
+	msgs, err := w.parseUpdateMessage(tssMsg, from)
+	if err != nil {
+		round := ""
+		if len(tssMsg.UpdateMessages) > 0 {
+			round = tssMsg.UpdateMessages[0].Round
 		}
+		w.blame(round, []*tss.PartyID{from})
 
-		msgs[i] = msg
+		w.broadcastResult(ExecutionResult{
+			Success: false,
+		})
+		return err
 	}
 
+	// Now update all messages
+	w.jobsLock.RLock()
+	jobs := w.jobs
+	w.jobsLock.RUnlock()
+
 	// Update the message monitor
 	w.messageMonitor.NewMessageReceived(msgs[0], from)
 
@@ -374,6 +430,7 @@ func (w *WorkerExecutor) ProcessUpdateMessage(tssMsg *commonTypes.TssMessage) er
 
 			if err := job.processMessage(msgs[jobIndex]); err != nil {
 				log.Error("worker: cannot process message, err = ", err)
+				w.blame(msgs[jobIndex].Type(), err.Culprits())
 
 				w.broadcastResult(ExecutionResult{
 					Success: false,
@@ -386,6 +443,116 @@ func (w *WorkerExecutor) ProcessUpdateMessage(tssMsg *commonTypes.TssMessage) er
 	return nil
 }
 
+// parseUpdateMessage checks an update message from a selected party and parses the tss messages of
+// all jobs in it.
+func (w *WorkerExecutor) parseUpdateMessage(tssMsg *commonTypes.TssMessage, from *tss.PartyID) ([]tss.ParsedMessage, error) {
+	if len(tssMsg.UpdateMessages) != w.request.BatchSize {
+		return nil, fmt.Errorf("%w: expected %d messages, got %d", ErrInvalidUpdateMessage,
+			w.request.BatchSize, len(tssMsg.UpdateMessages))
+	}
+
+	round := tssMsg.UpdateMessages[0].Round
+	if !message.IsMessageOfWorkType(w.workType, w.hasPresignData(), round) {
+		return nil, fmt.Errorf("%w: unexpected round %s for work type %s", ErrInvalidUpdateMessage,
+			round, w.workType)
+	}
+
+	// A party only sends the messages of a round after it has received the messages of the previous
+	// round from every party, including this node.
+	if roundIndex := message.GetRoundIndex(w.workType, w.hasPresignData(), round); roundIndex > w.getCurrentRound()+1 {
+		return nil, fmt.Errorf("%w: round %s is sent before this node finishes the previous round",
+			ErrInvalidUpdateMessage, round)
+	}
+
+	isBroadcast := message.IsBroadcastMessage(round)
+	if isBroadcast != tssMsg.IsBroadcast() {
+		return nil, fmt.Errorf("%w: round %s has broadcast = %t, message has broadcast = %t",
+			ErrInvalidUpdateMessage, round, isBroadcast, tssMsg.IsBroadcast())
+	}
+
+	if !isBroadcast && tssMsg.To != w.myPid.Id {
+		return nil, fmt.Errorf("%w: message is sent to %s", ErrInvalidUpdateMessage, tssMsg.To)
+	}
+
+	msgs := make([]tss.ParsedMessage, w.request.BatchSize)
+	for i, updateMessage := range tssMsg.UpdateMessages {
+		if updateMessage.Round != round {
+			return nil, fmt.Errorf("%w: messages of rounds %s and %s in the same batch",
+				ErrInvalidUpdateMessage, round, updateMessage.Round)
+		}
+
+		msgRouting := tss.MessageRouting{}
+		if err := json.Unmarshal(updateMessage.SerializedMessageRouting, &msgRouting); err != nil {
+			return nil, fmt.Errorf("%w: cannot unmarshal message routing, err = %v", ErrInvalidUpdateMessage, err)
+		}
+
+		if msgRouting.From == nil || msgRouting.From.Id != tssMsg.From {
+			return nil, fmt.Errorf("%w: routing sender does not match the sender %s", ErrInvalidUpdateMessage,
+				tssMsg.From)
+		}
+
+		if msgRouting.IsBroadcast != isBroadcast {
+			return nil, fmt.Errorf("%w: routing has broadcast = %t", ErrInvalidUpdateMessage,
+				msgRouting.IsBroadcast)
+		}
+
+		msg, err := tss.ParseWireMessage(updateMessage.Data, from, isBroadcast)
+		if err != nil {
+			return nil, fmt.Errorf("%w: cannot parse wire message, err = %v", ErrInvalidUpdateMessage, err)
+		}
+
+		if msg.Type() != round {
+			return nil, fmt.Errorf("%w: message of type %s in round %s", ErrInvalidUpdateMessage, msg.Type(),
+				round)
+		}
+
+		msgs[i] = msg
+	}
+
+	return msgs, nil
+}
+
+// getCurrentRound returns the position of the latest round in which the jobs of this work have
+// produced messages, or -1 if they have not produced any message yet.
+func (w *WorkerExecutor) getCurrentRound() int {
+	w.jobOutputLock.RLock()
+	defer w.jobOutputLock.RUnlock()
+
+	current := -1
+	for _, list := range w.jobOutput {
+		for _, msg := range list {
+			if msg == nil {
+				continue
+			}
+
+			if roundIndex := message.GetRoundIndex(w.workType, w.hasPresignData(), msg.Type()); roundIndex > current {
+				current = roundIndex
+			}
+		}
+	}
+
+	return current
+}
+
+// blame records the culprits of a round of this work. The round number is the position of the
+// message type in the messages of the work type, or 0 if the message type is unknown.
+func (w *WorkerExecutor) blame(round string, culprits []*tss.PartyID) {
+	if len(culprits) == 0 {
+		return
+	}
+
+	roundNumber := 0
+	for i, msgType := range message.GetMessagesByWorkType(w.workType, w.hasPresignData()) {
+		if msgType == round {
+			roundNumber = i + 1
+			break
+		}
+	}
+
+	log.Warnf("Work %s: blaming %d parties in round %s", w.request.WorkId, len(culprits), round)
+	w.blameMgr.AddCulpritByRound(w.request.WorkId, uint32(roundNumber), culprits)
+}
+
 func (w *WorkerExecutor) Stop() {
 	w.isStopped.Store(true)
 }
@@ -396,6 +563,32 @@ func (w *WorkerExecutor) OnJobTimeout() {
 	})
 }
 
+// blameMissingSenders blames the parties that have not sent the earliest message that is still
+// missing. Parties that miss messages of later rounds are only waiting for these parties.
+func (w *WorkerExecutor) blameMissingSenders() {
+	w.missingLock.RLock()
+	missingMessages := w.missingMessages
+	w.missingLock.RUnlock()
+
+	allMessages := message.GetMessagesByWorkType(w.workType, w.hasPresignData())
+	for _, msgType := range allMessages {
+		culprits := make([]*tss.PartyID, 0)
+		for pid, msgTypes := range missingMessages {
+			for _, missing := range msgTypes {
+				if missing == msgType && w.pIDsMap[pid] != nil {
+					culprits = append(culprits, w.pIDsMap[pid])
+					break
+				}
+			}
+		}
+
+		if len(culprits) > 0 {
+			w.blame(msgType, culprits)
+			return
+		}
+	}
+}
+
 func (w *WorkerExecutor) broadcastResult(result ExecutionResult) {
 	w.jobsLock.Lock()
 	defer w.jobsLock.Unlock()
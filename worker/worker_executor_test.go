@@ -0,0 +1,176 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sisu-network/dheart/blame"
+	"github.com/sisu-network/dheart/core/config"
+	"github.com/sisu-network/dheart/core/message"
+	"github.com/sisu-network/dheart/types/common"
+	"github.com/sisu-network/dheart/worker/types"
+	edkeygen "github.com/sisu-network/tss-lib/eddsa/keygen"
+	"github.com/sisu-network/tss-lib/tss"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerExecutor_InvalidUpdateMessage(t *testing.T) {
+	t.Parallel()
+
+	pIDs := GetTestPartyIds(3)
+	request := types.NewEdKeygenRequest("keygen", pIDs, 1)
+
+	// newMessage creates a valid round 1 update message from the party at index 1.
+	newMessage := func() *common.TssMessage {
+		msg := edkeygen.NewKGRound1Message(pIDs[1], big.NewInt(1))
+		tssMsg, err := common.NewTssMessage(pIDs[1].Id, "", request.WorkId, []tss.Message{msg}, msg.Type())
+		require.NoError(t, err)
+
+		return tssMsg
+	}
+
+	tests := []struct {
+		name   string
+		modify func(tssMsg *common.TssMessage)
+		// The round 1 messages of this node have been sent.
+		afterRound1 bool
+	}{
+		{
+			name: "wrong batch size",
+			modify: func(tssMsg *common.TssMessage) {
+				tssMsg.UpdateMessages = append(tssMsg.UpdateMessages, tssMsg.UpdateMessages[0])
+			},
+		},
+		{
+			name: "round of another work type",
+			modify: func(tssMsg *common.TssMessage) {
+				tssMsg.UpdateMessages[0].Round = "ecdsa.keygen.KGRound1Message"
+			},
+		},
+		{
+			name: "broadcast round sent as unicast",
+			modify: func(tssMsg *common.TssMessage) {
+				tssMsg.To = pIDs[0].Id
+			},
+		},
+		{
+			name: "routing from another party",
+			modify: func(tssMsg *common.TssMessage) {
+				routing, err := json.Marshal(tss.MessageRouting{From: pIDs[2], IsBroadcast: true})
+				require.NoError(t, err)
+				tssMsg.UpdateMessages[0].SerializedMessageRouting = routing
+			},
+		},
+		{
+			name: "round ahead of this node",
+			modify: func(tssMsg *common.TssMessage) {
+				tssMsg.UpdateMessages[0].Round = "eddsa.keygen.KGRound2Message2"
+			},
+		},
+		{
+			name: "data of another round",
+			modify: func(tssMsg *common.TssMessage) {
+				tssMsg.UpdateMessages[0].Round = "eddsa.keygen.KGRound2Message2"
+			},
+			afterRound1: true,
+		},
+		{
+			name: "corrupted data",
+			modify: func(tssMsg *common.TssMessage) {
+				tssMsg.UpdateMessages[0].Data = []byte{0xff, 0xff, 0xff}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			results := make(chan ExecutionResult, 1)
+			blameMgr := blame.NewManager()
+			executor := NewWorkerExecutor(request, types.EdKeygen, pIDs[0], pIDs, &MockMessageDispatcher{}, nil,
+				nil, nil, blameMgr, func(_ *WorkerExecutor, result ExecutionResult) {
+					results <- result
+				}, config.NewDefaultTimeoutConfig())
+
+			_, err := executor.parseUpdateMessage(newMessage(), pIDs[1])
+			require.NoError(t, err)
+
+			if test.afterRound1 {
+				executor.OnJobMessage(&Job{index: 0}, edkeygen.NewKGRound1Message(pIDs[0], big.NewInt(1)))
+			}
+
+			tssMsg := newMessage()
+			test.modify(tssMsg)
+			err = executor.ProcessUpdateMessage(tssMsg)
+			require.True(t, errors.Is(err, ErrInvalidUpdateMessage), "err = %v", err)
+
+			// The sender is blamed and the work fails.
+			select {
+			case result := <-results:
+				require.False(t, result.Success)
+			case <-time.After(time.Second):
+				t.Fatal("work does not fail")
+			}
+
+			culprits := blameMgr.GetCulprits(request.WorkId, map[string]*tss.PartyID{pIDs[1].Id: pIDs[1]})
+			require.Equal(t, []*tss.PartyID{pIDs[1]}, culprits)
+		})
+	}
+}
+
+func TestWorkerExecutor_UnknownSender(t *testing.T) {
+	t.Parallel()
+
+	pIDs := GetTestPartyIds(3)
+	request := types.NewEdKeygenRequest("keygen", pIDs, 1)
+	blameMgr := blame.NewManager()
+	executor := NewWorkerExecutor(request, types.EdKeygen, pIDs[0], pIDs[:2], &MockMessageDispatcher{}, nil,
+		nil, nil, blameMgr, func(_ *WorkerExecutor, result ExecutionResult) {
+			require.Fail(t, "work must not finish")
+		}, config.NewDefaultTimeoutConfig())
+
+	// A message from a party that is not selected is rejected without failing the work.
+	msg := edkeygen.NewKGRound1Message(pIDs[2], big.NewInt(1))
+	tssMsg, err := common.NewTssMessage(pIDs[2].Id, "", request.WorkId, []tss.Message{msg}, msg.Type())
+	require.NoError(t, err)
+
+	err = executor.ProcessUpdateMessage(tssMsg)
+	require.True(t, errors.Is(err, ErrInvalidUpdateMessage), "err = %v", err)
+	require.Empty(t, blameMgr.GetCulprits(request.WorkId, map[string]*tss.PartyID{pIDs[2].Id: pIDs[2]}))
+}
+
+func TestWorkerExecutor_TimeoutBlamesMissingSenders(t *testing.T) {
+	t.Parallel()
+
+	pIDs := GetTestPartyIds(3)
+	request := types.NewEdKeygenRequest("keygen", pIDs, 1)
+	blameMgr := blame.NewManager()
+	done := make(chan ExecutionResult, 1)
+	executor := NewWorkerExecutor(request, types.EdKeygen, pIDs[0], pIDs, &MockMessageDispatcher{}, nil,
+		nil, nil, blameMgr, func(_ *WorkerExecutor, result ExecutionResult) {
+			done <- result
+		}, config.NewDefaultTimeoutConfig())
+
+	// Party 1 has not sent its round 2 unicast message. Party 2 is waiting for it and has not sent its
+	// next message either, so only party 1 is blamed.
+	allMessages := message.GetMessagesByWorkType(types.EdKeygen, false)
+	executor.OnMissingMesssageDetected(map[string][]string{
+		pIDs[1].Id: {allMessages[1]},
+		pIDs[2].Id: {allMessages[2]},
+	})
+	executor.OnJobResult(&Job{index: 0}, JobResult{Success: false, Failure: JobFailureTimeout})
+
+	result := <-done
+	require.False(t, result.Success)
+
+	allParties := make(map[string]*tss.PartyID)
+	for _, pid := range pIDs {
+		allParties[pid.Id] = pid
+	}
+	require.Equal(t, []*tss.PartyID{pIDs[1]}, blameMgr.GetCulprits(request.WorkId, allParties))
+}